@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// SocketChannel is a channel open on this node, wallets join the channel
+// named after the paymentID of their invoice.
+type SocketChannel struct {
+	ChannelID string    `json:"channelId"`
+	Created   time.Time `json:"created"`
+	// Sent is the number of messages this node sent to the channel.
+	Sent uint64 `json:"sent"`
+	// Received is the number of messages received from clients on the channel,
+	// including clients that have since left.
+	Received uint64         `json:"received"`
+	Clients  []SocketClient `json:"clients"`
+	// Awaits are the round trips waiting on a reply from the channel, only set
+	// when a single channel is read.
+	Awaits []SocketAwait `json:"awaits,omitempty"`
+}
+
+// SocketClient is a websocket connection joined to a channel.
+type SocketClient struct {
+	ClientID  string    `json:"clientId"`
+	ChannelID string    `json:"channelId"`
+	Joined    time.Time `json:"joined"`
+	// Received is the number of messages received from the client.
+	Received uint64 `json:"received"`
+}
+
+// SocketAwait is a message sent to a channel which is waiting on a reply.
+type SocketAwait struct {
+	ChannelID     string    `json:"channelId"`
+	CorrelationID string    `json:"correlationId"`
+	Key           string    `json:"key"`
+	Started       time.Time `json:"started"`
+}
+
+// SocketChannelArgs identifies a channel.
+type SocketChannelArgs struct {
+	ChannelID string `param:"channelID"`
+}
+
+// SocketClientArgs identifies a client.
+type SocketClientArgs struct {
+	ClientID string `param:"clientID"`
+}
+
+// SocketAdminReader reads the channels and connections held by a node.
+type SocketAdminReader interface {
+	// Channels returns every channel open on this node with its clients.
+	Channels(ctx context.Context) ([]SocketChannel, error)
+	// Channel returns a channel with its clients and awaits, a NotFound error
+	// is returned if the channel isn't open on this node.
+	Channel(ctx context.Context, args SocketChannelArgs) (*SocketChannel, error)
+	// Clients returns every client connected to this node.
+	Clients(ctx context.Context) ([]SocketClient, error)
+	// Awaits returns the messages sent to channels on this node waiting on a reply.
+	Awaits(ctx context.Context) ([]SocketAwait, error)
+}
+
+// SocketAdminWriter closes the channels and connections held by a node.
+type SocketAdminWriter interface {
+	// ChannelClose will disconnect every client on a channel, a NotFound
+	// error is returned if the channel isn't open on this node.
+	ChannelClose(ctx context.Context, args SocketChannelArgs) error
+	// ClientClose will disconnect a client, a NotFound error is returned if
+	// the client isn't connected to this node.
+	ClientClose(ctx context.Context, args SocketClientArgs) error
+}
+
+// SocketAdminReaderWriter combines the reader and writer interfaces.
+type SocketAdminReaderWriter interface {
+	SocketAdminReader
+	SocketAdminWriter
+}
+
+// SocketAdminService lets operators inspect and close the channels and
+// connections held by a node.
+type SocketAdminService interface {
+	SocketAdminReaderWriter
+}
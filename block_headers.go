@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// BlockHeaderReader returns block headers, used to check merkle proofs lead to
+// a real block.
+type BlockHeaderReader interface {
+	// BlockHeader returns the header with the block hash, bc.ErrHeaderNotFound is
+	// returned if the header isn't known.
+	BlockHeader(ctx context.Context, blockHash string) (*bc.BlockHeader, error)
+}
+
+// BlockHash returns the hex encoded block hash of a header.
+func BlockHash(bh *bc.BlockHeader) string {
+	return hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(bh.Bytes())))
+}
@@ -0,0 +1,31 @@
+package server
+
+import "context"
+
+// ChannelRole is the access a websocket connection has to a channel.
+type ChannelRole string
+
+// Channel roles a connection can authenticate as.
+const (
+	// ChannelRoleWallet can open a channel and answer the requests sent on it.
+	ChannelRoleWallet ChannelRole = "wallet"
+	// ChannelRolePayer can only join a channel a wallet has opened.
+	ChannelRolePayer ChannelRole = "payer"
+)
+
+// ChannelAuthArgs are the credentials a connection presents for a channel.
+type ChannelAuthArgs struct {
+	ChannelID string
+	// APIKey is a merchant API key, sent by wallets in the X-API-Key header.
+	APIKey string
+	// Token is an HMAC token bound to the channelID and a role.
+	Token string
+}
+
+// ChannelAuthenticator checks the credentials of websocket connections.
+type ChannelAuthenticator interface {
+	// ChannelAuthenticate returns the role the credentials grant on the channel,
+	// a NotAuthenticated error is returned if they are missing or invalid and a
+	// NotAuthorised error if they aren't valid for the channel.
+	ChannelAuthenticate(ctx context.Context, args ChannelAuthArgs) (ChannelRole, error)
+}
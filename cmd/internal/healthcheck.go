@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+)
+
+// healthcheckTimeout is how long the healthcheck command waits for the server.
+const healthcheckTimeout = 5 * time.Second
+
+// Healthcheck will call the liveness endpoint of the server running on this
+// host, returning an error if it doesn't respond with a 200.
+//
+// It's run by the healthcheck command so container images without curl can
+// be checked, the certificate isn't verified as the server is called on localhost.
+func Healthcheck(cfg config.Server) error {
+	host, port, err := net.SplitHostPort(cfg.Port)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read server port '%s'", cfg.Port)
+	}
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
+	}
+	c := &http.Client{
+		Timeout: healthcheckTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		fmt.Sprintf("%s://%s/healthz", scheme, net.JoinHostPort(host, port)), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create healthcheck request")
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "server can't be reached")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("server responded with %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/pprof"
+
+	echoProm "github.com/labstack/echo-contrib/prometheus"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+// Listeners are the echo servers routes are mounted on.
+//
+// Payers call the public server, wallets connect to the wallet server and
+// operators use the ops server. If the wallet or ops listener isn't enabled
+// its routes are mounted on the public server.
+type Listeners struct {
+	Public *echo.Echo
+	Wallet *echo.Echo
+	Ops    *echo.Echo
+	// listeners are the enabled servers with their config, public first.
+	listeners []listener
+}
+
+// listener is an echo server with the config it's served with.
+type listener struct {
+	name string
+	e    *echo.Echo
+	cfg  config.Listener
+	// clientAuth is how client certificates are verified when the listener
+	// has a client CA.
+	clientAuth tls.ClientAuthType
+}
+
+// SetupListeners will set up and return the echo servers of each enabled
+// listener, with metrics and pprof served on the ops server.
+func SetupListeners(cfg *config.Config, l log.Logger) *Listeners {
+	p := echoProm.NewPrometheus("dpp", nil)
+	ls := &Listeners{}
+	ls.Public = newEcho(cfg, l, cfg.Server.Listener)
+	ls.Public.Use(p.HandlerFunc)
+	// payers don't have client certificates, wallets must send them on /ws.
+	ls.add("public", ls.Public, cfg.Server.Listener, tls.VerifyClientCertIfGiven)
+
+	ls.Wallet = ls.Public
+	if cfg.Wallet != nil && cfg.Wallet.Enabled() {
+		ls.Wallet = newEcho(cfg, l, cfg.Wallet.Listener)
+		ls.Wallet.Use(p.HandlerFunc)
+		ls.add("wallet", ls.Wallet, cfg.Wallet.Listener, tls.RequireAndVerifyClientCert)
+	}
+	ls.Ops = ls.Public
+	var opsAuth []echo.MiddlewareFunc
+	if cfg.Ops != nil && cfg.Ops.Enabled() {
+		ls.Ops = newEcho(cfg, l, cfg.Ops.Listener)
+		ls.add("ops", ls.Ops, cfg.Ops.Listener, tls.RequireAndVerifyClientCert)
+		if len(cfg.Ops.Keys) > 0 {
+			opsAuth = append(opsAuth, dppMiddleware.KeyAuth(cfg.Ops.Keys))
+		}
+	}
+
+	ls.Ops.GET(p.MetricsPath, echo.WrapHandler(promhttp.Handler()), opsAuth...)
+	if cfg.Ops != nil && cfg.Ops.Pprof {
+		setupPprof(ls.Ops, opsAuth...)
+	}
+	return ls
+}
+
+// newEcho will set up and return an echo server with the CORS settings of lc.
+func newEcho(cfg *config.Config, l log.Logger, lc config.Listener) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+
+	// Middleware
+	e.Use(middleware.Recover())
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Skipper: func(c echo.Context) bool {
+			return cfg.Logging.Level != config.LogDebug
+		},
+	}))
+	e.Use(middleware.RequestID())
+	// echo allows any origin if none are set, so CORS is left off instead.
+	if len(lc.CORSOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:  lc.CORSOrigins,
+			AllowHeaders:  lc.CORSHeaders,
+			ExposeHeaders: []string{"ETag"},
+		}))
+	}
+	if cfg.Server.SecurityHeaders {
+		e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
+			ContentTypeNosniff:    "nosniff",
+			XFrameOptions:         "DENY",
+			HSTSMaxAge:            cfg.Server.HSTSMaxAge,
+			ContentSecurityPolicy: "frame-ancestors 'none'",
+		}))
+	}
+	e.HTTPErrorHandler = dppMiddleware.ErrorHandler(l)
+	return e
+}
+
+// setupPprof will serve the runtime profiles under /debug/pprof.
+func setupPprof(e *echo.Echo, m ...echo.MiddlewareFunc) {
+	e.GET("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)), m...)
+	e.GET("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)), m...)
+	e.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)), m...)
+	e.GET("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)), m...)
+	e.POST("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)), m...)
+	e.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)), m...)
+}
+
+func (ls *Listeners) add(name string, e *echo.Echo, cfg config.Listener, clientAuth tls.ClientAuthType) {
+	ls.listeners = append(ls.listeners, listener{name: name, e: e, cfg: cfg, clientAuth: clientAuth})
+}
+
+// separateWallet returns true if wallets connect to their own listener.
+func (ls *Listeners) separateWallet() bool {
+	return ls.Wallet != ls.Public
+}
+
+// separateOps returns true if operators use their own listener.
+func (ls *Listeners) separateOps() bool {
+	return ls.Ops != ls.Public
+}
+
+// others returns the servers of the enabled listeners other than the public one.
+func (ls *Listeners) others() []*echo.Echo {
+	var servers []*echo.Echo
+	for _, lis := range ls.listeners[1:] {
+		servers = append(servers, lis.e)
+	}
+	return servers
+}
+
+// Start will setup the TLS of every listener and then start serving them,
+// an error is returned if a listener's TLS can't be setup.
+//
+// Servers that fail once started are logged, echo only shuts down its own
+// server so that is started rather than a new one.
+func (ls *Listeners) Start(l log.Logger) error {
+	for _, lis := range ls.listeners {
+		tlsCfg, err := SetupTLS(lis.cfg, lis.clientAuth, l)
+		if err != nil {
+			return errors.Wrapf(err, "failed to setup tls of the %s listener", lis.name)
+		}
+		lis.e.Server.Addr = lis.cfg.Port
+		lis.e.Server.TLSConfig = tlsCfg
+	}
+	for _, lis := range ls.listeners {
+		lis := lis
+		l.Infof("%s listener serving on %s", lis.name, lis.cfg.Port)
+		go func() {
+			if err := lis.e.StartServer(lis.e.Server); !errors.Is(err, http.ErrServerClosed) {
+				l.Error(err, lis.name+" listener failed")
+			}
+		}()
+	}
+	return nil
+}
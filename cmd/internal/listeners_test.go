@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+)
+
+func TestNewEcho_CORS(t *testing.T) {
+	tests := map[string]struct {
+		origins      []string
+		origin       string
+		preflight    bool
+		expOrigin    string
+		expHeaders   string
+		expExposeTag string
+	}{
+		"listed origin allowed": {
+			origins:      []string{"https://checkout.example.com"},
+			origin:       "https://checkout.example.com",
+			expOrigin:    "https://checkout.example.com",
+			expExposeTag: "ETag",
+		},
+		"listed origin preflight allowed with headers": {
+			origins:    []string{"https://checkout.example.com"},
+			origin:     "https://checkout.example.com",
+			preflight:  true,
+			expOrigin:  "https://checkout.example.com",
+			expHeaders: "Content-Type,Idempotency-Key",
+		},
+		"other origin not allowed": {
+			origins: []string{"https://checkout.example.com"},
+			origin:  "https://evil.example.com",
+		},
+		"any origin allowed": {
+			origins:      []string{"*"},
+			origin:       "https://evil.example.com",
+			expOrigin:    "*",
+			expExposeTag: "ETag",
+		},
+		"cors off without origins": {
+			origin: "https://checkout.example.com",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := newEcho(&config.Config{Logging: &config.Logging{}, Server: &config.Server{}}, log.Noop{},
+				config.Listener{CORSOrigins: test.origins, CORSHeaders: []string{"Content-Type", "Idempotency-Key"}})
+			e.GET("/api/v1/payment/:paymentID", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payment/abc123", nil)
+			if test.preflight {
+				req = httptest.NewRequest(http.MethodOptions, "/api/v1/payment/abc123", nil)
+				req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+			}
+			req.Header.Set(echo.HeaderOrigin, test.origin)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expOrigin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			assert.Equal(t, test.expHeaders, rec.Header().Get(echo.HeaderAccessControlAllowHeaders))
+			assert.Equal(t, test.expExposeTag, rec.Header().Get(echo.HeaderAccessControlExposeHeaders))
+		})
+	}
+}
+
+func TestNewEcho_SecurityHeaders(t *testing.T) {
+	tests := map[string]struct {
+		enabled    bool
+		https      bool
+		expHeaders map[string]string
+	}{
+		"headers sent over https": {
+			enabled: true,
+			https:   true,
+			expHeaders: map[string]string{
+				echo.HeaderXContentTypeOptions:     "nosniff",
+				echo.HeaderXFrameOptions:           "DENY",
+				echo.HeaderContentSecurityPolicy:   "frame-ancestors 'none'",
+				echo.HeaderStrictTransportSecurity: "max-age=3600; includeSubdomains",
+			},
+		},
+		"hsts not sent over http": {
+			enabled: true,
+			expHeaders: map[string]string{
+				echo.HeaderXContentTypeOptions:     "nosniff",
+				echo.HeaderXFrameOptions:           "DENY",
+				echo.HeaderContentSecurityPolicy:   "frame-ancestors 'none'",
+				echo.HeaderStrictTransportSecurity: "",
+			},
+		},
+		"headers not sent when disabled": {
+			https: true,
+			expHeaders: map[string]string{
+				echo.HeaderXContentTypeOptions:     "",
+				echo.HeaderXFrameOptions:           "",
+				echo.HeaderContentSecurityPolicy:   "",
+				echo.HeaderStrictTransportSecurity: "",
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := newEcho(&config.Config{
+				Logging: &config.Logging{},
+				Server:  &config.Server{SecurityHeaders: test.enabled, HSTSMaxAge: 3600},
+			}, log.Noop{}, config.Listener{})
+			e.GET("/", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.https {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			for k, v := range test.expHeaders {
+				assert.Equal(t, v, rec.Header().Get(k), k)
+			}
+		})
+	}
+}
@@ -1,30 +1,49 @@
 package internal
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
-	"github.com/bitcoin-sv/dpp-proxy/docs"
-	"github.com/bitcoin-sv/dpp-proxy/log"
-	dppHandlers "github.com/bitcoin-sv/dpp-proxy/transports/http"
-	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
-	dppSoc "github.com/bitcoin-sv/dpp-proxy/transports/sockets"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
-	echoProm "github.com/labstack/echo-contrib/prometheus"
+	"github.com/libsv/go-bc/spv"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/spf13/viper"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/theflyingcodr/sockets"
 	smw "github.com/theflyingcodr/sockets/middleware"
 	"github.com/theflyingcodr/sockets/server"
 
+	proxy "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/bitcoin-sv/dpp-proxy/config"
-	"github.com/bitcoin-sv/dpp-proxy/data/noop"
+	"github.com/bitcoin-sv/dpp-proxy/data"
+	"github.com/bitcoin-sv/dpp-proxy/data/cache"
+	"github.com/bitcoin-sv/dpp-proxy/data/cluster"
+	"github.com/bitcoin-sv/dpp-proxy/data/fake"
+	"github.com/bitcoin-sv/dpp-proxy/data/headers"
+	"github.com/bitcoin-sv/dpp-proxy/data/ledger"
+	"github.com/bitcoin-sv/dpp-proxy/data/payd"
+	redisData "github.com/bitcoin-sv/dpp-proxy/data/redis"
 	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+	"github.com/bitcoin-sv/dpp-proxy/data/sqlite"
+	"github.com/bitcoin-sv/dpp-proxy/docs"
+	"github.com/bitcoin-sv/dpp-proxy/log"
 	"github.com/bitcoin-sv/dpp-proxy/service"
-	"github.com/libsv/go-dpp"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	dppHandlers "github.com/bitcoin-sv/dpp-proxy/transports/http"
+	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+	dppSoc "github.com/bitcoin-sv/dpp-proxy/transports/sockets"
 )
 
 // Deps holds all the dependencies.
@@ -34,28 +53,8 @@ type Deps struct {
 	ProofsService       dpp.ProofsService
 }
 
-// SetupEcho will set up and return an echo server.
-func SetupEcho(cfg *config.Config, l log.Logger) *echo.Echo {
-	e := echo.New()
-	e.HideBanner = true
-
-	// Middleware
-	e.Use(middleware.Recover())
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Skipper: func(c echo.Context) bool {
-			return cfg.Logging.Level != config.LogDebug
-		},
-	}))
-	e.Use(middleware.RequestID())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
-	}))
-	p := echoProm.NewPrometheus("dpp", nil)
-	p.Use(e)
-	e.HTTPErrorHandler = dppMiddleware.ErrorHandler(l)
-	return e
-}
+// headerAPIKey is the header wallets send their merchant API key in.
+const headerAPIKey = "X-API-Key"
 
 // SetupSwagger will enable the swagger endpoints.
 func SetupSwagger(cfg config.Server, e *echo.Echo) {
@@ -63,60 +62,393 @@ func SetupSwagger(cfg config.Server, e *echo.Echo) {
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 }
 
-// SetupSockets will setup handlers and socket server.
-func SetupSockets(cfg config.Socket, e *echo.Echo) *server.SocketServer {
-	g := e.Group("/")
+// SetupHealth will setup the liveness, readiness and version endpoints on
+// the public listener, and on the ops listener if it's separate.
+//
+// The server is ready when every check passes, stats are reported if the node
+// holds websockets and can be nil otherwise.
+func SetupHealth(cfg config.Config, ls *Listeners, checks map[string]proxy.HealthChecker, stats proxy.SocketStatsReader) {
+	h := dppHandlers.NewHealthHandler(service.NewHealth(cfg.Deployment, checks, stats))
+	h.RegisterRoutes(ls.Public.Group("/"))
+	if ls.separateOps() {
+		h.RegisterRoutes(ls.Ops.Group("/"))
+	}
+}
+
+// SetupAdmin will setup the operator API on the ops listener.
+//
+// The socket routes are served if s is not nil and, if the ledger is enabled,
+// the ledger is read from db.
+func SetupAdmin(cfg config.Config, ls *Listeners, s *SocketServer, db *sql.DB) {
+	g := ls.Ops.Group("/")
+	auth := dppMiddleware.KeyAuth(cfg.Admin.Keys)
+	if s != nil {
+		dppHandlers.NewSocketAdminHandler(service.NewSocketAdmin(s)).RegisterRoutes(g, auth)
+	}
+	if cfg.Ledger.Enabled {
+		dppHandlers.NewLedgerHandler(service.NewLedger(sqlite.NewLedger(db))).RegisterRoutes(g, auth)
+	}
+}
+
+// SetupSockets will setup handlers and socket server, the socket server is
+// closed by sd.
+func SetupSockets(cfg config.Config, l log.Logger, ls *Listeners, sd *Shutdown) *SocketServer {
+	g := ls.Public.Group("/")
 	// create socket server
-	s := server.New(
-		server.WithMaxMessageSize(int64(cfg.MaxMessageBytes)),
-		server.WithChannelTimeout(cfg.ChannelTimeout))
+	s := newSocketServer(server.New(
+		server.WithMaxMessageSize(int64(cfg.Sockets.MaxMessageBytes)),
+		server.WithChannelTimeout(cfg.Sockets.ChannelTimeout)))
 
 	// add middleware, with panic going first
-	s.WithMiddleware(smw.PanicHandler, smw.Timeout(smw.NewTimeoutConfig()), smw.Metrics())
+	s.WithMiddleware(smw.PanicHandler, smw.Timeout(smw.NewTimeoutConfig()), smw.Metrics(), s.countMessages,
+		s.checkRole)
 
-	dppSoc.NewPaymentTerms().Register(s)
-	dppSoc.NewPayment().Register(s)
-	dppHandlers.NewProofs(service.NewProof(socData.NewPaymentStore(s))).RegisterRoutes(g)
+	dppSoc.NewPaymentTerms().Register(s.SocketServer)
+	dppSoc.NewPayment().Register(s.SocketServer)
+	// payments are relayed between wallets, only proofs pass through the proxy.
+	_, proofHeaders := setupSPV(cfg.SPV, l)
+	proofsSvc := service.NewProof(socData.NewPaymentStore(s, cfg.Sockets), proofHeaders, nil)
+	proofsAuth := setupProofsAuth(cfg.ProofsAuth, l)
+	dppHandlers.NewProofs(proofsSvc, cfg.ProofsAuth.RequireSignature).RegisterRoutes(g, proofsAuth...)
+	dppHandlers.NewProofsBatch(l, proofsSvc, cfg.ProofsAuth.RequireSignature).RegisterRoutes(g, proofsAuth...)
 
 	// this is our websocket endpoint, clients will hit this with the channelID they wish to connect to
-	e.GET("/ws/:channelID", wsHandler(s))
+	setupWebsockets(cfg, ls, s, s, setupChannelAuth(cfg.Auth, l), sd, setupRateLimit(cfg.RateLimit))
+	sd.closeSockets(s)
 	return s
 }
 
 // SetupHybrid will setup handlers for http=>socket communication.
-func SetupHybrid(cfg config.Config, l log.Logger, e *echo.Echo) *server.SocketServer {
-	g := e.Group("/")
-	s := server.New(
+//
+// If the ledger is enabled, db is used to record all payment traffic and if
+// the proof queue is enabled, db stores proofs for offline wallets. The socket
+// server is closed by sd, once wallet round trips in-flight have finished.
+func SetupHybrid(cfg config.Config, l log.Logger, ls *Listeners, db *sql.DB, sd *Shutdown) *SocketServer {
+	g := ls.Public.Group("/")
+	s := newSocketServer(server.New(
 		server.WithMaxMessageSize(int64(cfg.Sockets.MaxMessageBytes)),
-		server.WithChannelTimeout(cfg.Sockets.ChannelTimeout))
+		server.WithChannelTimeout(cfg.Sockets.ChannelTimeout)))
 	// add middleware, with panic going first
-	s.WithMiddleware(smw.PanicHandler, smw.Timeout(smw.NewTimeoutConfig()), smw.Metrics())
+	s.WithMiddleware(smw.PanicHandler, smw.Timeout(smw.NewTimeoutConfig()), smw.Metrics(), s.countMessages,
+		s.checkRole)
+
+	// the recorder and drainer wrap this node's wallets, so requests routed
+	// here from other nodes go through them too.
+	var local sockets.ServerChannelBroadcaster = s
+	if cfg.Sockets.Record != "" {
+		f, err := os.OpenFile(cfg.Sockets.Record, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			l.Fatal(err, "failed to open socket recording")
+		}
+		l.Infof("recording socket traffic to %s", cfg.Sockets.Record)
+		sd.onClose(func() error {
+			if err := f.Sync(); err != nil {
+				return errors.Wrap(err, "failed to flush socket recording")
+			}
+			return errors.Wrap(f.Close(), "failed to close socket recording")
+		})
+		rec := socData.NewRecorder(local, f)
+		s.WithMiddleware(rec.Middleware)
+		local = rec
+	}
+	drainer := socData.NewDrainer(local)
+	sd.onDrain(drainer.Drain)
+	var channels cluster.Broadcaster = wrappedServer{ServerChannelBroadcaster: drainer, s: s}
+	var broadcaster sockets.ServerChannelBroadcaster = channels
+	if cfg.Cluster != nil && cfg.Cluster.Enabled {
+		channels = setupCluster(*cfg.Cluster, l, s, wrappedServer{ServerChannelBroadcaster: drainer, s: s})
+		// requests this node routes to other nodes are also left to finish.
+		remote := socData.NewDrainer(channels)
+		sd.onDrain(remote.Drain)
+		broadcaster = remote
+	}
+	var paymentStore proxy.PaymentStore = socData.NewPaymentStore(broadcaster, cfg.Sockets)
+	if cfg.ProofQueue != nil && cfg.ProofQueue.Enabled {
+		paymentStore = setupProofQueue(l, s, paymentStore, sqlite.NewProofQueue(db), cfg.ProofQueue.TTL, sd)
+	}
+	if cfg.Cache.PaymentTerms {
+		termsCache := cache.NewPaymentTermsCache(paymentStore, roundTripTimeout(cfg.Sockets.PaymentTerms))
+		dppSoc.NewPaymentTermsCache(service.NewPaymentTermsCache(termsCache)).Register(s.SocketServer)
+		paymentStore = termsCache
+	}
+	rateLimit := setupRateLimit(cfg.RateLimit)
+	setupPayments(cfg, l, g, paymentStore, db, sd, rateLimit)
+	dppSoc.NewHealthHandler().Register(s.SocketServer)
+	s.HandleReplies(socData.RoutePaymentTermsResponse, socData.RoutePaymentTermsError, socData.RoutePaymentACK,
+		socData.RoutePaymentError)
 
-	paymentStore := socData.NewPaymentStore(s)
-	paymentSvc := service.NewPayment(l, paymentStore)
+	setupWebsockets(cfg, ls, s, channels, setupChannelAuth(cfg.Auth, l), sd, rateLimit)
+	sd.closeSockets(s)
+	return s
+}
+
+// roundTripTimeout returns the longest a round trip to a payee wallet can take,
+// including every retry.
+func roundTripTimeout(rt config.RoundTrip) time.Duration {
+	d, backoff := rt.Timeout, rt.Backoff
+	for i := 0; i < rt.Retries; i++ {
+		d += backoff + rt.Timeout
+		backoff *= 2
+	}
+	return d
+}
+
+// setupCluster will connect this node to the cluster backbone, messages for
+// channels held by other nodes are routed to them.
+//
+// Messages for channels on this node are sent through local.
+func setupCluster(cfg config.Cluster, l log.Logger, s *SocketServer, local cluster.Local) cluster.Broadcaster {
+	ctx, cancel := context.WithCancel(context.Background())
+	rc := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	ps := redisData.NewPubSub(ctx, rc)
+	nodeID := uuid.NewString()
+	c := cluster.New(l, local, ps, nodeID, cfg.Prefix)
+	s.OnChannelCreate(c.ChannelCreated)
+	s.OnChannelClose(c.ChannelClosed)
+	go func() {
+		// redis reconnects by itself once subscribed, so Run only fails when
+		// the backbone can't be reached at all.
+		backoff := time.Second
+		for {
+			err := c.Run(ctx)
+			if err == nil {
+				return
+			}
+			l.Error(err, "failed to join cluster, retrying")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+		}
+	}()
+	s.onHealthCheck(proxy.HealthCheckFunc(func(ctx context.Context) error {
+		return errors.Wrap(rc.Ping(ctx).Err(), "cluster redis can't be reached")
+	}))
+	s.onClose(func() {
+		cancel()
+		_ = ps.Close()
+		_ = rc.Close()
+	})
+	l.Infof("joined cluster '%s' as node %s", cfg.Prefix, nodeID)
+	return c
+}
+
+// SetupHTTP will setup handlers for http=>http communication, payee wallets
+// are called on their own REST endpoints rather than over a websocket.
+//
+// If the ledger is enabled, db is used to record all payment traffic.
+func SetupHTTP(cfg config.Config, l log.Logger, ls *Listeners, db *sql.DB, sd *Shutdown) {
+	var paymentStore proxy.PaymentStore = payd.NewPayD(cfg.PayD, data.NewClient(&http.Client{
+		Timeout: cfg.PayD.Timeout,
+	}))
+	if cfg.Cache.PaymentTerms {
+		paymentStore = cache.NewPaymentTermsCache(paymentStore, cfg.PayD.Timeout)
+	}
+	setupPayments(cfg, l, ls.Public.Group("/"), paymentStore, db, sd, setupRateLimit(cfg.RateLimit))
+}
+
+// setupPayments will setup the payer facing services and handlers on top of the
+// payment store used to reach payee wallets.
+//
+// If PayD Noop is set a fake payee is used in place of the payment store. New
+// invoices are refused once sd starts draining, and the payer facing routes are
+// limited by rateLimit.
+func setupPayments(cfg config.Config, l log.Logger, g *echo.Group, paymentStore proxy.PaymentStore, db *sql.DB,
+	sd *Shutdown, rateLimit []echo.MiddlewareFunc) {
 	if cfg.PayD.Noop {
-		noopStore := noop.NewNoOp(log.Noop{})
-		paymentSvc = service.NewPayment(log.Noop{}, noopStore)
+		fakeStore, err := fake.NewPayee(l, cfg.PayD.Fake, cfg.Server, cfg.Deployment)
+		if err != nil {
+			l.Fatal(err, "failed to setup fake payee")
+		}
+		paymentStore = fakeStore
+	}
+	if cfg.Ledger.Enabled {
+		paymentStore = ledger.NewPaymentStore(l, sqlite.NewLedger(db), paymentStore)
+	}
+	// results, served terms and invoice states are held in memory, so are off
+	// when clustered as a payer's requests can reach any node.
+	var paymentResults proxy.PaymentResultReaderWriter
+	if cfg.Cache.PaymentResultsTTL > 0 {
+		paymentResults = cache.NewPaymentResults(cfg.Cache.PaymentResultsTTL)
+	}
+	var servedTerms proxy.ServedTermsReaderWriter
+	if cfg.Cluster == nil || !cfg.Cluster.Enabled {
+		servedTerms = cache.NewServedTerms()
+	}
+	verifier, proofHeaders := setupSPV(cfg.SPV, l)
+	var invoiceStates proxy.InvoiceStateReaderWriter
+	if cfg.Cache.InvoiceStatesTTL > 0 {
+		invoiceStates = cache.NewInvoiceStates(cfg.Cache.InvoiceStatesTTL)
 	}
-	paymentReqSvc := service.NewPaymentTermsProxy(paymentStore, cfg.Transports, cfg.Server)
-	proofsSvc := service.NewProof(paymentStore)
+	paymentSvc := service.NewPayment(l, paymentStore, paymentResults, servedTerms, verifier, invoiceStates)
+	paymentReqSvc := service.NewPaymentTermsProxy(paymentStore, cfg.Transports, cfg.Server, cfg.Deployment,
+		cfg.PaymentTerms, servedTerms, invoiceStates)
+	proofsSvc := service.NewProof(paymentStore, proofHeaders, invoiceStates)
 
-	dppHandlers.NewPaymentHandler(paymentSvc).RegisterRoutes(g)
-	dppHandlers.NewPaymentTermsHandler(paymentReqSvc).RegisterRoutes(g)
-	dppHandlers.NewProofs(proofsSvc).RegisterRoutes(g)
-	dppSoc.NewHealthHandler().Register(s)
+	proofsAuth := setupProofsAuth(cfg.ProofsAuth, l)
+	dppHandlers.NewPaymentHandler(paymentSvc).RegisterRoutes(g, rateLimit...)
+	dppHandlers.NewPaymentTermsHandler(paymentReqSvc).RegisterRoutes(g, append([]echo.MiddlewareFunc{sd.middleware()},
+		rateLimit...)...)
+	dppHandlers.NewProofs(proofsSvc, cfg.ProofsAuth.RequireSignature).RegisterRoutes(g, proofsAuth...)
+	dppHandlers.NewProofsBatch(l, proofsSvc, cfg.ProofsAuth.RequireSignature).RegisterRoutes(g, proofsAuth...)
+	if invoiceStates != nil {
+		dppHandlers.NewInvoiceStatusHandler(service.NewInvoiceStatus(invoiceStates)).RegisterRoutes(g, rateLimit...)
+	}
+}
 
-	e.GET("/ws/:channelID", wsHandler(s))
-	return s
+// setupSPV will read the block headers used to verify payment ancestry and
+// proofs, nil is returned for each that isn't enabled.
+func setupSPV(cfg *config.SPV, l log.Logger) (spv.PaymentVerifier, proxy.BlockHeaderReader) {
+	if cfg == nil || (!cfg.Enabled && !cfg.Proofs) {
+		return nil, nil
+	}
+	hdrs, err := headers.NewFile(cfg.Headers)
+	if err != nil {
+		l.Fatal(err, "failed to read spv block headers")
+	}
+	var verifier spv.PaymentVerifier
+	if cfg.Enabled {
+		if verifier, err = spv.NewPaymentVerifier(hdrs); err != nil {
+			l.Fatal(err, "failed to setup spv verifier")
+		}
+	}
+	var proofHeaders proxy.BlockHeaderReader
+	if cfg.Proofs {
+		proofHeaders = hdrs
+	}
+	return verifier, proofHeaders
+}
+
+// setupProofQueue will wrap store so proofs for channels with no listening
+// wallet are queued, they are delivered when a wallet joins the channel.
+//
+// Expired proofs are pruned periodically until sd is run and the queue size is
+// reported as a metric.
+func setupProofQueue(l log.Logger, s *SocketServer, store proxy.PaymentStore, q proxy.ProofQueueReaderWriter,
+	ttl time.Duration, sd *Shutdown) proxy.PaymentStore {
+	queueStore := socData.NewProofQueueStore(store, s, q, ttl)
+	s.OnClientJoin(func(clientID, channelID string) {
+		// proofs are only for wallets, payers joining are ignored.
+		if !s.isWallet(clientID) {
+			return
+		}
+		// hooks are called by the socket server's channel manager, sending
+		// from here would block it so deliver in the background.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := queueStore.ProofsDeliver(ctx, channelID); err != nil {
+				l.Error(err, "failed to deliver queued proofs")
+			}
+		}()
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "dpp",
+		Subsystem: "proofs_queue",
+		Name:      "undelivered",
+		Help:      "The number of unexpired proofs waiting for a payee wallet to connect.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		n, err := q.ProofQueueCount(ctx)
+		if err != nil {
+			l.Error(err, "failed to count proof queue")
+		}
+		return float64(n)
+	})
+	cExp := promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "dpp",
+		Subsystem: "proofs_queue",
+		Name:      "expired_total",
+		Help:      "The number of queued proofs dropped before a payee wallet connected.",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		t := time.NewTicker(time.Minute)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			n, err := q.ProofQueuePrune(ctx)
+			if err != nil {
+				l.Error(err, "failed to prune proof queue")
+				continue
+			}
+			cExp.Add(float64(n))
+		}
+	}()
+	sd.onClose(func() error {
+		cancel()
+		return nil
+	})
+	return queueStore
+}
+
+// wsListener is how websocket connections are accepted on a listener.
+type wsListener struct {
+	// origins browsers can connect from other than the same origin.
+	origins []string
+	// mtls is true if wallets connect with client certificates.
+	mtls bool
+	// role is the only role allowed to connect, any role is allowed if empty.
+	role proxy.ChannelRole
+}
+
+// setupWebsockets will setup the websocket endpoint.
+//
+// If the wallet listener is enabled, payers connect on the public listener and
+// wallets connect on the wallet listener, otherwise both connect on the public
+// listener. Connections on the public listener are limited by rateLimit.
+func setupWebsockets(cfg config.Config, ls *Listeners, s *SocketServer, channels socData.ChannelChecker,
+	auth proxy.ChannelAuthenticator, sd *Shutdown, rateLimit []echo.MiddlewareFunc) {
+	public := append([]echo.MiddlewareFunc{sd.middleware()}, rateLimit...)
+	if !ls.separateWallet() {
+		ls.Public.GET("/ws/:channelID", wsHandler(s, channels, auth, wsListener{
+			origins: cfg.Server.WSOrigins,
+			mtls:    cfg.Server.TLSClientCA != "",
+		}), public...)
+		return
+	}
+	ls.Public.GET("/ws/:channelID", wsHandler(s, channels, auth, wsListener{
+		origins: cfg.Server.WSOrigins,
+		role:    proxy.ChannelRolePayer,
+	}), public...)
+	ls.Wallet.GET("/ws/:channelID", wsHandler(s, channels, auth, wsListener{
+		origins: cfg.Wallet.WSOrigins,
+		mtls:    cfg.Wallet.TLSClientCA != "",
+		role:    proxy.ChannelRoleWallet,
+	}), sd.middleware())
 }
 
 // wsHandler will upgrade connections to a websocket and then wait for messages.
-func wsHandler(svr *server.SocketServer) echo.HandlerFunc {
-	upgrader := websocket.Upgrader{}
+//
+// Clients other than internal payee wallets can only join channels known to channels.
+// Browsers can only connect from the same origin or one of the listener's origins.
+func wsHandler(svr *SocketServer, channels socData.ChannelChecker, auth proxy.ChannelAuthenticator,
+	lis wsListener) echo.HandlerFunc {
+	upgrader := websocket.Upgrader{CheckOrigin: checkOrigin(lis.origins)}
 	return func(c echo.Context) error {
-		upgrader.CheckOrigin = func(r *http.Request) bool {
-			return true
+		chID := c.Param("channelID")
+		role, err := wsRole(c, chID, auth, lis)
+		if err != nil {
+			return err
 		}
+		if role != proxy.ChannelRoleWallet && !channels.HasChannel(chID) {
+			return c.JSON(http.StatusNotFound, fmt.Sprintf("Connection for invoice '%s' not found", chID))
+		}
+
 		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 		if err != nil {
 			return err
@@ -126,20 +458,175 @@ func wsHandler(svr *server.SocketServer) echo.HandlerFunc {
 			_ = ws.Close()
 		}()
 
-		chID := c.Param("channelID")
+		return svr.Listen(ws, chID, role)
+	}
+}
 
-		if c.QueryParam("internal") != "true" {
-			if !svr.HasChannel(chID) {
-				return c.JSON(http.StatusNotFound, fmt.Sprintf("Connection for invoice '%s' not found", chID))
+// wsRole returns the role a connection has on the channel, a NotAuthorised
+// error is returned if the role can't connect on the listener.
+func wsRole(c echo.Context, chID string, auth proxy.ChannelAuthenticator, lis wsListener) (proxy.ChannelRole, error) {
+	role, err := channelRole(c, chID, auth, lis)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case lis.role == "" || role == lis.role:
+		return role, nil
+	case role == proxy.ChannelRoleWallet:
+		return "", client_errors.NewErrNotAuthorised("403", "wallets must connect on the wallet listener")
+	default:
+		return "", client_errors.NewErrNotAuthorised("403", "payers can't connect on the wallet listener")
+	}
+}
+
+// channelRole authenticates the connection and returns its role on the channel.
+//
+// With mutual TLS, wallets must connect with a client certificate whose common
+// name is a merchantID, the certificate can open channels for the merchant's
+// paymentIDs, or any channel if the common name is *. Without auth, wallets
+// otherwise open channels with the internal flag.
+func channelRole(c echo.Context, chID string, auth proxy.ChannelAuthenticator, lis wsListener) (proxy.ChannelRole, error) {
+	mtls := lis.mtls
+	if merchantID, ok := clientCertMerchant(c.Request()); mtls && ok {
+		if merchantID != "*" && !strings.HasPrefix(chID, merchantID+".") {
+			return "", client_errors.NewErrNotAuthorisedf("403", "client certificate can't open channel %s", chID)
+		}
+		return proxy.ChannelRoleWallet, nil
+	}
+	if auth == nil {
+		if !mtls && c.QueryParam("internal") == "true" {
+			return proxy.ChannelRoleWallet, nil
+		}
+		return proxy.ChannelRolePayer, nil
+	}
+	role, err := auth.ChannelAuthenticate(c.Request().Context(), proxy.ChannelAuthArgs{
+		ChannelID: chID,
+		APIKey:    c.Request().Header.Get(headerAPIKey),
+		Token:     channelToken(c),
+	})
+	if err != nil {
+		return "", err
+	}
+	if mtls && role == proxy.ChannelRoleWallet {
+		return "", client_errors.NewErrNotAuthenticated("401", "wallets must connect with a client certificate")
+	}
+	return role, nil
+}
+
+// clientCertMerchant returns the common name of the verified client certificate
+// the request was sent with.
+func clientCertMerchant(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// checkOrigin returns a websocket origin check allowing connections without an
+// Origin header, from the same origin or from one of origins, * allows any.
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get(echo.HeaderOrigin)
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, o := range origins {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
 			}
 		}
+		return false
+	}
+}
+
+// channelToken returns the channel token sent as a bearer token or, as browsers
+// can't set websocket headers, the token query param.
+func channelToken(c echo.Context) string {
+	if token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); token != "" {
+		return token
+	}
+	return c.QueryParam("token")
+}
 
-		return svr.Listen(ws, chID)
+// setupChannelAuth returns the authenticator for websocket connections, nil is
+// returned if auth is disabled.
+func setupChannelAuth(cfg *config.Auth, l log.Logger) proxy.ChannelAuthenticator {
+	if cfg == nil || !cfg.Enabled {
+		l.Warn("websocket auth is disabled, any client can open a channel with ?internal=true")
+		return nil
 	}
+	return service.NewChannelAuth(cfg)
+}
+
+// setupProofsAuth returns the middleware authenticating proof callbacks, none is
+// returned if proofs auth is disabled.
+//
+// Rejected requests are counted by reason as a metric.
+func setupProofsAuth(cfg *config.ProofsAuth, l log.Logger) []echo.MiddlewareFunc {
+	if cfg == nil || !cfg.Enabled {
+		l.Warn("proofs auth is disabled, any client can send proofs to payee wallets")
+		return nil
+	}
+	cRej := promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dpp",
+		Subsystem: "proofs_auth",
+		Name:      "rejected_total",
+		Help:      "The number of proof requests rejected by proofs auth.",
+	}, []string{"reason"})
+	return []echo.MiddlewareFunc{dppMiddleware.ProofsAuth(service.NewProofSourceAuth(cfg),
+		dppMiddleware.TrustedProxyIP(cfg.TrustedProxies), func(reason string) {
+			cRej.WithLabelValues(reason).Inc()
+		})}
+}
+
+// setupRateLimit returns the middleware limiting requests to the payment
+// endpoints, none is returned if rate limiting is disabled.
+//
+// The limits set and requests limited by scope are reported as metrics.
+func setupRateLimit(cfg *config.RateLimit) []echo.MiddlewareFunc {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	gRate := promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dpp",
+		Subsystem: "ratelimit",
+		Name:      "rate",
+		Help:      "The requests a second allowed in each rate limit scope.",
+	}, []string{"scope"})
+	gBurst := promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dpp",
+		Subsystem: "ratelimit",
+		Name:      "burst",
+		Help:      "The burst of requests allowed in each rate limit scope.",
+	}, []string{"scope"})
+	for scope, bucket := range map[string]config.TokenBucket{
+		dppMiddleware.RateLimitIP:        cfg.IP,
+		dppMiddleware.RateLimitPaymentID: cfg.PaymentID,
+		dppMiddleware.RateLimitNotFound:  cfg.NotFound,
+	} {
+		gRate.WithLabelValues(scope).Set(bucket.Rate)
+		gBurst.WithLabelValues(scope).Set(float64(bucket.Burst))
+	}
+	cLim := promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dpp",
+		Subsystem: "ratelimit",
+		Name:      "limited_total",
+		Help:      "The number of requests rejected with a 429 by rate limit scope.",
+	}, []string{"scope"})
+	return []echo.MiddlewareFunc{dppMiddleware.RateLimit(*cfg, func(scope string) {
+		cLim.WithLabelValues(scope).Inc()
+	})}
 }
 
 // SetupSocketMetrics will setup the socket server metrics.
-func SetupSocketMetrics(s *server.SocketServer) {
+func SetupSocketMetrics(s *SocketServer) {
 	// simple metrics
 	gCo := promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "sockets",
@@ -172,11 +659,13 @@ func SetupSocketMetrics(s *server.SocketServer) {
 
 // PrintDev outputs some useful dev information such as http routes
 // and current settings being used.
-func PrintDev(e *echo.Echo) {
+func PrintDev(ls *Listeners) {
 	fmt.Println("==================================")
-	fmt.Println("DEV mode, printing http routes:")
-	for _, r := range e.Routes() {
-		fmt.Printf("%s: %s\n", r.Method, r.Path)
+	for _, lis := range ls.listeners {
+		fmt.Printf("DEV mode, printing http routes of the %s listener:\n", lis.name)
+		for _, r := range lis.e.Routes() {
+			fmt.Printf("%s: %s\n", r.Method, r.Path)
+		}
 	}
 	fmt.Println("==================================")
 	fmt.Println("DEV mode, printing settings:")
@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets/server"
+
+	proxy "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestChannelRole(t *testing.T) {
+	auth := service.NewChannelAuth(&config.Auth{
+		Enabled:    true,
+		WalletKeys: map[string]string{"merchant1": "key1"},
+	})
+	tests := map[string]struct {
+		query   string
+		apiKey  string
+		auth    proxy.ChannelAuthenticator
+		lis     wsListener
+		expRole proxy.ChannelRole
+		expErr  error
+	}{
+		"internal flag opens a channel without auth": {
+			query:   "?internal=true",
+			expRole: proxy.ChannelRoleWallet,
+		},
+		"connection without auth is a payer": {
+			expRole: proxy.ChannelRolePayer,
+		},
+		"connection without auth on the wallet listener is a payer": {
+			lis:     wsListener{role: proxy.ChannelRoleWallet},
+			expRole: proxy.ChannelRolePayer,
+		},
+		"internal flag ignored with auth": {
+			query:  "?internal=true",
+			auth:   auth,
+			expErr: errors.New("Not Authenticated: an api key or channel token is required"),
+		},
+		"api key opens a channel with auth": {
+			apiKey:  "key1",
+			auth:    auth,
+			expRole: proxy.ChannelRoleWallet,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ws/merchant1.abc123"+test.query, nil)
+			if test.apiKey != "" {
+				req.Header.Set(headerAPIKey, test.apiKey)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			role, err := channelRole(c, "merchant1.abc123", test.auth, test.lis)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expRole, role)
+		})
+	}
+}
+
+func TestSetupWebsockets_RateLimit(t *testing.T) {
+	e := echo.New()
+	ls := &Listeners{Public: e, Wallet: e, Ops: e, listeners: []listener{{name: "public", e: e}}}
+	svr := newSocketServer(server.New())
+	defer svr.Close()
+	var limited []string
+	rateLimit := dppMiddleware.RateLimit(config.RateLimit{
+		Enabled:   true,
+		IP:        config.TokenBucket{Rate: 100, Burst: 100},
+		PaymentID: config.TokenBucket{Rate: 100, Burst: 100},
+		NotFound:  config.TokenBucket{Rate: 0.001, Burst: 2},
+	}, func(scope string) {
+		limited = append(limited, scope)
+	})
+	setupWebsockets(config.Config{Server: &config.Server{}}, ls, svr, svr, nil, NewShutdown(log.Noop{}, ls),
+		[]echo.MiddlewareFunc{rateLimit})
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws/abc123", nil))
+		codes = append(codes, rec.Code)
+	}
+	assert.Equal(t, []int{http.StatusNotFound, http.StatusNotFound, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, []string{dppMiddleware.RateLimitNotFound}, limited)
+}
+
+func TestSetupWebsockets_Listeners(t *testing.T) {
+	tests := map[string]struct {
+		listener  string
+		role      proxy.ChannelRole
+		origin    string
+		expStatus int
+	}{
+		"wallet connects on the wallet listener": {
+			listener:  "wallet",
+			role:      proxy.ChannelRoleWallet,
+			expStatus: http.StatusSwitchingProtocols,
+		},
+		"payer connects on the public listener": {
+			listener:  "public",
+			role:      proxy.ChannelRolePayer,
+			expStatus: http.StatusSwitchingProtocols,
+		},
+		"wallet can't connect on the public listener": {
+			listener:  "public",
+			role:      proxy.ChannelRoleWallet,
+			expStatus: http.StatusForbidden,
+		},
+		"payer can't connect on the wallet listener": {
+			listener:  "wallet",
+			role:      proxy.ChannelRolePayer,
+			expStatus: http.StatusForbidden,
+		},
+		"browser wallet connects from a wallet origin": {
+			listener:  "wallet",
+			role:      proxy.ChannelRoleWallet,
+			origin:    "https://wallet.example.com",
+			expStatus: http.StatusSwitchingProtocols,
+		},
+		"browser wallet can't connect from a checkout origin": {
+			listener:  "wallet",
+			role:      proxy.ChannelRoleWallet,
+			origin:    "https://checkout.example.com",
+			expStatus: http.StatusForbidden,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			public, wallet := echo.New(), echo.New()
+			public.HTTPErrorHandler = dppMiddleware.ErrorHandler(log.Noop{})
+			wallet.HTTPErrorHandler = dppMiddleware.ErrorHandler(log.Noop{})
+			ls := &Listeners{Public: public, Wallet: wallet, Ops: public, listeners: []listener{{name: "public", e: public}}}
+			svr := newSocketServer(server.New())
+			auth := service.NewChannelAuth(&config.Auth{
+				Enabled:       true,
+				WalletKeys:    map[string]string{"merchant1": "key1"},
+				ChannelSecret: "secret",
+			})
+			setupWebsockets(config.Config{
+				Server: &config.Server{WSOrigins: []string{"https://checkout.example.com"}},
+				Wallet: &config.Wallet{
+					Listener:  config.Listener{Port: ":8447"},
+					WSOrigins: []string{"https://wallet.example.com"},
+				},
+			}, ls, svr, svr, auth, NewShutdown(log.Noop{}, ls), nil)
+			urls := map[string]string{}
+			for name, e := range map[string]*echo.Echo{"public": public, "wallet": wallet} {
+				srv := httptest.NewServer(e)
+				defer srv.Close()
+				urls[name] = "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/merchant1.abc123"
+			}
+			dial := func(listener string, role proxy.ChannelRole, origin string) (*websocket.Conn, *http.Response, error) {
+				header := http.Header{}
+				if origin != "" {
+					header.Set(echo.HeaderOrigin, origin)
+				}
+				query := ""
+				if role == proxy.ChannelRoleWallet {
+					header.Set(headerAPIKey, "key1")
+				} else {
+					query = "?token=" + service.ChannelToken("secret", role, "merchant1.abc123", time.Now().Add(time.Minute))
+				}
+				return websocket.DefaultDialer.Dial(urls[listener]+query, header)
+			}
+			defer func() {
+				assert.Eventually(t, func() bool {
+					return len(svr.openConns()) == 0
+				}, 2*time.Second, 10*time.Millisecond)
+				svr.Close()
+			}()
+			// a wallet opens the channel payers join.
+			ws, _, err := dial("wallet", proxy.ChannelRoleWallet, "")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer ws.Close()
+
+			conn, resp, err := dial(test.listener, test.role, test.origin)
+			if conn != nil {
+				defer conn.Close()
+			}
+			if assert.NotNil(t, resp, err) {
+				assert.Equal(t, test.expStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := map[string]struct {
+		origins []string
+		origin  string
+		exp     bool
+	}{
+		"no origin header allowed": {
+			exp: true,
+		},
+		"same origin allowed": {
+			origin: "https://dpp.example.com",
+			exp:    true,
+		},
+		"same origin with other case allowed": {
+			origin: "https://DPP.example.com",
+			exp:    true,
+		},
+		"other origin refused by default": {
+			origin: "https://evil.example.com",
+		},
+		"listed origin allowed": {
+			origins: []string{"https://checkout.example.com"},
+			origin:  "https://checkout.example.com",
+			exp:     true,
+		},
+		"unlisted origin refused": {
+			origins: []string{"https://checkout.example.com"},
+			origin:  "https://evil.example.com",
+		},
+		"origin differing by port refused": {
+			origins: []string{"https://checkout.example.com"},
+			origin:  "https://checkout.example.com:8443",
+		},
+		"any origin allowed with *": {
+			origins: []string{"*"},
+			origin:  "https://evil.example.com",
+			exp:     true,
+		},
+		"invalid origin refused": {
+			origins: []string{"https://checkout.example.com"},
+			origin:  "://bad",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://dpp.example.com/ws/abc123", nil)
+			if test.origin != "" {
+				req.Header.Set(echo.HeaderOrigin, test.origin)
+			}
+			assert.Equal(t, test.exp, checkOrigin(test.origins)(req))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+// Shutdown stops the server in order, when run:
+//  1. new invoices and websocket connections are refused with a 503
+//  2. wallet round trips in-flight are let finish
+//  3. the public listener stops, letting in-flight requests finish
+//  4. socket servers tell wallets the server is stopping and close
+//  5. the wallet and ops listeners stop
+//  6. background jobs stop and files are closed
+//
+// Every step shares the deadline of the context the shutdown is run with.
+type Shutdown struct {
+	l        log.Logger
+	e        *echo.Echo
+	draining int32
+	drainers []func(ctx context.Context) error
+	sockets  []*SocketServer
+	servers  []*echo.Echo
+	closers  []func() error
+}
+
+// NewShutdown will setup and return a new shutdown of the listeners.
+func NewShutdown(l log.Logger, ls *Listeners) *Shutdown {
+	return &Shutdown{l: l, e: ls.Public, servers: ls.others()}
+}
+
+// Draining returns true once the shutdown has started.
+func (s *Shutdown) Draining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// HealthCheck returns an error once the shutdown has started, so the node is
+// taken out of rotation while it drains.
+func (s *Shutdown) HealthCheck(ctx context.Context) error {
+	if s.Draining() {
+		return errors.New("server is shutting down")
+	}
+	return nil
+}
+
+// Run will stop the server, returning once every step has finished or ctx is done.
+func (s *Shutdown) Run(ctx context.Context) {
+	atomic.StoreInt32(&s.draining, 1)
+	s.l.Info("draining server")
+	for _, drain := range s.drainers {
+		if err := drain(ctx); err != nil {
+			s.l.Error(err, "wallet round trips didn't finish before the drain deadline")
+		}
+	}
+	if err := s.e.Shutdown(ctx); err != nil {
+		s.l.Error(err, "http server didn't stop before the drain deadline")
+	}
+	for _, svr := range s.sockets {
+		svr.Shutdown(ctx)
+	}
+	for _, svr := range s.servers {
+		if err := svr.Shutdown(ctx); err != nil {
+			s.l.Error(err, "http server didn't stop before the drain deadline")
+		}
+	}
+	for _, fn := range s.closers {
+		if err := fn(); err != nil {
+			s.l.Error(err, "failed to close")
+		}
+	}
+	s.l.Info("server stopped")
+}
+
+// middleware returns the middleware refusing requests once draining.
+func (s *Shutdown) middleware() echo.MiddlewareFunc {
+	return dppMiddleware.Drain(s.Draining)
+}
+
+// onDrain adds a func waiting on in-flight wallet round trips.
+func (s *Shutdown) onDrain(fn func(ctx context.Context) error) {
+	s.drainers = append(s.drainers, fn)
+}
+
+// closeSockets adds a socket server shut down after the http server.
+func (s *Shutdown) closeSockets(svr *SocketServer) {
+	s.sockets = append(s.sockets, svr)
+}
+
+// onClose adds a func stopping a background job or closing a file once
+// everything else has stopped.
+func (s *Shutdown) onClose(fn func() error) {
+	s.closers = append(s.closers, fn)
+}
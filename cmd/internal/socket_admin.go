@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	proxy "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// Channels returns every channel open on the server, oldest first.
+func (s *SocketServer) Channels(ctx context.Context) ([]proxy.SocketChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	channels := make([]proxy.SocketChannel, 0, len(s.channels))
+	for channelID := range s.channels {
+		channels = append(channels, s.channel(channelID))
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Created.Equal(channels[j].Created) {
+			return channels[i].ChannelID < channels[j].ChannelID
+		}
+		return channels[i].Created.Before(channels[j].Created)
+	})
+	return channels, nil
+}
+
+// Channel returns a channel with its clients and the messages sent to it
+// waiting on a reply.
+func (s *SocketServer) Channel(ctx context.Context, args proxy.SocketChannelArgs) (*proxy.SocketChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.channels[args.ChannelID]; !ok {
+		return nil, client_errors.NewErrNotFoundf("404", "channel %s not found", args.ChannelID)
+	}
+	ch := s.channel(args.ChannelID)
+	for _, await := range s.awaits {
+		if await.ChannelID == args.ChannelID {
+			ch.Awaits = append(ch.Awaits, await.SocketAwait)
+		}
+	}
+	sortAwaits(ch.Awaits)
+	return &ch, nil
+}
+
+// Clients returns every client connected to the server, longest connected first.
+func (s *SocketServer) Clients(ctx context.Context) ([]proxy.SocketClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clients := make([]proxy.SocketClient, 0, len(s.clients))
+	for clientID := range s.clients {
+		clients = append(clients, s.client(clientID))
+	}
+	sortClients(clients)
+	return clients, nil
+}
+
+// Awaits returns the messages sent to channels waiting on a reply, oldest first.
+func (s *SocketServer) Awaits(ctx context.Context) ([]proxy.SocketAwait, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	awaits := make([]proxy.SocketAwait, 0, len(s.awaits))
+	for _, await := range s.awaits {
+		awaits = append(awaits, await.SocketAwait)
+	}
+	sortAwaits(awaits)
+	return awaits, nil
+}
+
+// ChannelClose will disconnect every client on the channel, the channel is
+// closed once they have left.
+func (s *SocketServer) ChannelClose(ctx context.Context, args proxy.SocketChannelArgs) error {
+	s.mu.Lock()
+	ch, ok := s.channels[args.ChannelID]
+	if !ok {
+		s.mu.Unlock()
+		return client_errors.NewErrNotFoundf("404", "channel %s not found", args.ChannelID)
+	}
+	conns := make([]*websocket.Conn, 0, len(ch.clients))
+	for clientID := range ch.clients {
+		if c := s.clients[clientID]; c != nil && c.ws != nil {
+			conns = append(conns, c.ws)
+		}
+	}
+	s.mu.Unlock()
+	for _, ws := range conns {
+		closeConn(ws)
+	}
+	return nil
+}
+
+// ClientClose will disconnect the client.
+func (s *SocketServer) ClientClose(ctx context.Context, args proxy.SocketClientArgs) error {
+	s.mu.Lock()
+	c, ok := s.clients[args.ClientID]
+	s.mu.Unlock()
+	if !ok {
+		return client_errors.NewErrNotFoundf("404", "client %s not found", args.ClientID)
+	}
+	if c.ws == nil {
+		return errors.Errorf("connection of client %s is unknown", args.ClientID)
+	}
+	closeConn(c.ws)
+	return nil
+}
+
+// channel returns the channel with its clients, s.mu must be held.
+func (s *SocketServer) channel(channelID string) proxy.SocketChannel {
+	ch := s.channels[channelID]
+	resp := proxy.SocketChannel{
+		ChannelID: channelID,
+		Created:   ch.created,
+		Sent:      ch.sent,
+		Received:  ch.received,
+		Clients:   make([]proxy.SocketClient, 0, len(ch.clients)),
+	}
+	for clientID := range ch.clients {
+		resp.Clients = append(resp.Clients, s.client(clientID))
+	}
+	sortClients(resp.Clients)
+	return resp
+}
+
+// client returns the client, s.mu must be held.
+func (s *SocketServer) client(clientID string) proxy.SocketClient {
+	c := s.clients[clientID]
+	return proxy.SocketClient{
+		ClientID:  clientID,
+		ChannelID: c.channelID,
+		Joined:    c.joined,
+		Received:  c.received,
+	}
+}
+
+// closeConn will tell the client it's being disconnected and close the
+// connection, the server removes the client once its reader fails.
+//
+// Errors are ignored as the connection may already be closing.
+func closeConn(ws *websocket.Conn) {
+	closing := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "closed by operator")
+	_ = ws.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second))
+	_ = ws.Close()
+}
+
+func sortClients(clients []proxy.SocketClient) {
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Joined.Equal(clients[j].Joined) {
+			return clients[i].ClientID < clients[j].ClientID
+		}
+		return clients[i].Joined.Before(clients[j].Joined)
+	})
+}
+
+func sortAwaits(awaits []proxy.SocketAwait) {
+	sort.Slice(awaits, func(i, j int) bool {
+		if awaits[i].Started.Equal(awaits[j].Started) {
+			return awaits[i].CorrelationID < awaits[j].CorrelationID
+		}
+		return awaits[i].Started.Before(awaits[j].Started)
+	})
+}
@@ -0,0 +1,495 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/sockets"
+	"github.com/theflyingcodr/sockets/server"
+
+	proxy "github.com/bitcoin-sv/dpp-proxy"
+	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+const (
+	// routeServerShutdown is sent to every channel when the server is stopping
+	// so wallets can reconnect to another node.
+	routeServerShutdown = "server.shutdown"
+	// leaveGrace is how long clients are given to leave after being told the
+	// server is stopping, before their connections are closed.
+	leaveGrace = 5 * time.Second
+	// closeGrace is how long clients are given to answer the close frame
+	// before the server closes their connections.
+	closeGrace = time.Second
+)
+
+// walletRoutes are the messages only wallets can send, they answer requests
+// sent to the channel or change what is served to payers.
+var walletRoutes = map[string]struct{}{
+	socData.RoutePaymentTermsResponse: {},
+	socData.RoutePaymentTermsError:    {},
+	socData.RoutePaymentACK:           {},
+	socData.RoutePaymentError:         {},
+	"paymentterms.push":               {},
+	"paymentterms.invalidate":         {},
+}
+
+// SocketServer wraps a socket server, the server only holds one func per
+// hook, this allows any number to be registered against each.
+//
+// Hooks are called in the order they are added and should all be added
+// before the server accepts connections.
+type SocketServer struct {
+	*server.SocketServer
+	clientJoin    []func(clientID, channelID string)
+	clientLeave   []func(clientID, channelID string)
+	channelCreate []func(channelID string)
+	channelClose  []func(channelID string)
+	closers       []func()
+	healthChecks  []proxy.HealthChecker
+
+	// mu guards the open channels, clients, connections, awaits and joining
+	// connections, tracked so they can be inspected by operators and closed
+	// on shutdown.
+	mu       sync.Mutex
+	channels map[string]*socketChannel
+	clients  map[string]*socketClient
+	conns    map[*websocket.Conn]struct{}
+	awaits   map[string]*socketAwait
+	closed   bool
+	// joining holds the connection being registered by Listen on each channel
+	// until the client join hook, the first place its clientID is known, takes it.
+	joining map[string]*joiningConn
+}
+
+// joiningConn is a connection being registered with the role it connected as,
+// joined is closed once it's been taken by the client join hook or Listen returns.
+type joiningConn struct {
+	ws     *websocket.Conn
+	role   proxy.ChannelRole
+	joined chan struct{}
+}
+
+// socketAwait is a message sent to a channel waiting on a reply from a wallet.
+type socketAwait struct {
+	proxy.SocketAwait
+	reply chan *sockets.Message
+}
+
+// socketChannel is a channel open on the server.
+type socketChannel struct {
+	created  time.Time
+	sent     uint64
+	received uint64
+	clients  map[string]struct{}
+}
+
+// socketClient is a connection joined to a channel.
+type socketClient struct {
+	channelID string
+	joined    time.Time
+	received  uint64
+	ws        *websocket.Conn
+	role      proxy.ChannelRole
+}
+
+func newSocketServer(s *server.SocketServer) *SocketServer {
+	svr := &SocketServer{
+		SocketServer: s,
+		channels:     map[string]*socketChannel{},
+		clients:      map[string]*socketClient{},
+		conns:        map[*websocket.Conn]struct{}{},
+		awaits:       map[string]*socketAwait{},
+		joining:      map[string]*joiningConn{},
+	}
+	s.OnClientJoin(func(clientID, channelID string) {
+		svr.mu.Lock()
+		c := &socketClient{
+			channelID: channelID,
+			joined:    time.Now().UTC(),
+		}
+		if conn := svr.joining[channelID]; conn != nil {
+			c.ws, c.role = conn.ws, conn.role
+			svr.joined(channelID, conn)
+		}
+		svr.clients[clientID] = c
+		if ch := svr.channels[channelID]; ch != nil {
+			ch.clients[clientID] = struct{}{}
+		}
+		svr.mu.Unlock()
+		for _, fn := range svr.clientJoin {
+			fn(clientID, channelID)
+		}
+	})
+	s.OnClientLeave(func(clientID, channelID string) {
+		svr.mu.Lock()
+		delete(svr.clients, clientID)
+		if ch := svr.channels[channelID]; ch != nil {
+			delete(ch.clients, clientID)
+		}
+		svr.mu.Unlock()
+		for _, fn := range svr.clientLeave {
+			fn(clientID, channelID)
+		}
+	})
+	s.OnChannelCreate(func(channelID string) {
+		svr.mu.Lock()
+		svr.channels[channelID] = &socketChannel{
+			created: time.Now().UTC(),
+			clients: map[string]struct{}{},
+		}
+		svr.mu.Unlock()
+		for _, fn := range svr.channelCreate {
+			fn(channelID)
+		}
+	})
+	s.OnChannelClose(func(channelID string) {
+		svr.mu.Lock()
+		delete(svr.channels, channelID)
+		svr.mu.Unlock()
+		for _, fn := range svr.channelClose {
+			fn(channelID)
+		}
+	})
+	return svr
+}
+
+// OnClientJoin adds a func called when a client joins a channel.
+func (s *SocketServer) OnClientJoin(fn func(clientID, channelID string)) {
+	s.clientJoin = append(s.clientJoin, fn)
+}
+
+// OnClientLeave adds a func called when a client leaves a channel.
+func (s *SocketServer) OnClientLeave(fn func(clientID, channelID string)) {
+	s.clientLeave = append(s.clientLeave, fn)
+}
+
+// OnChannelCreate adds a func called when a new channel is created.
+func (s *SocketServer) OnChannelCreate(fn func(channelID string)) {
+	s.channelCreate = append(s.channelCreate, fn)
+}
+
+// OnChannelClose adds a func called when all clients have left a channel and it is closed.
+func (s *SocketServer) OnChannelClose(fn func(channelID string)) {
+	s.channelClose = append(s.channelClose, fn)
+}
+
+// Listen will add the connection to the channel with the role it connected
+// as and read messages from it until it is closed.
+//
+// Connections joining the same channel are registered one at a time so each
+// is matched to the clientID it's given when it joins, connections to other
+// channels are registered at the same time.
+func (s *SocketServer) Listen(ws *websocket.Conn, channelID string, role proxy.ChannelRole) error {
+	conn := &joiningConn{ws: ws, role: role, joined: make(chan struct{})}
+	s.mu.Lock()
+	for prev := s.joining[channelID]; prev != nil; prev = s.joining[channelID] {
+		s.mu.Unlock()
+		<-prev.joined
+		s.mu.Lock()
+	}
+	s.joining[channelID] = conn
+	s.conns[ws] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.joined(channelID, conn)
+		delete(s.conns, ws)
+		s.mu.Unlock()
+	}()
+	return s.SocketServer.Listen(ws, channelID)
+}
+
+// joined releases the channel for the next connection to join, if conn is
+// still the one joining it. It must be called with mu held.
+func (s *SocketServer) joined(channelID string, conn *joiningConn) {
+	if s.joining[channelID] != conn {
+		return
+	}
+	delete(s.joining, channelID)
+	close(conn.joined)
+}
+
+// Broadcast will send a message to every client on the channel.
+func (s *SocketServer) Broadcast(channelID string, msg *sockets.Message) {
+	s.mu.Lock()
+	if ch := s.channels[channelID]; ch != nil {
+		ch.sent++
+	}
+	s.mu.Unlock()
+	s.SocketServer.Broadcast(channelID, msg)
+}
+
+// BroadcastAwait will send a message to the channel and wait on the first
+// reply from a wallet on it, the message is listed as an await until it returns.
+//
+// Replies are only received for the keys registered with HandleReplies.
+func (s *SocketServer) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	if !s.HasChannel(channelID) {
+		return nil, sockets.ErrChannelNotFound
+	}
+	await := &socketAwait{
+		SocketAwait: proxy.SocketAwait{
+			ChannelID:     channelID,
+			CorrelationID: msg.CorrelationID,
+			Key:           msg.Key(),
+			Started:       time.Now().UTC(),
+		},
+		reply: make(chan *sockets.Message, 1),
+	}
+	s.mu.Lock()
+	if ch := s.channels[channelID]; ch != nil {
+		ch.sent++
+	}
+	s.awaits[msg.CorrelationID] = await
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.awaits, msg.CorrelationID)
+		s.mu.Unlock()
+	}()
+	s.SocketServer.Broadcast(channelID, msg)
+	select {
+	case reply := <-await.reply:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, errors.New("timeout waiting for message")
+	}
+}
+
+// HandleReplies will register handlers for the keys wallets reply to awaits
+// with, replies are passed to the await they correlate to.
+//
+// Replies pass through the server middleware, so are checked against the
+// role of the client like any other message.
+func (s *SocketServer) HandleReplies(keys ...string) {
+	for _, key := range keys {
+		s.RegisterChannelHandler(key, s.reply)
+	}
+}
+
+// reply hands msg to the await it correlates to, if it was sent to the channel
+// the client is on. Replies not awaited are dropped.
+func (s *SocketServer) reply(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+	s.mu.Lock()
+	await := s.awaits[msg.CorrelationID]
+	c := s.clients[msg.ClientID]
+	if await != nil && c != nil && c.channelID == await.ChannelID {
+		delete(s.awaits, msg.CorrelationID)
+	} else {
+		await = nil
+	}
+	s.mu.Unlock()
+	if await != nil {
+		await.reply <- msg
+	}
+	return msg.NoContent()
+}
+
+// checkRole is socket middleware rejecting messages payers can't send, these
+// are the wallet only routes and replies to awaits.
+//
+// A client's role is known once it has joined, messages received before then
+// are treated as sent by a payer.
+func (s *SocketServer) checkRole(next sockets.HandlerFunc) sockets.HandlerFunc {
+	return func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+		s.mu.Lock()
+		c := s.clients[msg.ClientID]
+		_, awaited := s.awaits[msg.CorrelationID]
+		s.mu.Unlock()
+		if c != nil && c.role == proxy.ChannelRoleWallet {
+			return next(ctx, msg)
+		}
+		if _, ok := walletRoutes[msg.Key()]; ok || (awaited && msg.CorrelationID != "") {
+			return nil, client_errors.NewErrNotAuthorisedf("403", "%s can only be sent by wallets", msg.Key())
+		}
+		return next(ctx, msg)
+	}
+}
+
+// countMessages is socket middleware counting the messages received from
+// each client, and the replies sent to its channel.
+func (s *SocketServer) countMessages(next sockets.HandlerFunc) sockets.HandlerFunc {
+	return func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+		s.received(msg.ClientID, msg.ChannelID())
+		resp, err := next(ctx, msg)
+		if resp != nil {
+			s.mu.Lock()
+			if ch := s.channels[resp.ChannelID()]; ch != nil {
+				ch.sent++
+			}
+			s.mu.Unlock()
+		}
+		return resp, err
+	}
+}
+
+// received counts a message received from a client on a channel.
+func (s *SocketServer) received(clientID, channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.clients[clientID]; c != nil {
+		c.received++
+	}
+	if ch := s.channels[channelID]; ch != nil {
+		ch.received++
+	}
+}
+
+// Shutdown will send server.shutdown to every channel and give clients until
+// leaveGrace, or ctx is done, to leave. Connections still open are sent a
+// close frame, given closeGrace to close and then closed by the server.
+//
+// The server is only closed once every listener has returned, as they can't
+// leave their channel after it is.
+func (s *SocketServer) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	channels := make([]string, 0, len(s.channels))
+	for channelID := range s.channels {
+		channels = append(channels, channelID)
+	}
+	s.mu.Unlock()
+	for _, channelID := range channels {
+		msg := sockets.NewMessage(routeServerShutdown, "", channelID)
+		msg.AppID = "dpp"
+		s.Broadcast(channelID, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, leaveGrace)
+	defer cancel()
+	if !s.awaitLeave(ctx) {
+		closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+		for _, ws := range s.openConns() {
+			_ = ws.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second))
+		}
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), closeGrace)
+		defer closeCancel()
+		if !s.awaitLeave(closeCtx) {
+			for _, ws := range s.openConns() {
+				_ = ws.Close()
+			}
+			s.awaitLeave(context.Background())
+		}
+	}
+	s.Close()
+}
+
+// awaitLeave returns true once every connection has closed, or false if ctx
+// is done first.
+func (s *SocketServer) awaitLeave(ctx context.Context) bool {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for len(s.openConns()) > 0 {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// openConns returns the connections currently open.
+func (s *SocketServer) openConns() []*websocket.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]*websocket.Conn, 0, len(s.conns))
+	for ws := range s.conns {
+		conns = append(conns, ws)
+	}
+	return conns
+}
+
+// HasWallet returns true if a client with the wallet role is connected to
+// the channel on this node.
+func (s *SocketServer) HasWallet(channelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := s.channels[channelID]
+	if ch == nil {
+		return false
+	}
+	for clientID := range ch.clients {
+		if c := s.clients[clientID]; c != nil && c.role == proxy.ChannelRoleWallet {
+			return true
+		}
+	}
+	return false
+}
+
+// isWallet returns true if the client joined with the wallet role.
+func (s *SocketServer) isWallet(clientID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.clients[clientID]
+	return c != nil && c.role == proxy.ChannelRoleWallet
+}
+
+// wrappedServer is the socket server with its broadcasts sent through a
+// wrapper, such as a recorder or drainer.
+type wrappedServer struct {
+	sockets.ServerChannelBroadcaster
+	s *SocketServer
+}
+
+// HasChannel returns true if the channel is open on this node.
+func (w wrappedServer) HasChannel(channelID string) bool {
+	return w.s.HasChannel(channelID)
+}
+
+// HasWallet returns true if a wallet is connected to the channel on this node.
+func (w wrappedServer) HasWallet(channelID string) bool {
+	return w.s.HasWallet(channelID)
+}
+
+// SocketStats returns the number of channels and connections open on this node.
+func (s *SocketServer) SocketStats() proxy.SocketStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return proxy.SocketStats{
+		Channels:    len(s.channels),
+		Connections: len(s.conns),
+	}
+}
+
+// HealthCheck returns an error if the server is closed or anything it
+// depends on fails its check.
+func (s *SocketServer) HealthCheck(ctx context.Context) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return errors.New("socket server is closed")
+	}
+	for _, check := range s.healthChecks {
+		if err := check.HealthCheck(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close will stop the socket server and then anything depending on it.
+func (s *SocketServer) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.SocketServer.Close()
+	for _, fn := range s.closers {
+		fn()
+	}
+}
+
+// onHealthCheck adds a check of something the server depends on.
+func (s *SocketServer) onHealthCheck(check proxy.HealthChecker) {
+	s.healthChecks = append(s.healthChecks, check)
+}
+
+// onClose adds a func called when the server is closed.
+func (s *SocketServer) onClose(fn func()) {
+	s.closers = append(s.closers, fn)
+}
@@ -0,0 +1,306 @@
+package internal
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+	"github.com/theflyingcodr/sockets/server"
+
+	proxy "github.com/bitcoin-sv/dpp-proxy"
+	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+)
+
+// testMessage is the part of a message or error message read by clients.
+type testMessage struct {
+	Type          string `json:"type"`
+	OriginType    string `json:"originType"`
+	CorrelationID string `json:"correlationId"`
+	ChannelID     string `json:"channelId"`
+}
+
+// newTestSocketServer will setup a socket server accepting connections on /ws/:channelID,
+// without auth clients connecting with ?internal=true are wallets.
+func newTestSocketServer(t *testing.T) (*SocketServer, string) {
+	svr := newSocketServer(server.New())
+	svr.WithMiddleware(svr.checkRole)
+	svr.HandleReplies(socData.RoutePaymentACK, socData.RoutePaymentError)
+	svr.RegisterChannelHandler(socData.RoutePayment, func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+		return msg, nil
+	})
+	e := echo.New()
+	e.GET("/ws/:channelID", wsHandler(svr, svr, nil, wsListener{}))
+	srv := httptest.NewServer(e)
+	// clients close their connections first, the server can't be closed
+	// until every listener has returned.
+	t.Cleanup(func() {
+		assert.Eventually(t, func() bool {
+			return len(svr.openConns()) == 0
+		}, 2*time.Second, 10*time.Millisecond)
+		svr.mu.Lock()
+		closed := svr.closed
+		svr.mu.Unlock()
+		if !closed {
+			svr.Close()
+		}
+		srv.Close()
+	})
+	return svr, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// dialChannel will connect to the channel and wait until it has joined.
+func dialChannel(t *testing.T, url, channelID, query string) *websocket.Conn {
+	ws, _, err := websocket.DefaultDialer.Dial(url+"/ws/"+channelID+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ws.Close()
+	})
+	assert.Equal(t, sockets.MessageJoinSuccess, readMessage(t, ws).Type)
+	return ws
+}
+
+// readMessage returns the next message sent to the client.
+func readMessage(t *testing.T, ws *websocket.Conn) testMessage {
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg testMessage
+	if err := ws.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestSocketServer_BroadcastAwait(t *testing.T) {
+	tests := map[string]struct {
+		sender   string
+		key      string
+		expReply bool
+		expError bool
+	}{
+		"wallet reply is delivered": {
+			sender:   "wallet",
+			key:      socData.RoutePaymentACK,
+			expReply: true,
+		},
+		"wallet error reply is delivered": {
+			sender:   "wallet",
+			key:      socData.RoutePaymentError,
+			expReply: true,
+		},
+		"payer can't answer the await": {
+			sender:   "payer",
+			key:      socData.RoutePaymentACK,
+			expError: true,
+		},
+		"payer can't send other messages with the awaited correlationID": {
+			sender:   "payer",
+			key:      socData.RoutePayment,
+			expError: true,
+		},
+		"wallet on another channel can't answer the await": {
+			sender: "other wallet",
+			key:    socData.RoutePaymentACK,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svr, url := newTestSocketServer(t)
+			clients := map[string]*websocket.Conn{
+				"wallet":       dialChannel(t, url, "abc123", "?internal=true"),
+				"payer":        dialChannel(t, url, "abc123", ""),
+				"other wallet": dialChannel(t, url, "def456", "?internal=true"),
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			type result struct {
+				reply *sockets.Message
+				err   error
+			}
+			done := make(chan result, 1)
+			go func() {
+				msg := sockets.NewMessage(socData.RoutePayment, "", "abc123")
+				msg.CorrelationID = "corr1"
+				reply, err := svr.BroadcastAwait(ctx, "abc123", msg)
+				done <- result{reply: reply, err: err}
+			}()
+			// the request reaches every client on the channel.
+			assert.Equal(t, socData.RoutePayment, readMessage(t, clients["wallet"]).Type)
+			assert.Equal(t, socData.RoutePayment, readMessage(t, clients["payer"]).Type)
+
+			msg := sockets.NewMessage(test.key, "", "abc123")
+			msg.CorrelationID = "corr1"
+			assert.NoError(t, clients[test.sender].WriteJSON(msg))
+			if test.expError {
+				errMsg := readMessage(t, clients[test.sender])
+				assert.Equal(t, sockets.MessageError, errMsg.Type)
+				assert.Equal(t, test.key, errMsg.OriginType)
+			}
+
+			res := <-done
+			if !test.expReply {
+				assert.EqualError(t, res.err, "timeout waiting for message")
+				return
+			}
+			assert.NoError(t, res.err)
+			assert.Equal(t, test.key, res.reply.Key())
+			assert.Equal(t, "corr1", res.reply.CorrelationID)
+		})
+	}
+}
+
+func TestSocketServer_BroadcastAwaitNoChannel(t *testing.T) {
+	svr, _ := newTestSocketServer(t)
+	_, err := svr.BroadcastAwait(context.Background(), "abc123", sockets.NewMessage(socData.RoutePayment, "", "abc123"))
+	assert.Equal(t, sockets.ErrChannelNotFound, err)
+}
+
+func TestSocketServer_ListenRoles(t *testing.T) {
+	svr, url := newTestSocketServer(t)
+	// clients send the role they connected as, the clientID is set on
+	// messages as they're read from the connection.
+	var mu sync.Mutex
+	roles := map[string]proxy.ChannelRole{}
+	svr.RegisterChannelHandler("role", func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+		mu.Lock()
+		roles[msg.ClientID] = proxy.ChannelRole(msg.CorrelationID)
+		mu.Unlock()
+		return msg.NoContent()
+	})
+	// payers can only join channels already opened by a wallet.
+	dialChannel(t, url, "abc123", "?internal=true")
+	dialChannel(t, url, "def456", "?internal=true")
+
+	const conns = 20
+	errs := make(chan error, conns)
+	for i := 0; i < conns; i++ {
+		go func(i int) {
+			channelID, query, role := "abc123", "", proxy.ChannelRolePayer
+			if i%2 == 0 {
+				query, role = "?internal=true", proxy.ChannelRoleWallet
+			}
+			if i%3 == 0 {
+				channelID = "def456"
+			}
+			ws, _, err := websocket.DefaultDialer.Dial(url+"/ws/"+channelID+query, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			t.Cleanup(func() {
+				_ = ws.Close()
+			})
+			msg := sockets.NewMessage("role", "", channelID)
+			msg.CorrelationID = string(role)
+			errs <- ws.WriteJSON(msg)
+		}(i)
+	}
+	for i := 0; i < conns; i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(roles) == conns
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// every connection joining at the same time is given the role it connected as.
+	mu.Lock()
+	defer mu.Unlock()
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+	for clientID, role := range roles {
+		c := svr.clients[clientID]
+		if assert.NotNil(t, c) {
+			assert.Equal(t, role, c.role)
+			assert.NotNil(t, c.ws)
+		}
+	}
+	assert.Empty(t, svr.joining)
+}
+
+func TestSocketServer_Shutdown(t *testing.T) {
+	tests := map[string]struct {
+		leave       bool
+		ignoreClose bool
+		expClose    bool
+	}{
+		"clients leaving on shutdown": {
+			leave: true,
+		},
+		"clients not leaving are sent a close frame": {
+			expClose: true,
+		},
+		"clients ignoring the close frame are closed": {
+			ignoreClose: true,
+			expClose:    true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svr, url := newTestSocketServer(t)
+			clients := []*websocket.Conn{
+				dialChannel(t, url, "abc123", "?internal=true"),
+				dialChannel(t, url, "abc123", ""),
+				dialChannel(t, url, "def456", "?internal=true"),
+			}
+			for _, ws := range clients {
+				if test.ignoreClose {
+					ws.SetCloseHandler(func(int, string) error { return nil })
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				svr.Shutdown(ctx)
+				close(done)
+			}()
+			for _, ws := range clients {
+				assert.Equal(t, routeServerShutdown, readMessage(t, ws).Type)
+				if test.leave {
+					assert.NoError(t, ws.Close())
+				}
+			}
+			if !test.leave {
+				for _, ws := range clients {
+					_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+					_, _, err := ws.ReadMessage()
+					assert.Equal(t, test.expClose, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+				}
+			}
+
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				t.Fatal("shutdown didn't return")
+			}
+			assert.Empty(t, svr.openConns())
+			assert.EqualError(t, svr.HealthCheck(context.Background()), "socket server is closed")
+		})
+	}
+}
+
+func TestSocketServer_HasWallet(t *testing.T) {
+	svr, url := newTestSocketServer(t)
+	wallet := dialChannel(t, url, "abc123", "?internal=true")
+	dialChannel(t, url, "abc123", "")
+	assert.True(t, svr.HasWallet("abc123"))
+	assert.False(t, svr.HasWallet("def456"))
+
+	// the channel is kept open by the payer once the wallet leaves.
+	assert.NoError(t, wallet.Close())
+	assert.Eventually(t, func() bool {
+		return !svr.HasWallet("abc123")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, svr.HasChannel("abc123"))
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+)
+
+// certCheckInterval is how often the certificate files are checked for changes.
+const certCheckInterval = 10 * time.Second
+
+// SetupTLS will return the tls config a listener is served with, nil is
+// returned if TLS is disabled.
+//
+// The certificate is reloaded when its files change. If a client CA is set,
+// client certificates it issued are verified as set by clientAuth. The public
+// listener verifies them if given, as payers have none, and wsHandler requires
+// them of wallets.
+func SetupTLS(cfg config.Listener, clientAuth tls.ClientAuthType, l log.Logger) (*tls.Config, error) {
+	if !cfg.TLSEnabled() {
+		return nil, nil
+	}
+	certs, err := newCertReloader(l, cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	if cfg.TLSClientCA != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCA)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read tls client ca")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in tls client ca %s", cfg.TLSClientCA)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = clientAuth
+	}
+	return tlsCfg, nil
+}
+
+// certReloader serves a certificate, reloading it when its files are changed.
+type certReloader struct {
+	mu       sync.Mutex
+	l        log.Logger
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+	checked  time.Time
+}
+
+func newCertReloader(l log.Logger, certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{l: l, certFile: certFile, keyFile: keyFile}
+	modTime, err := r.modified()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the certificate, checking its files for changes at
+// most every certCheckInterval. If a changed certificate can't be loaded, such
+// as when only one file has been replaced, the last one is served and loading
+// is tried again on the next check.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+	modTime, err := r.modified()
+	if err != nil {
+		r.l.Error(err, "failed to check tls certificate")
+		return r.cert, nil
+	}
+	if modTime.Equal(r.modTime) {
+		return r.cert, nil
+	}
+	if err := r.load(modTime); err != nil {
+		r.l.Error(err, "failed to reload tls certificate")
+		return r.cert, nil
+	}
+	r.l.Infof("reloaded tls certificate %s", r.certFile)
+	return r.cert, nil
+}
+
+// load reads the certificate from its files.
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load tls certificate")
+	}
+	r.cert, r.modTime, r.checked = &cert, modTime, time.Now()
+	return nil
+}
+
+// modified returns the latest time either certificate file was changed.
+func (r *certReloader) modified() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "failed to stat tls certificate")
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
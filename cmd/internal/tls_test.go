@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	dppMiddleware "github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+// testCA is a throwaway certificate authority.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a new certificate for commonName.
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to name in dir, with its modification time set to modTime.
+func writeFile(t *testing.T, dir, name string, data []byte, modTime time.Time) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// commonName returns the common name of the leaf of cert.
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_GetCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	old := time.Now().Add(-time.Hour)
+	tests := map[string]struct {
+		replace func(t *testing.T, dir string)
+		expCN   string
+	}{
+		"unchanged certificate served": {
+			replace: func(t *testing.T, dir string) {},
+			expCN:   "dpp1.example.com",
+		},
+		"changed certificate reloaded": {
+			replace: func(t *testing.T, dir string) {
+				cert, key := ca.issue(t, "dpp2.example.com", x509.ExtKeyUsageServerAuth)
+				writeFile(t, dir, "tls.crt", cert, time.Now())
+				writeFile(t, dir, "tls.key", key, time.Now())
+			},
+			expCN: "dpp2.example.com",
+		},
+		"last certificate served until both files are replaced": {
+			replace: func(t *testing.T, dir string) {
+				cert, _ := ca.issue(t, "dpp2.example.com", x509.ExtKeyUsageServerAuth)
+				writeFile(t, dir, "tls.crt", cert, time.Now())
+			},
+			expCN: "dpp1.example.com",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			cert, key := ca.issue(t, "dpp1.example.com", x509.ExtKeyUsageServerAuth)
+			r, err := newCertReloader(log.Noop{}, writeFile(t, dir, "tls.crt", cert, old),
+				writeFile(t, dir, "tls.key", key, old))
+			if err != nil {
+				t.Fatal(err)
+			}
+			served, err := r.GetCertificate(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "dpp1.example.com", commonName(t, served))
+
+			test.replace(t, dir)
+			// files are only checked every certCheckInterval.
+			served, err = r.GetCertificate(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "dpp1.example.com", commonName(t, served))
+			r.checked = time.Now().Add(-certCheckInterval)
+			served, err = r.GetCertificate(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expCN, commonName(t, served))
+		})
+	}
+}
+
+func TestSetupTLS_ClientCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	otherCA := newTestCA(t)
+	dir := t.TempDir()
+	serverCert, serverKey := ca.issue(t, "dpp.example.com", x509.ExtKeyUsageServerAuth)
+	tlsCfg, err := SetupTLS(config.Listener{
+		TLSCert:     writeFile(t, dir, "tls.crt", serverCert, time.Now()),
+		TLSKey:      writeFile(t, dir, "tls.key", serverKey, time.Now()),
+		TLSClientCA: writeFile(t, dir, "ca.crt", ca.pem, time.Now()),
+	}, tls.VerifyClientCertIfGiven, log.Noop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = dppMiddleware.ErrorHandler(log.Noop{})
+	e.GET("/ws/:channelID", func(c echo.Context) error {
+		role, err := channelRole(c, c.Param("channelID"), nil, wsListener{mtls: true})
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(role))
+	})
+	srv := httptest.NewUnstartedServer(e)
+	srv.TLS = tlsCfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := map[string]struct {
+		ca         *testCA
+		commonName string
+		channelID  string
+		expStatus  int
+		expRole    string
+		expErr     bool
+	}{
+		"merchant certificate opens its channels": {
+			ca:         ca,
+			commonName: "merchant1",
+			channelID:  "merchant1.abc123",
+			expStatus:  http.StatusOK,
+			expRole:    "wallet",
+		},
+		"merchant certificate can't open another merchant's channel": {
+			ca:         ca,
+			commonName: "merchant1",
+			channelID:  "merchant2.abc123",
+			expStatus:  http.StatusForbidden,
+		},
+		"merchant prefix must be followed by a dot": {
+			ca:         ca,
+			commonName: "merchant1",
+			channelID:  "merchant10.abc123",
+			expStatus:  http.StatusForbidden,
+		},
+		"* certificate opens any channel": {
+			ca:         ca,
+			commonName: "*",
+			channelID:  "merchant2.abc123",
+			expStatus:  http.StatusOK,
+			expRole:    "wallet",
+		},
+		"certificate from another ca rejected": {
+			ca:         otherCA,
+			commonName: "*",
+			channelID:  "merchant1.abc123",
+			expErr:     true,
+		},
+		"connection without a certificate is a payer": {
+			channelID: "merchant1.abc123",
+			expStatus: http.StatusOK,
+			expRole:   "payer",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr := srv.Client().Transport.(*http.Transport).Clone()
+			if test.ca != nil {
+				certPEM, keyPEM := test.ca.issue(t, test.commonName, x509.ExtKeyUsageClientAuth)
+				cert, err := tls.X509KeyPair(certPEM, keyPEM)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tr.TLSClientConfig.Certificates = []tls.Certificate{cert}
+			}
+			defer tr.CloseIdleConnections()
+			resp, err := (&http.Client{Transport: tr}).Get(srv.URL + "/ws/" + test.channelID)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			assert.Equal(t, test.expStatus, resp.StatusCode)
+			if test.expRole != "" {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, test.expRole, string(body))
+			}
+		})
+	}
+}
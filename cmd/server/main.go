@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"os"
 	"os/signal"
-	"time"
+	"syscall"
 
+	proxy "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/bitcoin-sv/dpp-proxy/cmd/internal"
 	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data/sqlite"
 	"github.com/bitcoin-sv/dpp-proxy/log"
 )
 
@@ -41,6 +44,10 @@ const banner = `
 //   - http
 //   - https
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		healthcheck()
+		return
+	}
 	println("\033[32m" + banner + "\033[0m")
 	config.SetupDefaults()
 	cfg := config.NewViperConfig(appname).
@@ -50,6 +57,19 @@ func main() {
 		WithSockets().
 		WithPayD().
 		WithTransports().
+		WithDb().
+		WithLedger().
+		WithCache().
+		WithProofQueue().
+		WithCluster().
+		WithPaymentTerms().
+		WithSPV().
+		WithAuth().
+		WithProofsAuth().
+		WithRateLimit().
+		WithAdmin().
+		WithWallet().
+		WithOps().
 		Load()
 	log := log.NewZero(cfg.Logging)
 	log.Infof("\n------Environment: %#v -----\n", cfg.Server)
@@ -57,40 +77,77 @@ func main() {
 		log.Fatal(err, "config error")
 	}
 
-	e := internal.SetupEcho(cfg, log)
+	var db *sql.DB
+	if cfg.UsesDb() {
+		var err error
+		if db, err = sqlite.NewSQLite(cfg.Db.DSN); err != nil {
+			log.Fatal(err, "failed to setup database")
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+	}
+
+	ls := internal.SetupListeners(cfg, log)
 
 	if cfg.Server.SwaggerEnabled {
-		internal.SetupSwagger(*cfg.Server, e)
+		internal.SetupSwagger(*cfg.Server, ls.Public)
 	}
 
 	// setup transports
+	sd := internal.NewShutdown(log, ls)
+	checks := map[string]proxy.HealthChecker{
+		"shutdown": sd,
+	}
+	if db != nil {
+		checks["db"] = proxy.HealthCheckFunc(db.PingContext)
+	}
+	var s *internal.SocketServer
 	switch cfg.Transports.Mode {
 	case config.TransportModeSocket:
-		s := internal.SetupSockets(*cfg.Sockets, e)
-		internal.SetupSocketMetrics(s)
-		defer s.Close()
+		s = internal.SetupSockets(*cfg, log, ls, sd)
 	case config.TransportModeHybrid:
-		s := internal.SetupHybrid(*cfg, log, e)
+		s = internal.SetupHybrid(*cfg, log, ls, db, sd)
+	case config.TransportModeHTTP:
+		internal.SetupHTTP(*cfg, log, ls, db, sd)
+	}
+	var stats proxy.SocketStatsReader
+	if s != nil {
 		internal.SetupSocketMetrics(s)
-		defer s.Close()
+		checks["sockets"], stats = s, s
+	}
+	internal.SetupHealth(*cfg, ls, checks, stats)
+	if cfg.Admin.Enabled {
+		internal.SetupAdmin(*cfg, ls, s, db)
 	}
 	if cfg.Deployment.IsDev() {
-		internal.PrintDev(e)
+		internal.PrintDev(ls)
+	}
+	if err := ls.Start(log); err != nil {
+		log.Fatal(err, "failed to start listeners")
 	}
-	go func() {
-		log.Error(e.Start(cfg.Server.Port), "echo server failed")
-	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with a timeout of 10 seconds.
+	// Wait for an interrupt or, as sent by container runtimes, a terminate
+	// signal to gracefully shutdown the server within the drain timeout.
 	// Use a buffered channel to avoid missing signals as recommended for signal.Notify
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
 	defer cancel()
-	if err := e.Shutdown(ctx); err != nil {
-		log.Error(err, "")
-	}
+	sd.Run(ctx)
+}
 
+// healthcheck exits with a non zero code if the server running on this host
+// isn't live, it's used as the docker HEALTHCHECK as the image has no curl.
+func healthcheck() {
+	config.SetupDefaults()
+	cfg := config.NewViperConfig(appname).
+		WithServer().
+		Load()
+	if err := internal.Healthcheck(*cfg.Server); err != nil {
+		println(err.Error())
+		os.Exit(1)
+	}
 }
@@ -12,16 +12,94 @@ const (
 	EnvServerFQDN                  = "server.fqdn"
 	EnvServerSwaggerEnabled        = "server.swagger.enabled"
 	EnvServerSwaggerHost           = "server.swagger.host"
+	EnvServerCORSOrigins           = "server.cors.origins"
+	EnvServerCORSHeaders           = "server.cors.headers"
+	EnvServerWSOrigins             = "server.ws.origins"
+	EnvServerSecurityHeaders       = "server.securityheaders"
+	EnvServerHSTSMaxAge            = "server.hsts.maxage"
+	EnvServerTLSCert               = "server.tls.cert"
+	EnvServerTLSKey                = "server.tls.key"
+	EnvServerTLSClientCA           = "server.tls.clientca"
+	EnvServerDrainTimeout          = "server.drain.timeout"
 	EnvEnvironment                 = "env.environment"
 	EnvRegion                      = "env.region"
 	EnvVersion                     = "env.version"
 	EnvCommit                      = "env.commit"
 	EnvBuildDate                   = "env.builddate"
+	EnvBitcoinNetwork              = "env.bitcoin_network"
 	EnvLogLevel                    = "log.level"
 	EnvPaydNoop                    = "payd.noop"
+	EnvPaydHost                    = "payd.host"
+	EnvPaydMerchants               = "payd.merchants"
+	EnvPaydTimeout                 = "payd.timeout"
+	EnvPaydFakeFixtures            = "payd.fake.fixtures"
+	EnvPaydFakeSigningKey          = "payd.fake.signingkey"
+	EnvPaydFakeLatency             = "payd.fake.latency"
 	EnvSocketChannelTimeoutSeconds = "socket.channel.timeoutseconds"
 	EnvSocketMaxMessageBytes       = "socket.maxmessage.bytes"
+	EnvSocketPaymentTermsTimeout   = "socket.paymentterms.timeout"
+	EnvSocketPaymentTermsRetries   = "socket.paymentterms.retries"
+	EnvSocketPaymentTermsBackoff   = "socket.paymentterms.backoff"
+	EnvSocketPaymentTermsReconnect = "socket.paymentterms.awaitreconnect"
+	EnvSocketPaymentTimeout        = "socket.payment.timeout"
+	EnvSocketPaymentRetries        = "socket.payment.retries"
+	EnvSocketPaymentBackoff        = "socket.payment.backoff"
+	EnvSocketPaymentReconnect      = "socket.payment.awaitreconnect"
+	EnvSocketRecord                = "socket.record"
 	EnvTransportMode               = "transport.mode"
+	EnvDbDSN                       = "db.dsn"
+	EnvLedgerEnabled               = "ledger.enabled"
+	EnvLedgerDSN                   = "ledger.dsn" // deprecated, read if EnvDbDSN isn't set
+	EnvCachePaymentTerms           = "cache.paymentterms"
+	EnvCachePaymentResultsTTL      = "cache.paymentresults.ttl"
+	EnvCacheInvoiceStatesTTL       = "cache.invoicestates.ttl"
+	EnvProofQueueEnabled           = "proofs.queue.enabled"
+	EnvProofQueueTTL               = "proofs.queue.ttl"
+	EnvClusterEnabled              = "cluster.enabled"
+	EnvClusterPrefix               = "cluster.prefix"
+	EnvClusterRedisAddr            = "cluster.redis.addr"
+	EnvClusterRedisPassword        = "cluster.redis.password"
+	EnvClusterRedisDB              = "cluster.redis.db"
+	EnvPaymentTermsRequireSig      = "paymentterms.requiresignature"
+	EnvPaymentTermsRequireNetwork  = "paymentterms.requirenetwork"
+	EnvSPVEnabled                  = "spv.enabled"
+	EnvSPVHeaders                  = "spv.headers"
+	EnvSPVProofs                   = "spv.proofs"
+	EnvAuthEnabled                 = "auth.enabled"
+	EnvAuthWalletKeys              = "auth.wallet.keys"
+	EnvAuthChannelSecret           = "auth.channel.secret"
+	EnvProofsAuthEnabled           = "proofs.auth.enabled"
+	EnvProofsAuthKeys              = "proofs.auth.keys"
+	EnvProofsAuthSecrets           = "proofs.auth.secrets"
+	EnvProofsAuthAllowlist         = "proofs.auth.allowlist"
+	EnvProofsAuthRequireSig        = "proofs.auth.requiresignature"
+	EnvProofsAuthTrustedProxies    = "proofs.auth.trustedproxies"
+	EnvRateLimitEnabled            = "ratelimit.enabled"
+	EnvRateLimitTrustProxy         = "ratelimit.trustproxy"
+	EnvRateLimitIPRate             = "ratelimit.ip.rate"
+	EnvRateLimitIPBurst            = "ratelimit.ip.burst"
+	EnvRateLimitPaymentIDRate      = "ratelimit.paymentid.rate"
+	EnvRateLimitPaymentIDBurst     = "ratelimit.paymentid.burst"
+	EnvRateLimitNotFoundRate       = "ratelimit.notfound.rate"
+	EnvRateLimitNotFoundBurst      = "ratelimit.notfound.burst"
+	EnvAdminEnabled                = "admin.enabled"
+	EnvAdminKeys                   = "admin.keys"
+	EnvAdminPort                   = "admin.port" // Deprecated: use EnvOpsPort.
+	EnvWalletPort                  = "wallet.port"
+	EnvWalletCORSOrigins           = "wallet.cors.origins"
+	EnvWalletCORSHeaders           = "wallet.cors.headers"
+	EnvWalletWSOrigins             = "wallet.ws.origins"
+	EnvWalletTLSCert               = "wallet.tls.cert"
+	EnvWalletTLSKey                = "wallet.tls.key"
+	EnvWalletTLSClientCA           = "wallet.tls.clientca"
+	EnvOpsPort                     = "ops.port"
+	EnvOpsCORSOrigins              = "ops.cors.origins"
+	EnvOpsCORSHeaders              = "ops.cors.headers"
+	EnvOpsTLSCert                  = "ops.tls.cert"
+	EnvOpsTLSKey                   = "ops.tls.key"
+	EnvOpsTLSClientCA              = "ops.tls.clientca"
+	EnvOpsKeys                     = "ops.keys"
+	EnvOpsPprof                    = "ops.pprof"
 
 	LogDebug = "debug"
 	LogInfo  = "info"
@@ -30,16 +108,40 @@ const (
 
 	TransportModeHybrid = "hybrid"
 	TransportModeSocket = "socket"
+	TransportModeHTTP   = "http"
+
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+	NetworkSTN     = "stn"
+	NetworkRegtest = "regtest"
 )
 
 // Config returns strongly typed config values.
 type Config struct {
-	Logging    *Logging
-	Server     *Server
-	Deployment *Deployment
-	PayD       *PayD
-	Sockets    *Socket
-	Transports *Transports
+	Logging      *Logging
+	Server       *Server
+	Deployment   *Deployment
+	PayD         *PayD
+	Sockets      *Socket
+	Transports   *Transports
+	Db           *Db
+	Ledger       *Ledger
+	Cache        *Cache
+	ProofQueue   *ProofQueue
+	Cluster      *Cluster
+	PaymentTerms *PaymentTerms
+	SPV          *SPV
+	Auth         *Auth
+	ProofsAuth   *ProofsAuth
+	RateLimit    *RateLimit
+	Admin        *Admin
+	Wallet       *Wallet
+	Ops          *Ops
+}
+
+// UsesDb returns true if a feature needing the sqlite database is enabled.
+func (c *Config) UsesDb() bool {
+	return (c.Ledger != nil && c.Ledger.Enabled) || (c.ProofQueue != nil && c.ProofQueue.Enabled)
 }
 
 // Deployment contains information relating to the current
@@ -51,6 +153,8 @@ type Deployment struct {
 	Version     string
 	Commit      string
 	BuildDate   time.Time
+	// Network is the bitcoin network payments are made on.
+	Network string
 }
 
 // IsDev determines if this app is running on a dev environment.
@@ -68,9 +172,41 @@ type Logging struct {
 	Level string
 }
 
+// Listener contains the settings of an http listener.
+type Listener struct {
+	// Port is the address the listener is served on, if empty the routes of
+	// the wallet and ops listeners are served by the server instead.
+	Port string
+	// CORSOrigins are the origins browsers can call the listener from, * allows any.
+	CORSOrigins []string
+	// CORSHeaders are the request headers browsers can send cross-origin.
+	CORSHeaders []string
+	// TLSCert and TLSKey are the files of the certificate served over TLS, if
+	// empty the listener is plain http. Changes to the files are picked up
+	// without a restart.
+	TLSCert string
+	TLSKey  string
+	// TLSClientCA is the file of the CA client certificates are issued by.
+	TLSClientCA string
+}
+
+// Enabled returns true if the listener has a port to be served on.
+func (l Listener) Enabled() bool {
+	return l.Port != ""
+}
+
+// TLSEnabled returns true if the listener is served over TLS.
+func (l Listener) TLSEnabled() bool {
+	return l.TLSCert != "" || l.TLSKey != ""
+}
+
 // Server contains all settings required to run a web server.
+//
+// The server is the public listener payers call, wallets and operators use
+// it too unless the wallet and ops listeners are enabled. If the server has a
+// TLSClientCA, wallets must connect to /ws with a client certificate.
 type Server struct {
-	Port     string
+	Listener
 	Hostname string
 	// FQDN - fully qualified domain name, used to form the PaymentTerms
 	// payment URL as this may be different from the hostname + port.
@@ -78,17 +214,90 @@ type Server struct {
 	// SwaggerEnabled if true we will include an endpoint to serve swagger documents.
 	SwaggerEnabled bool
 	SwaggerHost    string
+	// WSOrigins are the origins browsers can open a websocket from, * allows
+	// any. Same origin connections and those without an Origin header, such as
+	// wallets, are always allowed so if empty only those are.
+	WSOrigins []string
+	// SecurityHeaders if true adds HSTS, X-Content-Type-Options and a
+	// frame-ancestors policy to every response.
+	SecurityHeaders bool
+	// HSTSMaxAge is the max-age in seconds of the HSTS header, it is only sent
+	// on https requests.
+	HSTSMaxAge int
+	// DrainTimeout is the deadline for in-flight requests and websockets to
+	// finish when the server is stopped.
+	DrainTimeout time.Duration
+}
+
+// AllowsAnyWSOrigin returns true if websockets can be opened from any origin.
+func (s *Server) AllowsAnyWSOrigin() bool {
+	return allowsAnyOrigin(s.WSOrigins)
+}
+
+// allowsAnyOrigin returns true if origins contains *.
+func allowsAnyOrigin(origins []string) bool {
+	for _, origin := range origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
 }
 
-// PayD is for mock testing.
+// PayD contains settings used to reach payee wallets over http when
+// running in http transport mode, Noop can be set to use a fake payee for testing.
 type PayD struct {
 	Noop bool
+	// Fake contains settings for the fake payee used when Noop is set.
+	Fake FakePayee
+	// Host is the base url of the default payee wallet.
+	Host string
+	// Merchants maps a merchantID to the base url of its wallet, a paymentID
+	// prefixed with "merchantID." is sent to that wallet.
+	Merchants map[string]string
+	// Timeout is the maximum time to wait on a payee wallet request.
+	Timeout time.Duration
+}
+
+// FakePayee contains settings for a fake payee wallet, used to exercise the
+// proxy without running a real wallet.
+type FakePayee struct {
+	// Fixtures is a directory of <paymentID>.json files setting the responses
+	// per paymentID, default.json is used for paymentIDs without a fixture.
+	Fixtures string
+	// SigningKey is a WIF used to sign PaymentTerms, a key is generated on startup if not set.
+	SigningKey string
+	// Latency is added before every response unless set by a fixture.
+	Latency time.Duration
 }
 
 // Socket contains config items for a socket server.
 type Socket struct {
 	MaxMessageBytes int
 	ChannelTimeout  time.Duration
+	// PaymentTerms is the policy used when requesting PaymentTerms from a payee wallet.
+	PaymentTerms RoundTrip
+	// Payment is the policy used when sending a Payment to a payee wallet.
+	Payment RoundTrip
+	// Record if set is a file all messages to and from payee wallets are appended
+	// to, as JSON lines, for debugging.
+	Record string
+}
+
+// RoundTrip is the policy used when sending a message to a payee wallet and
+// waiting on its reply.
+type RoundTrip struct {
+	// Timeout is how long to wait on a reply for each attempt.
+	Timeout time.Duration
+	// Retries is the number of times a request is resent after a timeout or,
+	// if AwaitReconnect is true, when no wallet is connected. Payments are
+	// never resent after a timeout as the wallet may have received them.
+	Retries int
+	// Backoff is the wait before the first retry, it doubles on each retry after.
+	Backoff time.Duration
+	// AwaitReconnect if true will retry when the payee wallet is not connected,
+	// giving it a chance to reconnect, rather than failing immediately.
+	AwaitReconnect bool
 }
 
 // Transports enables or disables dpp transports.
@@ -96,6 +305,163 @@ type Transports struct {
 	Mode string
 }
 
+// Db contains settings for the sqlite database used by the ledger and proof queue.
+type Db struct {
+	// DSN is the sqlite data source name used to open the database.
+	DSN string
+}
+
+// Ledger contains settings for the persistent payment ledger.
+type Ledger struct {
+	// Enabled if true will record every PaymentTerms, Payment, PaymentACK and
+	// proof passing through the proxy.
+	Enabled bool
+}
+
+// Cache contains settings for in memory caches.
+type Cache struct {
+	// PaymentTerms if true will cache signed PaymentTerms until they expire
+	// rather than requesting them from the payee wallet on every call.
+	PaymentTerms bool
+	// PaymentResultsTTL is how long the outcome of a payment is kept to answer
+	// a repeated submission with the same Idempotency-Key or transactions, 0
+	// disables this.
+	PaymentResultsTTL time.Duration
+	// InvoiceStatesTTL is how long the state of an invoice is kept after it
+	// last changed, 0 disables invoice states and the status endpoint.
+	InvoiceStatesTTL time.Duration
+}
+
+// ProofQueue contains settings for queueing proofs sent to offline payee wallets.
+type ProofQueue struct {
+	// Enabled if true will store proofs for a paymentReference with no wallet
+	// listening and send them when the wallet joins the channel.
+	Enabled bool
+	// TTL is how long a queued proof is kept before it is dropped.
+	TTL time.Duration
+}
+
+// Cluster contains settings for running several proxy nodes behind a load balancer.
+type Cluster struct {
+	// Enabled if true will route socket messages over redis to the node holding the channel.
+	Enabled bool
+	// Prefix is added to all redis channels, allowing clusters to share a redis.
+	Prefix        string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// PaymentTerms contains the checks applied to PaymentTerms received from payee wallets.
+type PaymentTerms struct {
+	// RequireSignature if true will reject terms that aren't signed by the payee wallet.
+	RequireSignature bool
+	// RequireNetwork if true will reject terms for a network other than the deployment's.
+	RequireNetwork bool
+}
+
+// SPV contains settings for verifying the ancestry of payments.
+type SPV struct {
+	// Enabled if true will reject payments whose transactions aren't anchored
+	// by their ancestry to known blocks.
+	Enabled bool
+	// Headers is a file of hex encoded block headers, one per line, merkle
+	// proofs are checked against.
+	Headers string
+	// Proofs if true will reject merkle proofs received from the payment processor
+	// that don't lead to a block in Headers.
+	Proofs bool
+}
+
+// Auth contains settings for authenticating websocket connections.
+type Auth struct {
+	// Enabled if true requires wallet and payer connections to present an API
+	// key or channel token, if false wallets open channels with ?internal=true.
+	Enabled bool
+	// WalletKeys maps a merchantID to the API key its wallets connect with, a
+	// key can open channels for paymentIDs of the form merchantID.invoiceID and
+	// the key of merchant * can open any channel.
+	WalletKeys map[string]string
+	// ChannelSecret is the HMAC key channel tokens are signed with.
+	ChannelSecret string
+}
+
+// ProofsAuth contains settings for authenticating proof callbacks.
+type ProofsAuth struct {
+	// Enabled if true requires proofs to be sent by a known broadcaster.
+	Enabled bool
+	// Keys maps a broadcaster to the API key it sends proofs with.
+	Keys map[string]string
+	// Secrets maps a broadcaster to the secret it signs proof requests with.
+	Secrets map[string]string
+	// Allowlist is the IPs and CIDRs proofs can be sent from, if empty proofs
+	// can be sent from anywhere.
+	Allowlist []string
+	// TrustedProxies are the IPs and CIDRs of proxies the allowlist is checked
+	// behind, the client address is read from their X-Forwarded-For header. If
+	// empty the address of the connecting peer is checked.
+	TrustedProxies []string
+	// RequireSignature if true rejects proofs that aren't in a signed envelope,
+	// unwrapped mAPI and ARC callbacks included. This applies even if Enabled is false.
+	RequireSignature bool
+}
+
+// RateLimit contains settings for limiting requests to the payment endpoints.
+type RateLimit struct {
+	// Enabled if true limits requests to the payment endpoints.
+	Enabled bool
+	// TrustProxy if true reads the client IP from the X-Forwarded-For header
+	// set by a proxy on a private network, otherwise the peer address is used.
+	TrustProxy bool
+	// IP limits the requests of each client IP.
+	IP TokenBucket
+	// PaymentID limits the requests for each paymentID.
+	PaymentID TokenBucket
+	// NotFound limits the 404 responses each client IP can receive, once used
+	// up the client is limited on every request.
+	NotFound TokenBucket
+}
+
+// TokenBucket is a limit of Rate requests a second, allowing bursts of up to
+// Burst requests.
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+// Admin contains settings for the operator API, served on the ops listener.
+type Admin struct {
+	// Enabled if true serves the admin API.
+	Enabled bool
+	// Keys are the API keys operators can call the admin API with.
+	Keys []string
+}
+
+// Wallet contains settings for the listener wallets connect on.
+type Wallet struct {
+	Listener
+	// WSOrigins are the origins browsers can open a websocket from on the
+	// wallet listener, * allows any. Same origin connections and those without
+	// an Origin header are always allowed so if empty only those are.
+	WSOrigins []string
+}
+
+// AllowsAnyWSOrigin returns true if websockets can be opened from any origin.
+func (w *Wallet) AllowsAnyWSOrigin() bool {
+	return allowsAnyOrigin(w.WSOrigins)
+}
+
+// Ops contains settings for the listener operators use, serving metrics,
+// swagger, pprof and the admin API.
+type Ops struct {
+	Listener
+	// Keys are the API keys metrics and pprof can be read with, if empty they
+	// can be read by anything able to reach the listener.
+	Keys []string
+	// Pprof if true serves the runtime profiles under /debug/pprof.
+	Pprof bool
+}
+
 // ConfigurationLoader will load configuration items
 // into a struct that contains a configuration.
 type ConfigurationLoader interface {
@@ -105,5 +471,18 @@ type ConfigurationLoader interface {
 	WithPayD() ConfigurationLoader
 	WithSockets() ConfigurationLoader
 	WithTransports() ConfigurationLoader
+	WithDb() ConfigurationLoader
+	WithLedger() ConfigurationLoader
+	WithCache() ConfigurationLoader
+	WithProofQueue() ConfigurationLoader
+	WithCluster() ConfigurationLoader
+	WithPaymentTerms() ConfigurationLoader
+	WithSPV() ConfigurationLoader
+	WithAuth() ConfigurationLoader
+	WithProofsAuth() ConfigurationLoader
+	WithRateLimit() ConfigurationLoader
+	WithAdmin() ConfigurationLoader
+	WithWallet() ConfigurationLoader
+	WithOps() ConfigurationLoader
 	Load() *Config
 }
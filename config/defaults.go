@@ -16,6 +16,15 @@ func SetupDefaults() {
 	viper.SetDefault(EnvServerFQDN, "dpp:8445")
 	viper.SetDefault(EnvServerSwaggerEnabled, true)
 	viper.SetDefault(EnvServerSwaggerHost, "localhost:8445")
+	viper.SetDefault(EnvServerCORSOrigins, "*")
+	viper.SetDefault(EnvServerCORSHeaders, "Origin,Content-Type,Accept,If-None-Match,Idempotency-Key")
+	viper.SetDefault(EnvServerWSOrigins, "")
+	viper.SetDefault(EnvServerSecurityHeaders, false)
+	viper.SetDefault(EnvServerHSTSMaxAge, 31536000)
+	viper.SetDefault(EnvServerTLSCert, "")
+	viper.SetDefault(EnvServerTLSKey, "")
+	viper.SetDefault(EnvServerTLSClientCA, "")
+	viper.SetDefault(EnvServerDrainTimeout, time.Second*30)
 
 	// Environment Defaults
 	viper.SetDefault(EnvEnvironment, "dev")
@@ -23,6 +32,7 @@ func SetupDefaults() {
 	viper.SetDefault(EnvCommit, "test")
 	viper.SetDefault(EnvVersion, "v0.0.0")
 	viper.SetDefault(EnvBuildDate, time.Now().UTC())
+	viper.SetDefault(EnvBitcoinNetwork, NetworkMainnet)
 
 	// Log level defaults
 	viper.SetDefault(EnvLogLevel, "info")
@@ -30,7 +40,104 @@ func SetupDefaults() {
 	// Socket settings
 	viper.SetDefault(EnvSocketChannelTimeoutSeconds, 7200*time.Second) // 2 hrs in seconds
 	viper.SetDefault(EnvSocketMaxMessageBytes, 10000)
+	viper.SetDefault(EnvSocketPaymentTermsTimeout, 10*time.Second)
+	viper.SetDefault(EnvSocketPaymentTermsRetries, 3)
+	viper.SetDefault(EnvSocketPaymentTermsBackoff, 500*time.Millisecond)
+	viper.SetDefault(EnvSocketPaymentTermsReconnect, true)
+	viper.SetDefault(EnvSocketPaymentTimeout, 10*time.Second)
+	viper.SetDefault(EnvSocketPaymentRetries, 0)
+	viper.SetDefault(EnvSocketPaymentBackoff, 500*time.Millisecond)
+	viper.SetDefault(EnvSocketPaymentReconnect, true)
+	viper.SetDefault(EnvSocketRecord, "")
+
+	// PayD settings
+	viper.SetDefault(EnvPaydHost, "")
+	viper.SetDefault(EnvPaydTimeout, 10*time.Second)
+	viper.SetDefault(EnvPaydFakeFixtures, "")
+	viper.SetDefault(EnvPaydFakeSigningKey, "")
+	viper.SetDefault(EnvPaydFakeLatency, 0)
 
 	// Transport settings
 	viper.SetDefault(EnvTransportMode, TransportModeHybrid)
+
+	// Db settings
+	// db.dsn replaced ledger.dsn, the ledger's default is kept so existing
+	// deployments keep their database.
+	viper.SetDefault(EnvDbDSN, "")
+	viper.SetDefault(EnvLedgerDSN, "file:ledger.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+
+	// Ledger settings
+	viper.SetDefault(EnvLedgerEnabled, false)
+
+	// Cache settings
+	viper.SetDefault(EnvCachePaymentTerms, false)
+	viper.SetDefault(EnvCachePaymentResultsTTL, 0)
+	viper.SetDefault(EnvCacheInvoiceStatesTTL, 0)
+
+	// Proof queue settings
+	viper.SetDefault(EnvProofQueueEnabled, false)
+	viper.SetDefault(EnvProofQueueTTL, 72*time.Hour)
+
+	// Cluster settings
+	viper.SetDefault(EnvClusterEnabled, false)
+	viper.SetDefault(EnvClusterPrefix, "dpp")
+	viper.SetDefault(EnvClusterRedisAddr, "localhost:6379")
+	viper.SetDefault(EnvClusterRedisPassword, "")
+	viper.SetDefault(EnvClusterRedisDB, 0)
+
+	// PaymentTerms settings
+	viper.SetDefault(EnvPaymentTermsRequireSig, false)
+	viper.SetDefault(EnvPaymentTermsRequireNetwork, false)
+
+	// SPV settings
+	viper.SetDefault(EnvSPVEnabled, false)
+	viper.SetDefault(EnvSPVHeaders, "")
+	viper.SetDefault(EnvSPVProofs, false)
+
+	// Auth settings
+	viper.SetDefault(EnvAuthEnabled, false)
+	viper.SetDefault(EnvAuthWalletKeys, map[string]string{})
+	viper.SetDefault(EnvAuthChannelSecret, "")
+
+	// Proofs auth settings
+	viper.SetDefault(EnvProofsAuthEnabled, false)
+	viper.SetDefault(EnvProofsAuthKeys, map[string]string{})
+	viper.SetDefault(EnvProofsAuthSecrets, map[string]string{})
+	viper.SetDefault(EnvProofsAuthAllowlist, "")
+	viper.SetDefault(EnvProofsAuthRequireSig, false)
+	viper.SetDefault(EnvProofsAuthTrustedProxies, "")
+
+	// Rate limit settings
+	viper.SetDefault(EnvRateLimitEnabled, false)
+	viper.SetDefault(EnvRateLimitTrustProxy, false)
+	viper.SetDefault(EnvRateLimitIPRate, 10)
+	viper.SetDefault(EnvRateLimitIPBurst, 20)
+	viper.SetDefault(EnvRateLimitPaymentIDRate, 2)
+	viper.SetDefault(EnvRateLimitPaymentIDBurst, 10)
+	viper.SetDefault(EnvRateLimitNotFoundRate, 0.1)
+	viper.SetDefault(EnvRateLimitNotFoundBurst, 5)
+
+	// Admin API settings
+	viper.SetDefault(EnvAdminEnabled, false)
+	viper.SetDefault(EnvAdminKeys, "")
+	viper.SetDefault(EnvAdminPort, ":8446")
+
+	// Wallet listener settings
+	viper.SetDefault(EnvWalletPort, "")
+	viper.SetDefault(EnvWalletCORSOrigins, "")
+	viper.SetDefault(EnvWalletCORSHeaders, "")
+	viper.SetDefault(EnvWalletWSOrigins, "")
+	viper.SetDefault(EnvWalletTLSCert, "")
+	viper.SetDefault(EnvWalletTLSKey, "")
+	viper.SetDefault(EnvWalletTLSClientCA, "")
+
+	// Ops listener settings
+	viper.SetDefault(EnvOpsPort, "")
+	viper.SetDefault(EnvOpsCORSOrigins, "")
+	viper.SetDefault(EnvOpsCORSHeaders, "")
+	viper.SetDefault(EnvOpsTLSCert, "")
+	viper.SetDefault(EnvOpsTLSKey, "")
+	viper.SetDefault(EnvOpsTLSClientCA, "")
+	viper.SetDefault(EnvOpsKeys, "")
+	viper.SetDefault(EnvOpsPprof, false)
 }
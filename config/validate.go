@@ -1,13 +1,286 @@
 package config
 
-import validator "github.com/theflyingcodr/govalidator"
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
 
 // Validate the configuration.
 func (c *Config) Validate() error {
 	v := validator.New()
 	if c.Transports != nil {
-		v = v.Validate("transport.mode", validator.AnyString(c.Transports.Mode, TransportModeHybrid, TransportModeSocket))
+		v = v.Validate("transport.mode", validator.AnyString(c.Transports.Mode, TransportModeHybrid, TransportModeSocket, TransportModeHTTP))
+		if c.Transports.Mode == TransportModeHTTP && c.PayD != nil && !c.PayD.Noop {
+			v = v.Validate("payd.host", func() error {
+				if c.PayD.Host == "" && len(c.PayD.Merchants) == 0 {
+					return errors.New("a payd host or merchants must be set in http transport mode")
+				}
+				return nil
+			})
+		}
+	}
+
+	if c.Sockets != nil {
+		v = v.Validate("socket.paymentterms.timeout", validator.PositiveInt64(int64(c.Sockets.PaymentTerms.Timeout))).
+			Validate("socket.paymentterms.retries", validator.MinInt(c.Sockets.PaymentTerms.Retries, 0)).
+			Validate("socket.payment.timeout", validator.PositiveInt64(int64(c.Sockets.Payment.Timeout))).
+			Validate("socket.payment.retries", validator.MinInt(c.Sockets.Payment.Retries, 0))
+	}
+	if c.UsesDb() {
+		v = v.Validate("db.dsn", validator.NotEmpty(c.Db.DSN))
+	}
+	if c.Cache != nil {
+		v = v.Validate("cache.paymentresults.ttl", validator.MinInt64(int64(c.Cache.PaymentResultsTTL), 0)).
+			Validate("cache.invoicestates.ttl", validator.MinInt64(int64(c.Cache.InvoiceStatesTTL), 0))
+	}
+	if c.ProofQueue != nil && c.ProofQueue.Enabled {
+		v = v.Validate("proofs.queue.ttl", validator.PositiveInt64(int64(c.ProofQueue.TTL)))
+		if c.Transports != nil {
+			v = v.Validate("proofs.queue.enabled", func() error {
+				if c.Transports.Mode != TransportModeHybrid {
+					return errors.New("the proof queue is only supported in hybrid transport mode")
+				}
+				return nil
+			})
+		}
+	}
+
+	if c.Server != nil {
+		v = v.Validate("server.hsts.maxage", validator.MinInt(c.Server.HSTSMaxAge, 0)).
+			Validate("server.drain.timeout", validator.PositiveInt64(int64(c.Server.DrainTimeout)))
+		v = validateListener(v, "server", c.Server.Listener)
+		if c.Deployment != nil && !c.Deployment.IsDev() {
+			v = v.Validate("server.ws.origins", func() error {
+				if c.Server.AllowsAnyWSOrigin() {
+					return errors.New("websocket origins can't allow any origin outside dev")
+				}
+				return nil
+			})
+		}
+	}
+
+	if c.Deployment != nil {
+		v = v.Validate("env.bitcoin_network", validator.AnyString(c.Deployment.Network,
+			NetworkMainnet, NetworkTestnet, NetworkSTN, NetworkRegtest))
+	}
+
+	if c.SPV != nil && (c.SPV.Enabled || c.SPV.Proofs) {
+		v = v.Validate("spv.headers", validator.NotEmpty(c.SPV.Headers))
+		if c.Transports != nil {
+			v = v.Validate("spv.enabled", func() error {
+				if c.SPV.Enabled && c.Transports.Mode == TransportModeSocket {
+					return errors.New("payments are relayed between wallets in socket transport mode so can't be verified")
+				}
+				return nil
+			})
+		}
+	}
+
+	if c.Auth != nil {
+		v = v.Validate("auth.enabled", func() error {
+			configured := len(c.Auth.WalletKeys) > 0 || c.Auth.ChannelSecret != ""
+			if c.Auth.Enabled && !configured {
+				return errors.New("wallet keys or a channel secret must be set when auth is enabled")
+			}
+			// without auth any client can open a channel as a wallet.
+			if !c.Auth.Enabled && configured {
+				return errors.New("auth must be enabled when wallet keys or a channel secret are set")
+			}
+			return nil
+		})
+	}
+
+	if c.ProofsAuth != nil && c.ProofsAuth.Enabled {
+		v = v.Validate("proofs.auth.enabled", func() error {
+			if len(c.ProofsAuth.Keys) == 0 && len(c.ProofsAuth.Secrets) == 0 && len(c.ProofsAuth.Allowlist) == 0 {
+				return errors.New("keys, secrets or an allowlist must be set when proofs auth is enabled")
+			}
+			return nil
+		}).Validate("proofs.auth.allowlist", func() error {
+			return validateIPs(c.ProofsAuth.Allowlist)
+		}).Validate("proofs.auth.trustedproxies", func() error {
+			return validateIPs(c.ProofsAuth.TrustedProxies)
+		})
+	}
+
+	if c.RateLimit != nil && c.RateLimit.Enabled {
+		for name, bucket := range map[string]TokenBucket{
+			"ip":        c.RateLimit.IP,
+			"paymentid": c.RateLimit.PaymentID,
+			"notfound":  c.RateLimit.NotFound,
+		} {
+			bucket := bucket
+			v = v.Validate("ratelimit."+name+".rate", func() error {
+				if bucket.Rate <= 0 {
+					return errors.New("value must be greater than 0")
+				}
+				return nil
+			}).Validate("ratelimit."+name+".burst", validator.PositiveInt(bucket.Burst))
+		}
+	}
+
+	if c.Wallet != nil && c.Wallet.Enabled() {
+		v = validateListener(v, "wallet", c.Wallet.Listener).
+			Validate("wallet.port", func() error {
+				// wallets are otherwise told apart from payers by the internal flag,
+				// which any client reaching the listener can send.
+				if c.Wallet.TLSClientCA == "" && (c.Auth == nil || !c.Auth.Enabled) {
+					return errors.New("a wallet client ca or websocket auth must be set to use the wallet listener")
+				}
+				return nil
+			})
+		if c.Deployment != nil && !c.Deployment.IsDev() {
+			v = v.Validate("wallet.ws.origins", func() error {
+				if c.Wallet.AllowsAnyWSOrigin() {
+					return errors.New("websocket origins can't allow any origin outside dev")
+				}
+				return nil
+			})
+		}
+		if c.Transports != nil {
+			v = v.Validate("wallet.port", func() error {
+				if c.Transports.Mode == TransportModeHTTP {
+					return errors.New("the wallet listener is only supported in socket and hybrid transport modes")
+				}
+				return nil
+			})
+		}
+	}
+	if c.Ops != nil {
+		if c.Ops.Enabled() {
+			v = validateListener(v, "ops", c.Ops.Listener)
+		}
+		if c.Ops.Pprof {
+			v = v.Validate("ops.pprof", func() error {
+				if !c.Ops.Enabled() {
+					return errors.New("pprof is only served on the ops listener, an ops port must be set")
+				}
+				return nil
+			})
+		}
+	}
+	v = v.Validate("ports", c.validatePorts)
+
+	if c.Admin != nil && c.Admin.Enabled {
+		v = v.Validate("admin.keys", func() error {
+			if len(c.Admin.Keys) == 0 {
+				return errors.New("at least one key must be set when the admin api is enabled")
+			}
+			return nil
+		}).Validate("admin.enabled", func() error {
+			if c.Ops == nil || !c.Ops.Enabled() {
+				return errors.New("the admin api is only served on the ops listener, an ops port must be set")
+			}
+			return nil
+		})
+		if c.Transports != nil {
+			v = v.Validate("admin.enabled", func() error {
+				// only the ledger is served without a socket server.
+				if c.Transports.Mode == TransportModeHTTP && (c.Ledger == nil || !c.Ledger.Enabled) {
+					return errors.New("the admin api is only supported in http transport mode with the ledger enabled")
+				}
+				return nil
+			})
+		}
+	}
+
+	if c.Cluster != nil && c.Cluster.Enabled {
+		v = v.Validate("cluster.redis.addr", validator.NotEmpty(c.Cluster.RedisAddr))
+		if c.Transports != nil {
+			v = v.Validate("cluster.enabled", func() error {
+				if c.Transports.Mode != TransportModeHybrid {
+					return errors.New("clustering is only supported in hybrid transport mode")
+				}
+				return nil
+			})
+		}
+		// the queue is per node, proofs would only reach wallets that rejoin the
+		// node that queued them.
+		if c.ProofQueue != nil {
+			v = v.Validate("proofs.queue.enabled", func() error {
+				if c.ProofQueue.Enabled {
+					return errors.New("the proof queue can't be used with clustering")
+				}
+				return nil
+			})
+		}
+		// these are held in memory by each node.
+		if c.Cache != nil {
+			v = v.Validate("cache.paymentterms", func() error {
+				if c.Cache.PaymentTerms {
+					return errors.New("the payment terms cache can't be used with clustering")
+				}
+				return nil
+			}).Validate("cache.paymentresults.ttl", func() error {
+				if c.Cache.PaymentResultsTTL != 0 {
+					return errors.New("payment results can't be kept with clustering, set to 0")
+				}
+				return nil
+			}).Validate("cache.invoicestates.ttl", func() error {
+				if c.Cache.InvoiceStatesTTL != 0 {
+					return errors.New("invoice states can't be kept with clustering, set to 0")
+				}
+				return nil
+			})
+		}
 	}
 
 	return v.Err()
 }
+
+// validateListener checks the TLS settings of the listener, name is the
+// prefix of its config keys.
+func validateListener(v validator.ErrValidation, name string, l Listener) validator.ErrValidation {
+	if l.TLSEnabled() {
+		v = v.Validate(name+".tls.cert", validator.NotEmpty(l.TLSCert)).
+			Validate(name+".tls.key", validator.NotEmpty(l.TLSKey))
+	}
+	if l.TLSClientCA != "" {
+		v = v.Validate(name+".tls.clientca", func() error {
+			if !l.TLSEnabled() {
+				return errors.New("a tls cert and key must be set to use client certificates")
+			}
+			return nil
+		})
+	}
+	return v
+}
+
+// validatePorts returns an error if two listeners are set to the same port.
+func (c *Config) validatePorts() error {
+	listeners := map[string]*Listener{}
+	if c.Server != nil {
+		listeners["server"] = &c.Server.Listener
+	}
+	if c.Wallet != nil {
+		listeners["wallet"] = &c.Wallet.Listener
+	}
+	if c.Ops != nil {
+		listeners["ops"] = &c.Ops.Listener
+	}
+	ports := map[string]string{}
+	for _, name := range []string{"server", "wallet", "ops"} {
+		l, ok := listeners[name]
+		if !ok || !l.Enabled() {
+			continue
+		}
+		if other, ok := ports[l.Port]; ok {
+			return fmt.Errorf("the %s and %s listeners can't both use port %s", other, name, l.Port)
+		}
+		ports[l.Port] = name
+	}
+	return nil
+}
+
+// validateIPs returns an error if an entry isn't an IP or CIDR.
+func validateIPs(entries []string) error {
+	for _, entry := range entries {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Errorf("%s is not an IP or CIDR", entry)
+		}
+	}
+	return nil
+}
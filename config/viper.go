@@ -23,11 +23,22 @@ func NewViperConfig(appname string) *ViperConfig {
 // WithServer will setup the web server configuration if required.
 func (v *ViperConfig) WithServer() ConfigurationLoader {
 	v.Server = &Server{
-		Port:           viper.GetString(EnvServerPort),
-		Hostname:       viper.GetString(EnvServerHost),
-		SwaggerEnabled: viper.GetBool(EnvServerSwaggerEnabled),
-		SwaggerHost:    viper.GetString(EnvServerSwaggerHost),
-		FQDN:           viper.GetString(EnvServerFQDN),
+		Listener: Listener{
+			Port:        viper.GetString(EnvServerPort),
+			CORSOrigins: splitList(viper.GetString(EnvServerCORSOrigins)),
+			CORSHeaders: splitList(viper.GetString(EnvServerCORSHeaders)),
+			TLSCert:     viper.GetString(EnvServerTLSCert),
+			TLSKey:      viper.GetString(EnvServerTLSKey),
+			TLSClientCA: viper.GetString(EnvServerTLSClientCA),
+		},
+		Hostname:        viper.GetString(EnvServerHost),
+		SwaggerEnabled:  viper.GetBool(EnvServerSwaggerEnabled),
+		SwaggerHost:     viper.GetString(EnvServerSwaggerHost),
+		FQDN:            viper.GetString(EnvServerFQDN),
+		WSOrigins:       splitList(viper.GetString(EnvServerWSOrigins)),
+		SecurityHeaders: viper.GetBool(EnvServerSecurityHeaders),
+		HSTSMaxAge:      viper.GetInt(EnvServerHSTSMaxAge),
+		DrainTimeout:    viper.GetDuration(EnvServerDrainTimeout),
 	}
 	return v
 }
@@ -40,6 +51,7 @@ func (v *ViperConfig) WithDeployment(appName string) ConfigurationLoader {
 		Version:     viper.GetString(EnvVersion),
 		Commit:      viper.GetString(EnvCommit),
 		BuildDate:   viper.GetTime(EnvBuildDate),
+		Network:     viper.GetString(EnvBitcoinNetwork),
 		AppName:     appName,
 	}
 	return v
@@ -54,7 +66,15 @@ func (v *ViperConfig) WithLog() ConfigurationLoader {
 // WithPayD sets up and returns PayD viper config.
 func (v *ViperConfig) WithPayD() ConfigurationLoader {
 	v.PayD = &PayD{
-		Noop: viper.GetBool(EnvPaydNoop),
+		Noop:      viper.GetBool(EnvPaydNoop),
+		Host:      viper.GetString(EnvPaydHost),
+		Merchants: viper.GetStringMapString(EnvPaydMerchants),
+		Timeout:   viper.GetDuration(EnvPaydTimeout),
+		Fake: FakePayee{
+			Fixtures:   viper.GetString(EnvPaydFakeFixtures),
+			SigningKey: viper.GetString(EnvPaydFakeSigningKey),
+			Latency:    viper.GetDuration(EnvPaydFakeLatency),
+		},
 	}
 	return v
 }
@@ -64,6 +84,19 @@ func (v *ViperConfig) WithSockets() ConfigurationLoader {
 	v.Sockets = &Socket{
 		ChannelTimeout:  viper.GetDuration(EnvSocketChannelTimeoutSeconds),
 		MaxMessageBytes: viper.GetInt(EnvSocketMaxMessageBytes),
+		PaymentTerms: RoundTrip{
+			Timeout:        viper.GetDuration(EnvSocketPaymentTermsTimeout),
+			Retries:        viper.GetInt(EnvSocketPaymentTermsRetries),
+			Backoff:        viper.GetDuration(EnvSocketPaymentTermsBackoff),
+			AwaitReconnect: viper.GetBool(EnvSocketPaymentTermsReconnect),
+		},
+		Payment: RoundTrip{
+			Timeout:        viper.GetDuration(EnvSocketPaymentTimeout),
+			Retries:        viper.GetInt(EnvSocketPaymentRetries),
+			Backoff:        viper.GetDuration(EnvSocketPaymentBackoff),
+			AwaitReconnect: viper.GetBool(EnvSocketPaymentReconnect),
+		},
+		Record: viper.GetString(EnvSocketRecord),
 	}
 	return v
 }
@@ -76,7 +109,179 @@ func (v *ViperConfig) WithTransports() ConfigurationLoader {
 	return v
 }
 
+// WithDb reads database config.
+func (v *ViperConfig) WithDb() ConfigurationLoader {
+	v.Db = &Db{
+		DSN: viper.GetString(EnvDbDSN),
+	}
+	if v.Db.DSN == "" {
+		v.Db.DSN = viper.GetString(EnvLedgerDSN)
+	}
+	return v
+}
+
+// WithLedger reads ledger config.
+func (v *ViperConfig) WithLedger() ConfigurationLoader {
+	v.Ledger = &Ledger{
+		Enabled: viper.GetBool(EnvLedgerEnabled),
+	}
+	return v
+}
+
+// WithCache reads cache config.
+func (v *ViperConfig) WithCache() ConfigurationLoader {
+	v.Cache = &Cache{
+		PaymentTerms:      viper.GetBool(EnvCachePaymentTerms),
+		PaymentResultsTTL: viper.GetDuration(EnvCachePaymentResultsTTL),
+		InvoiceStatesTTL:  viper.GetDuration(EnvCacheInvoiceStatesTTL),
+	}
+	return v
+}
+
+// WithProofQueue reads proof queue config.
+func (v *ViperConfig) WithProofQueue() ConfigurationLoader {
+	v.ProofQueue = &ProofQueue{
+		Enabled: viper.GetBool(EnvProofQueueEnabled),
+		TTL:     viper.GetDuration(EnvProofQueueTTL),
+	}
+	return v
+}
+
+// WithCluster reads cluster config.
+func (v *ViperConfig) WithCluster() ConfigurationLoader {
+	v.Cluster = &Cluster{
+		Enabled:       viper.GetBool(EnvClusterEnabled),
+		Prefix:        viper.GetString(EnvClusterPrefix),
+		RedisAddr:     viper.GetString(EnvClusterRedisAddr),
+		RedisPassword: viper.GetString(EnvClusterRedisPassword),
+		RedisDB:       viper.GetInt(EnvClusterRedisDB),
+	}
+	return v
+}
+
+// WithPaymentTerms reads PaymentTerms verification config.
+func (v *ViperConfig) WithPaymentTerms() ConfigurationLoader {
+	v.PaymentTerms = &PaymentTerms{
+		RequireSignature: viper.GetBool(EnvPaymentTermsRequireSig),
+		RequireNetwork:   viper.GetBool(EnvPaymentTermsRequireNetwork),
+	}
+	return v
+}
+
+// WithSPV reads SPV config.
+func (v *ViperConfig) WithSPV() ConfigurationLoader {
+	v.SPV = &SPV{
+		Enabled: viper.GetBool(EnvSPVEnabled),
+		Headers: viper.GetString(EnvSPVHeaders),
+		Proofs:  viper.GetBool(EnvSPVProofs),
+	}
+	return v
+}
+
+// WithAuth reads websocket auth config.
+func (v *ViperConfig) WithAuth() ConfigurationLoader {
+	v.Auth = &Auth{
+		Enabled:       viper.GetBool(EnvAuthEnabled),
+		WalletKeys:    viper.GetStringMapString(EnvAuthWalletKeys),
+		ChannelSecret: viper.GetString(EnvAuthChannelSecret),
+	}
+	return v
+}
+
+// WithProofsAuth reads proof callback auth config.
+func (v *ViperConfig) WithProofsAuth() ConfigurationLoader {
+	v.ProofsAuth = &ProofsAuth{
+		Enabled:          viper.GetBool(EnvProofsAuthEnabled),
+		Keys:             viper.GetStringMapString(EnvProofsAuthKeys),
+		Secrets:          viper.GetStringMapString(EnvProofsAuthSecrets),
+		Allowlist:        splitList(viper.GetString(EnvProofsAuthAllowlist)),
+		RequireSignature: viper.GetBool(EnvProofsAuthRequireSig),
+		TrustedProxies:   splitList(viper.GetString(EnvProofsAuthTrustedProxies)),
+	}
+	return v
+}
+
+// WithRateLimit reads payment endpoint rate limit config.
+func (v *ViperConfig) WithRateLimit() ConfigurationLoader {
+	v.RateLimit = &RateLimit{
+		Enabled:    viper.GetBool(EnvRateLimitEnabled),
+		TrustProxy: viper.GetBool(EnvRateLimitTrustProxy),
+		IP: TokenBucket{
+			Rate:  viper.GetFloat64(EnvRateLimitIPRate),
+			Burst: viper.GetInt(EnvRateLimitIPBurst),
+		},
+		PaymentID: TokenBucket{
+			Rate:  viper.GetFloat64(EnvRateLimitPaymentIDRate),
+			Burst: viper.GetInt(EnvRateLimitPaymentIDBurst),
+		},
+		NotFound: TokenBucket{
+			Rate:  viper.GetFloat64(EnvRateLimitNotFoundRate),
+			Burst: viper.GetInt(EnvRateLimitNotFoundBurst),
+		},
+	}
+	return v
+}
+
+// WithAdmin reads operator admin API config.
+func (v *ViperConfig) WithAdmin() ConfigurationLoader {
+	v.Admin = &Admin{
+		Enabled: viper.GetBool(EnvAdminEnabled),
+		Keys:    splitList(viper.GetString(EnvAdminKeys)),
+	}
+	return v
+}
+
+// WithWallet reads the config of the listener wallets connect to.
+func (v *ViperConfig) WithWallet() ConfigurationLoader {
+	v.Wallet = &Wallet{
+		Listener: Listener{
+			Port:        viper.GetString(EnvWalletPort),
+			CORSOrigins: splitList(viper.GetString(EnvWalletCORSOrigins)),
+			CORSHeaders: splitList(viper.GetString(EnvWalletCORSHeaders)),
+			TLSCert:     viper.GetString(EnvWalletTLSCert),
+			TLSKey:      viper.GetString(EnvWalletTLSKey),
+			TLSClientCA: viper.GetString(EnvWalletTLSClientCA),
+		},
+		WSOrigins: splitList(viper.GetString(EnvWalletWSOrigins)),
+	}
+	return v
+}
+
+// WithOps reads the config of the listener operators use.
+func (v *ViperConfig) WithOps() ConfigurationLoader {
+	port := viper.GetString(EnvOpsPort)
+	// configs enabling the admin API before the ops listener set its port
+	// with admin.port, which the ops listener is served on instead.
+	if port == "" && viper.GetBool(EnvAdminEnabled) {
+		port = viper.GetString(EnvAdminPort)
+	}
+	v.Ops = &Ops{
+		Listener: Listener{
+			Port:        port,
+			CORSOrigins: splitList(viper.GetString(EnvOpsCORSOrigins)),
+			CORSHeaders: splitList(viper.GetString(EnvOpsCORSHeaders)),
+			TLSCert:     viper.GetString(EnvOpsTLSCert),
+			TLSKey:      viper.GetString(EnvOpsTLSKey),
+			TLSClientCA: viper.GetString(EnvOpsTLSClientCA),
+		},
+		Keys:  splitList(viper.GetString(EnvOpsKeys)),
+		Pprof: viper.GetBool(EnvOpsPprof),
+	}
+	return v
+}
+
 // Load will return the underlying config setup.
 func (v *ViperConfig) Load() *Config {
 	return v.Config
 }
+
+// splitList returns the items of a comma separated list, empty items are dropped.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
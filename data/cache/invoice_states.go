@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+type invoiceEntry struct {
+	history   []server.InvoiceTransition
+	expiresAt time.Time
+	updated   time.Time
+}
+
+// state returns the current state of the invoice.
+func (e *invoiceEntry) state() server.InvoiceState {
+	if len(e.history) == 0 {
+		return ""
+	}
+	return e.history[len(e.history)-1].State
+}
+
+// expire will move the invoice to expired if its terms expired before now
+// and it can still expire.
+func (e *invoiceEntry) expire(now time.Time) {
+	if e.expiresAt.IsZero() || e.expiresAt.After(now) || !e.state().CanMoveTo(server.InvoiceStateExpired) {
+		return
+	}
+	e.history = append(e.history, server.InvoiceTransition{
+		State:     server.InvoiceStateExpired,
+		CreatedAt: e.expiresAt.UTC(),
+	})
+}
+
+// invoiceStates is an in memory store of the state of each paymentID, an
+// invoice is held for ttl after its last change of state.
+type invoiceStates struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*invoiceEntry
+	lastPrune time.Time
+}
+
+// NewInvoiceStates will setup and return a new in memory invoice state store.
+func NewInvoiceStates(ttl time.Duration) *invoiceStates {
+	return &invoiceStates{
+		ttl:       ttl,
+		entries:   map[string]*invoiceEntry{},
+		lastPrune: time.Now(),
+	}
+}
+
+// InvoiceStatus returns the state and history of the paymentID.
+func (i *invoiceStates) InvoiceStatus(ctx context.Context, args server.InvoiceArgs) (*server.InvoiceStatus, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	e, ok := i.entries[args.PaymentID]
+	if !ok || !e.updated.Add(i.ttl).After(now) {
+		return nil, client_errors.NewErrNotFoundf("404", "invoice %s not found", args.PaymentID)
+	}
+	e.expire(now)
+	status := &server.InvoiceStatus{
+		PaymentID: args.PaymentID,
+		State:     e.state(),
+		History:   append([]server.InvoiceTransition{}, e.history...),
+	}
+	if !e.expiresAt.IsZero() {
+		expiresAt := e.expiresAt
+		status.ExpiresAt = &expiresAt
+	}
+	return status, nil
+}
+
+// InvoiceStateUpdate will move the paymentID to the state requested if it can
+// be reached from its current state.
+func (i *invoiceStates) InvoiceStateUpdate(ctx context.Context, args server.InvoiceArgs, req server.InvoiceStateUpdate) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	e, ok := i.entries[args.PaymentID]
+	if !ok || !e.updated.Add(i.ttl).After(now) {
+		e = &invoiceEntry{}
+		i.entries[args.PaymentID] = e
+	}
+	e.expire(now)
+	if !e.state().CanMoveTo(req.State) {
+		return client_errors.NewErrDuplicatef("409", "invoice %s is %s and can't be moved to %s",
+			args.PaymentID, e.state(), req.State)
+	}
+	switch {
+	case !req.ExpiresAt.IsZero():
+		e.expiresAt = req.ExpiresAt
+	case req.State == server.InvoiceStateTermsRequested:
+		// new terms replace the expiry of any served before.
+		e.expiresAt = time.Time{}
+	}
+	e.history = append(e.history, server.InvoiceTransition{
+		State:     req.State,
+		Detail:    req.Detail,
+		CreatedAt: now.UTC(),
+	})
+	e.updated = now
+	if now.Sub(i.lastPrune) >= pruneInterval {
+		for k, e := range i.entries {
+			if !e.updated.Add(i.ttl).After(now) {
+				delete(i.entries, k)
+			}
+		}
+		i.lastPrune = now
+	}
+	return nil
+}
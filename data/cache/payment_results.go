@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+type paymentResultEntry struct {
+	result  server.PaymentResult
+	expires time.Time
+}
+
+// paymentResults is an in memory store of payment outcomes used to answer
+// repeated payment submissions, results are held for ttl.
+type paymentResults struct {
+	ttl time.Duration
+
+	mu        sync.RWMutex
+	entries   map[string]paymentResultEntry
+	lastPrune time.Time
+}
+
+// NewPaymentResults will setup and return a new in memory payment result store.
+func NewPaymentResults(ttl time.Duration) *paymentResults {
+	return &paymentResults{
+		ttl:       ttl,
+		entries:   map[string]paymentResultEntry{},
+		lastPrune: time.Now(),
+	}
+}
+
+// PaymentResult returns the result stored against key or nil if not found or expired.
+func (p *paymentResults) PaymentResult(ctx context.Context, key string) (*server.PaymentResult, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	e, ok := p.entries[key]
+	if !ok || !e.expires.After(time.Now()) {
+		return nil, nil
+	}
+	res := e.result
+	return &res, nil
+}
+
+// PaymentResultSet will store the result against key.
+func (p *paymentResults) PaymentResultSet(ctx context.Context, key string, req server.PaymentResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.entries[key] = paymentResultEntry{result: req, expires: now.Add(p.ttl)}
+	if now.Sub(p.lastPrune) < pruneInterval {
+		return nil
+	}
+	for k, e := range p.entries {
+		if !e.expires.After(now) {
+			delete(p.entries, k)
+		}
+	}
+	p.lastPrune = now
+	return nil
+}
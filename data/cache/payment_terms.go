@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+)
+
+// pruneInterval is the minimum time between sweeps for expired entries.
+const pruneInterval = time.Minute
+
+type paymentTermsEntry struct {
+	env     envelope.JSONEnvelope
+	expires time.Time
+}
+
+// paymentTermsCall is a read from the store in flight, concurrent misses for
+// the same paymentID wait on it rather than each calling the wallet.
+type paymentTermsCall struct {
+	done chan struct{}
+	resp *envelope.JSONEnvelope
+	err  error
+}
+
+// paymentTermsCache is a read-through, in memory cache of signed PaymentTerms
+// sitting in front of a PaymentStore, payments and proofs are passed straight through.
+//
+// Entries are kept until the expirationTimestamp of the PaymentTerms they hold,
+// terms without an expiry are never cached.
+type paymentTermsCache struct {
+	server.PaymentStore
+
+	timeout   time.Duration
+	mu        sync.Mutex
+	entries   map[string]paymentTermsEntry
+	calls     map[string]*paymentTermsCall
+	lastPrune time.Time
+}
+
+// NewPaymentTermsCache will setup and return a new PaymentTerms cache, cache misses
+// are read from the store provided and each read is given up to timeout.
+func NewPaymentTermsCache(store server.PaymentStore, timeout time.Duration) *paymentTermsCache {
+	return &paymentTermsCache{
+		PaymentStore: store,
+		timeout:      timeout,
+		entries:      map[string]paymentTermsEntry{},
+		calls:        map[string]*paymentTermsCall{},
+		lastPrune:    time.Now(),
+	}
+}
+
+// PaymentTerms will return cached PaymentTerms if they have not expired, otherwise
+// they are read from the underlying store and cached.
+//
+// Concurrent misses for a paymentID share a single read from the store. The
+// read isn't tied to any caller, so one caller giving up doesn't fail the
+// others, each caller only waits until its own ctx is done.
+func (p *paymentTermsCache) PaymentTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+	p.mu.Lock()
+	if e, ok := p.entries[args.PaymentID]; ok && e.expires.After(time.Now()) {
+		p.mu.Unlock()
+		env := e.env
+		return &env, nil
+	}
+	c, ok := p.calls[args.PaymentID]
+	if !ok {
+		c = &paymentTermsCall{done: make(chan struct{})}
+		p.calls[args.PaymentID] = c
+		go p.read(args, c)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return nil, errors.WithStack(ctx.Err())
+	}
+	if c.err != nil || c.resp == nil {
+		return nil, c.err
+	}
+	env := *c.resp
+	return &env, nil
+}
+
+// read will read the PaymentTerms from the store for call c, caching them.
+func (p *paymentTermsCache) read(args dpp.PaymentTermsArgs, c *paymentTermsCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	c.resp, c.err = p.PaymentStore.PaymentTerms(ctx, args)
+	if c.err == nil && c.resp != nil {
+		p.set(args.PaymentID, *c.resp)
+	}
+	p.mu.Lock()
+	delete(p.calls, args.PaymentID)
+	p.mu.Unlock()
+	close(c.done)
+}
+
+// PaymentTermsSet will cache the envelope until the PaymentTerms it contains expire.
+func (p *paymentTermsCache) PaymentTermsSet(ctx context.Context, args dpp.PaymentTermsArgs, req envelope.JSONEnvelope) error {
+	if _, err := expiry(req); err != nil {
+		return err
+	}
+	p.set(args.PaymentID, req)
+	return nil
+}
+
+// PaymentTermsDelete will remove any cached PaymentTerms for the paymentID.
+func (p *paymentTermsCache) PaymentTermsDelete(ctx context.Context, args dpp.PaymentTermsArgs) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, args.PaymentID)
+	return nil
+}
+
+func (p *paymentTermsCache) set(paymentID string, env envelope.JSONEnvelope) {
+	exp, err := expiry(env)
+	if err != nil || !exp.After(time.Now()) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[paymentID] = paymentTermsEntry{env: env, expires: exp}
+	p.prune()
+}
+
+// prune removes expired entries, it must be called with the write lock held.
+func (p *paymentTermsCache) prune() {
+	now := time.Now()
+	if now.Sub(p.lastPrune) < pruneInterval {
+		return
+	}
+	for k, e := range p.entries {
+		if !e.expires.After(now) {
+			delete(p.entries, k)
+		}
+	}
+	p.lastPrune = now
+}
+
+// expiry reads the expirationTimestamp from the PaymentTerms payload.
+func expiry(env envelope.JSONEnvelope) (time.Time, error) {
+	var terms struct {
+		ExpirationTimestamp int64 `json:"expirationTimestamp"`
+	}
+	if err := json.Unmarshal([]byte(env.Payload), &terms); err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to read payment terms expiry")
+	}
+	if terms.ExpirationTimestamp == 0 {
+		return time.Time{}, errors.New("payment terms have no expirationTimestamp")
+	}
+	return time.Unix(terms.ExpirationTimestamp, 0), nil
+}
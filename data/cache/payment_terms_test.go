@@ -0,0 +1,237 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/data/cache"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+)
+
+func termsExpiring(exp time.Time) *envelope.JSONEnvelope {
+	if exp.IsZero() {
+		return &envelope.JSONEnvelope{Payload: `{"memo":"abc123"}`}
+	}
+	return &envelope.JSONEnvelope{Payload: fmt.Sprintf(`{"memo":"abc123","expirationTimestamp":%d}`, exp.Unix())}
+}
+
+func TestPaymentTermsCache_PaymentTerms(t *testing.T) {
+	tests := map[string]struct {
+		terms    *envelope.JSONEnvelope
+		storeErr error
+		expCalls int
+		expErr   error
+	}{
+		"unexpired terms read once": {
+			terms:    termsExpiring(time.Now().Add(time.Hour)),
+			expCalls: 1,
+		},
+		"expired terms read every time": {
+			terms:    termsExpiring(time.Now().Add(-time.Second)),
+			expCalls: 3,
+		},
+		"terms without expiry read every time": {
+			terms:    termsExpiring(time.Time{}),
+			expCalls: 3,
+		},
+		"errors not cached": {
+			storeErr: errors.New("wallet offline"),
+			expCalls: 3,
+			expErr:   errors.New("wallet offline"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &mocks.PaymentStoreMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					if test.storeErr != nil {
+						return nil, test.storeErr
+					}
+					env := *test.terms
+					return &env, nil
+				},
+			}
+			c := cache.NewPaymentTermsCache(store, time.Second)
+
+			for i := 0; i < 3; i++ {
+				resp, err := c.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+				if test.expErr != nil {
+					assert.EqualError(t, err, test.expErr.Error())
+					continue
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, test.terms, resp)
+			}
+			assert.Len(t, store.PaymentTermsCalls(), test.expCalls)
+		})
+	}
+}
+
+func TestPaymentTermsCache_PaymentTermsCopy(t *testing.T) {
+	store := &mocks.PaymentStoreMock{
+		PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+			return termsExpiring(time.Now().Add(time.Hour)), nil
+		},
+	}
+	c := cache.NewPaymentTermsCache(store, time.Second)
+
+	resp, err := c.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+	resp.Payload = "changed"
+
+	resp, err = c.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, "changed", resp.Payload)
+}
+
+func TestPaymentTermsCache_ConcurrentMisses(t *testing.T) {
+	release := make(chan struct{})
+	store := &mocks.PaymentStoreMock{
+		PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+			<-release
+			return termsExpiring(time.Now().Add(time.Hour)), nil
+		},
+	}
+	c := cache.NewPaymentTermsCache(store, time.Second)
+
+	var wg sync.WaitGroup
+	resps := make([]*envelope.JSONEnvelope, 10)
+	for i := range resps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			assert.NoError(t, err)
+			resps[i] = resp
+		}(i)
+	}
+	// let every caller miss before the wallet answers.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Len(t, store.PaymentTermsCalls(), 1)
+	for _, resp := range resps {
+		assert.Equal(t, resps[0], resp)
+	}
+}
+
+func TestPaymentTermsCache_ConcurrentMissesCancelled(t *testing.T) {
+	release := make(chan struct{})
+	store := &mocks.PaymentStoreMock{
+		PaymentTermsFunc: func(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+			select {
+			case <-release:
+				return termsExpiring(time.Now().Add(time.Hour)), nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+	c := cache.NewPaymentTermsCache(store, time.Second)
+
+	// the first caller gives up, the read it started carries on for the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := c.PaymentTerms(ctx, dpp.PaymentTermsArgs{PaymentID: "abc123"})
+		first <- err
+	}()
+	second := make(chan error)
+	go func() {
+		_, err := c.PaymentTerms(context.Background(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+		second <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.NoError(t, <-second)
+	assert.Len(t, store.PaymentTermsCalls(), 1)
+}
+
+func TestPaymentTermsCache_ReadTimeout(t *testing.T) {
+	store := &mocks.PaymentStoreMock{
+		PaymentTermsFunc: func(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	c := cache.NewPaymentTermsCache(store, 50*time.Millisecond)
+
+	_, err := c.PaymentTerms(context.Background(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPaymentTermsCache_PaymentTermsSet(t *testing.T) {
+	tests := map[string]struct {
+		terms    *envelope.JSONEnvelope
+		expCalls int
+		expErr   error
+	}{
+		"unexpired terms served from cache": {
+			terms: termsExpiring(time.Now().Add(time.Hour)),
+		},
+		"expired terms not cached": {
+			terms:    termsExpiring(time.Now().Add(-time.Second)),
+			expCalls: 1,
+		},
+		"terms without expiry rejected": {
+			terms:    termsExpiring(time.Time{}),
+			expCalls: 1,
+			expErr:   errors.New("payment terms have no expirationTimestamp"),
+		},
+		"invalid payload rejected": {
+			terms:    &envelope.JSONEnvelope{Payload: "{"},
+			expCalls: 1,
+			expErr:   errors.New("failed to read payment terms expiry: unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &mocks.PaymentStoreMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					return &envelope.JSONEnvelope{Payload: "from wallet"}, nil
+				},
+			}
+			c := cache.NewPaymentTermsCache(store, time.Second)
+
+			err := c.PaymentTermsSet(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"}, *test.terms)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			_, err = c.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			assert.NoError(t, err)
+			assert.Len(t, store.PaymentTermsCalls(), test.expCalls)
+		})
+	}
+}
+
+func TestPaymentTermsCache_PaymentTermsDelete(t *testing.T) {
+	store := &mocks.PaymentStoreMock{
+		PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+			return termsExpiring(time.Now().Add(time.Hour)), nil
+		},
+	}
+	c := cache.NewPaymentTermsCache(store, time.Second)
+	args := dpp.PaymentTermsArgs{PaymentID: "abc123"}
+
+	_, err := c.PaymentTerms(context.TODO(), args)
+	assert.NoError(t, err)
+	assert.NoError(t, c.PaymentTermsDelete(context.TODO(), args))
+	_, err = c.PaymentTerms(context.TODO(), args)
+	assert.NoError(t, err)
+	assert.Len(t, store.PaymentTermsCalls(), 2)
+}
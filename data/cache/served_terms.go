@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libsv/go-dpp"
+)
+
+// servedTermsTTL is how long PaymentTerms without an expiry are kept.
+const servedTermsTTL = 24 * time.Hour
+
+type servedTermsEntry struct {
+	terms   dpp.PaymentTerms
+	expires time.Time
+}
+
+// servedTerms keeps the PaymentTerms returned to payers, so a Payment can
+// later be checked against them.
+type servedTerms struct {
+	mu        sync.RWMutex
+	entries   map[string]servedTermsEntry
+	lastPrune time.Time
+}
+
+// NewServedTerms will setup and return a new served PaymentTerms store.
+func NewServedTerms() *servedTerms {
+	return &servedTerms{
+		entries:   map[string]servedTermsEntry{},
+		lastPrune: time.Now(),
+	}
+}
+
+// ServedTermsCreate will keep the PaymentTerms served for the paymentID until
+// they expire, replacing any served before.
+func (s *servedTerms) ServedTermsCreate(ctx context.Context, args dpp.PaymentTermsArgs, terms dpp.PaymentTerms) error {
+	now := time.Now()
+	expires := now.Add(servedTermsTTL)
+	if terms.ExpirationTimestamp != 0 {
+		expires = time.Unix(terms.ExpirationTimestamp, 0)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[args.PaymentID] = servedTermsEntry{terms: terms, expires: expires}
+	if now.Sub(s.lastPrune) >= pruneInterval {
+		for k, e := range s.entries {
+			if !e.expires.After(now) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPrune = now
+	}
+	return nil
+}
+
+// ServedTerms returns the PaymentTerms last served for the paymentID, or nil if
+// none were served or they have expired.
+func (s *servedTerms) ServedTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*dpp.PaymentTerms, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[args.PaymentID]
+	if !ok || !e.expires.After(time.Now()) {
+		return nil, nil
+	}
+	terms := e.terms
+	return &terms, nil
+}
@@ -0,0 +1,26 @@
+package data
+
+import (
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// ToLathosErr converts an error returned by a payee wallet into a client error
+// so it is returned to the payer with a matching status code.
+func ToLathosErr(c server.ClientError) error {
+	switch c.Code {
+	case "400":
+		return client_errors.NewErrBadRequest(c.Code, c.Message)
+	case "401":
+		return client_errors.NewErrNotAuthorised(c.Code, c.Message)
+	case "403":
+		return client_errors.NewErrNotAuthenticated(c.Code, c.Message)
+	case "404", "N0001":
+		return client_errors.NewErrNotFound(c.Code, c.Message)
+	case "409":
+		return client_errors.NewErrDuplicate(c.Code, c.Message)
+	case "422":
+		return client_errors.NewErrUnprocessable(c.Code, c.Message)
+	}
+	return client_errors.NewErrBadRequest(c.Code, c.Message)
+}
@@ -0,0 +1,342 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/sockets"
+)
+
+const (
+	topicChannel = "%s:channel:%s"
+	topicNode    = "%s:node:%s"
+
+	// defaultAwait is used for remote requests without a deadline.
+	defaultAwait = 10 * time.Second
+)
+
+// PubSub is a message backbone shared by all proxy nodes.
+type PubSub interface {
+	// Publish will send payload to all subscribers of topic, returning the number reached.
+	Publish(ctx context.Context, topic string, payload []byte) (int64, error)
+	// Subscribers returns the number of subscribers to a topic across all nodes.
+	Subscribers(ctx context.Context, topic string) (int64, error)
+	// Subscribe will add topics to this node's subscriptions.
+	Subscribe(ctx context.Context, topics ...string) error
+	// Unsubscribe will remove topics from this node's subscriptions.
+	Unsubscribe(ctx context.Context, topics ...string) error
+	// Messages returns messages received on this node's subscriptions.
+	Messages() <-chan Message
+	// Close will end all subscriptions.
+	Close() error
+}
+
+// Message is received from a subscribed topic.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Broadcaster sends messages to socket channels, it is implemented by the
+// socket server on each node and by the cluster.
+type Broadcaster interface {
+	sockets.ServerChannelBroadcaster
+	HasChannel(channelID string) bool
+}
+
+// Local is the socket server on this node.
+type Local interface {
+	Broadcaster
+	// HasWallet returns true if a payee wallet is connected to the channel on
+	// this node, payers can hold a channel on a node without one.
+	HasWallet(channelID string) bool
+}
+
+// frame is sent over the backbone.
+//
+// A frame sent to a channel topic with ReplyTo set is a request for the node
+// holding the channel to BroadcastAwait and publish the reply to ReplyTo.
+type frame struct {
+	Origin    string           `json:"origin"`
+	ChannelID string           `json:"channelId"`
+	ReplyTo   string           `json:"replyTo,omitempty"`
+	Deadline  time.Time        `json:"deadline,omitempty"`
+	Message   *sockets.Message `json:"message,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	NotFound  bool             `json:"notFound,omitempty"`
+}
+
+// reply holds the replies to a request sent to other nodes.
+type reply chan frame
+
+// cluster routes socket messages to whichever node holds the channel, so payer
+// requests can be handled by any node behind a load balancer.
+//
+// Each node subscribes to a topic per channel held by its socket server, messages
+// for channels not held locally are published to that topic.
+type cluster struct {
+	l      log.Logger
+	local  Local
+	ps     PubSub
+	nodeID string
+	prefix string
+
+	mu      sync.Mutex
+	waiting map[string]reply
+	// pending are the subscription changes Run hasn't made yet, true to
+	// subscribe to the channel and false to unsubscribe, changed signals Run.
+	pending map[string]bool
+	changed chan struct{}
+}
+
+// New will setup and return a new cluster for this node, Run must be called to start handling messages.
+func New(l log.Logger, local Local, ps PubSub, nodeID, prefix string) *cluster {
+	return &cluster{
+		l:       l,
+		local:   local,
+		ps:      ps,
+		nodeID:  nodeID,
+		prefix:  prefix,
+		waiting: map[string]reply{},
+		pending: map[string]bool{},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Run will handle messages from other nodes until ctx is cancelled or the
+// backbone is closed.
+//
+// An error is returned if this node can't subscribe to the backbone, Run can
+// then be called again. Channels created or closed before Run starts are
+// subscribed to, or unsubscribed from, once it has.
+func (c *cluster) Run(ctx context.Context) error {
+	if err := c.ps.Subscribe(ctx, c.nodeTopic()); err != nil {
+		return errors.Wrapf(err, "failed to subscribe to node %s", c.nodeID)
+	}
+	msgs := c.ps.Messages()
+	c.subscribe(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.changed:
+			c.subscribe(ctx)
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			c.handle(msg)
+		}
+	}
+}
+
+// ChannelCreated will subscribe this node to a channel created on its socket server.
+//
+// This is safe to call from socket server hooks, it never blocks and the
+// subscription is made by Run.
+func (c *cluster) ChannelCreated(channelID string) {
+	c.change(channelID, true)
+}
+
+// ChannelClosed will unsubscribe this node from a channel closed on its socket server.
+//
+// This is safe to call from socket server hooks, it never blocks and the
+// subscription is removed by Run.
+func (c *cluster) ChannelClosed(channelID string) {
+	c.change(channelID, false)
+}
+
+// change will record the latest subscription wanted for the channel and signal Run.
+func (c *cluster) change(channelID string, subscribe bool) {
+	c.mu.Lock()
+	c.pending[channelID] = subscribe
+	c.mu.Unlock()
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// subscribe will make the pending subscription changes.
+func (c *cluster) subscribe(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = map[string]bool{}
+	c.mu.Unlock()
+	for channelID, subscribe := range pending {
+		var err error
+		if subscribe {
+			err = c.ps.Subscribe(ctx, c.channelTopic(channelID))
+		} else {
+			err = c.ps.Unsubscribe(ctx, c.channelTopic(channelID))
+		}
+		if err != nil {
+			c.l.Error(errors.WithStack(err), "cluster subscription failed")
+		}
+	}
+}
+
+// HasChannel returns true if any node holds the channel.
+func (c *cluster) HasChannel(channelID string) bool {
+	if c.local.HasChannel(channelID) {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := c.ps.Subscribers(ctx, c.channelTopic(channelID))
+	if err != nil {
+		c.l.Error(err, "failed to check cluster for channel")
+		return false
+	}
+	return n > 0
+}
+
+// Broadcast will send the message to all clients on the channel, on any node.
+func (c *cluster) Broadcast(channelID string, msg *sockets.Message) {
+	if c.local.HasChannel(channelID) {
+		c.local.Broadcast(channelID, msg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.publish(ctx, c.channelTopic(channelID), frame{ChannelID: channelID, Message: msg}); err != nil {
+		c.l.Error(err, "failed to broadcast to cluster")
+	}
+}
+
+// BroadcastAwait will send the message to the channel and return the reply.
+//
+// A channel with the payee wallet connected to this node is awaited locally
+// without the backbone. Otherwise the message is sent to every node holding
+// the channel, as payers can hold it on nodes without the wallet, and the
+// first successful reply is returned.
+func (c *cluster) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	if c.local.HasWallet(channelID) {
+		return c.local.BroadcastAwait(ctx, channelID, msg)
+	}
+	replies := make(reply, 16)
+	c.mu.Lock()
+	c.waiting[msg.CorrelationID] = replies
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.waiting, msg.CorrelationID)
+		c.mu.Unlock()
+	}()
+
+	req := frame{ChannelID: channelID, ReplyTo: c.nodeTopic(), Message: msg}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Deadline = deadline
+	}
+	n, err := c.publish(ctx, c.channelTopic(channelID), req)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, sockets.ErrChannelNotFound
+	}
+	err = sockets.ErrChannelNotFound
+	for ; n > 0; n-- {
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timeout waiting for message")
+		case r := <-replies:
+			switch {
+			case r.Error == "" && !r.NotFound:
+				return r.Message, nil
+			case !r.NotFound:
+				err = errors.New(r.Error)
+			}
+		}
+	}
+	return nil, err
+}
+
+func (c *cluster) handle(msg Message) {
+	var f frame
+	if err := json.Unmarshal(msg.Payload, &f); err != nil {
+		c.l.Error(err, "failed to decode cluster message")
+		return
+	}
+	if msg.Topic == c.nodeTopic() {
+		if f.Message == nil {
+			return
+		}
+		c.mu.Lock()
+		replies, ok := c.waiting[f.Message.CorrelationID]
+		c.mu.Unlock()
+		if ok {
+			select {
+			case replies <- f:
+			default:
+			}
+		}
+		return
+	}
+	if f.Message == nil {
+		return
+	}
+	if f.ReplyTo == "" {
+		// the origin has already sent the message to its own clients.
+		if f.Origin != c.nodeID && c.local.HasChannel(f.ChannelID) {
+			c.local.Broadcast(f.ChannelID, f.Message)
+		}
+		return
+	}
+	go c.await(f)
+}
+
+// await will send a request from another node to the local channel and publish the reply.
+func (c *cluster) await(req frame) {
+	deadline := req.Deadline
+	if deadline.IsZero() {
+		deadline = time.Now().Add(defaultAwait)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	resp := frame{ChannelID: req.ChannelID, Message: req.Message}
+	var msg *sockets.Message
+	err := sockets.ErrChannelNotFound
+	// only the node with the wallet answers, nodes holding the channel for
+	// payers would wait until the deadline.
+	if c.local.HasWallet(req.ChannelID) {
+		msg, err = c.local.BroadcastAwait(ctx, req.ChannelID, req.Message)
+	}
+	switch {
+	case errors.Is(err, sockets.ErrChannelNotFound):
+		resp.NotFound = true
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		resp.Message = msg
+		resp.Message.CorrelationID = req.Message.CorrelationID
+	}
+	if _, err := c.publish(context.Background(), req.ReplyTo, resp); err != nil {
+		c.l.Error(err, "failed to reply to cluster request")
+	}
+}
+
+func (c *cluster) publish(ctx context.Context, topic string, f frame) (int64, error) {
+	f.Origin = c.nodeID
+	bb, err := json.Marshal(f)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode cluster message")
+	}
+	n, err := c.ps.Publish(ctx, topic, bb)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to publish to %s", topic)
+	}
+	return n, nil
+}
+
+func (c *cluster) channelTopic(channelID string) string {
+	return fmt.Sprintf(topicChannel, c.prefix, channelID)
+}
+
+func (c *cluster) nodeTopic() string {
+	return fmt.Sprintf(topicNode, c.prefix, c.nodeID)
+}
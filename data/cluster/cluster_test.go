@@ -0,0 +1,262 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+
+	"github.com/bitcoin-sv/dpp-proxy/data/cluster"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+)
+
+// server is a socket server holding channels, a wallet on each channel in
+// wallets replies to BroadcastAwait with reply. Channels not in wallets are
+// held by payers, who never reply.
+type server struct {
+	mu        sync.Mutex
+	channels  map[string]bool
+	wallets   map[string]bool
+	reply     string
+	broadcast []string
+}
+
+func (s *server) HasChannel(channelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channels[channelID]
+}
+
+func (s *server) HasWallet(channelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wallets[channelID]
+}
+
+func (s *server) Broadcast(channelID string, msg *sockets.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcast = append(s.broadcast, channelID)
+}
+
+func (s *server) Broadcasts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.broadcast
+}
+
+func (s *server) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	if !s.HasChannel(channelID) {
+		return nil, sockets.ErrChannelNotFound
+	}
+	if s.reply == "" || !s.HasWallet(channelID) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return msg.NewFrom(s.reply), nil
+}
+
+func TestCluster_BroadcastAwait(t *testing.T) {
+	tests := map[string]struct {
+		servers   []*server
+		channelID string
+		expKey    string
+		expErr    error
+	}{
+		"reply from wallet on other node": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+				{channels: map[string]bool{}},
+			},
+			channelID: "abc123",
+			expKey:    "paymentterms.response",
+		},
+		"reply from wallet on same node": {
+			servers: []*server{
+				{channels: map[string]bool{}},
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+			},
+			channelID: "abc123",
+			expKey:    "paymentterms.response",
+		},
+		"reply from wallet when payers hold the channel on other nodes": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+				{channels: map[string]bool{"abc123": true}},
+				{channels: map[string]bool{}},
+			},
+			channelID: "abc123",
+			expKey:    "paymentterms.response",
+		},
+		"reply from wallet on other node when a payer holds the channel on the same node": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+				{channels: map[string]bool{"abc123": true}, reply: "paymentterms.local"},
+			},
+			channelID: "abc123",
+			expKey:    "paymentterms.response",
+		},
+		"wallet on same node awaited without the backbone": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.remote"},
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+			},
+			channelID: "abc123",
+			expKey:    "paymentterms.response",
+		},
+		"channel held only by payers not found": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}},
+				{channels: map[string]bool{"abc123": true}},
+			},
+			channelID: "abc123",
+			expErr:    sockets.ErrChannelNotFound,
+		},
+		"channel on no node not found": {
+			servers: []*server{
+				{channels: map[string]bool{"abc123": true}, wallets: map[string]bool{"abc123": true}, reply: "paymentterms.response"},
+				{channels: map[string]bool{}},
+			},
+			channelID: "def456",
+			expErr:    sockets.ErrChannelNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			b := cluster.NewLocalBus()
+			nodes := make([]cluster.Broadcaster, 0)
+			holders := int64(0)
+			for i, s := range test.servers {
+				c := cluster.New(log.Noop{}, s, b.Node(), fmt.Sprintf("node%d", i), "test")
+				go func() {
+					_ = c.Run(ctx)
+				}()
+				for ch := range s.channels {
+					c.ChannelCreated(ch)
+					holders++
+				}
+				nodes = append(nodes, c)
+			}
+			ps := b.Node()
+			assert.Eventually(t, func() bool {
+				n, _ := ps.Subscribers(ctx, "test:channel:abc123")
+				return n == holders
+			}, time.Second, time.Millisecond)
+			requester := nodes[len(nodes)-1]
+
+			reqCtx, reqCancel := context.WithTimeout(ctx, time.Second)
+			defer reqCancel()
+			msg := sockets.NewMessage("paymentterms.create", "", test.channelID)
+			msg.CorrelationID = "corr1"
+			resp, err := requester.BroadcastAwait(reqCtx, test.channelID, msg)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expKey, resp.Key())
+			assert.Equal(t, "corr1", resp.CorrelationID)
+		})
+	}
+}
+
+func TestCluster_Broadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := cluster.NewLocalBus()
+	owner := &server{channels: map[string]bool{"abc123": true}}
+	other := &server{channels: map[string]bool{}}
+	c1 := cluster.New(log.Noop{}, owner, b.Node(), "node1", "test")
+	c2 := cluster.New(log.Noop{}, other, b.Node(), "node2", "test")
+	for _, c := range []interface{ Run(context.Context) error }{c1, c2} {
+		c := c
+		go func() {
+			_ = c.Run(ctx)
+		}()
+	}
+	c1.ChannelCreated("abc123")
+	assert.Eventually(t, func() bool {
+		return c2.HasChannel("abc123")
+	}, time.Second, time.Millisecond)
+
+	c2.Broadcast("abc123", sockets.NewMessage("proof.create", "", "abc123"))
+	assert.Eventually(t, func() bool {
+		return len(owner.Broadcasts()) == 1
+	}, time.Second, time.Millisecond)
+	assert.Empty(t, other.Broadcasts())
+
+	// the owner sends to its own clients once.
+	c1.Broadcast("abc123", sockets.NewMessage("proof.create", "", "abc123"))
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, owner.Broadcasts(), 2)
+}
+
+func TestCluster_ChannelCreatedAfterRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := cluster.New(log.Noop{}, &server{channels: map[string]bool{}}, cluster.NewLocalBus().Node(), "node0", "test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Run(ctx)
+	}()
+	cancel()
+	<-done
+
+	// changes must not block once Run has returned.
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for i := 0; i < 2048; i++ {
+			c.ChannelCreated(fmt.Sprintf("ch%d", i))
+			c.ChannelClosed(fmt.Sprintf("ch%d", i))
+		}
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("channel changes blocked after Run returned")
+	}
+}
+
+func TestCluster_ChannelCreatedBeforeRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := cluster.NewLocalBus()
+	c := cluster.New(log.Noop{}, &server{channels: map[string]bool{}}, b.Node(), "node0", "test")
+
+	// changes must not block while the backbone can't be reached.
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for i := 0; i < 2048; i++ {
+			c.ChannelCreated(fmt.Sprintf("ch%d", i))
+			c.ChannelClosed(fmt.Sprintf("ch%d", i))
+		}
+		c.ChannelCreated("abc123")
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("channel changes blocked before Run started")
+	}
+
+	// the latest change to each channel is made once Run starts.
+	go func() {
+		_ = c.Run(ctx)
+	}()
+	ps := b.Node()
+	assert.Eventually(t, func() bool {
+		n, _ := ps.Subscribers(ctx, "test:channel:abc123")
+		return n == 1
+	}, time.Second, time.Millisecond)
+	n, err := ps.Subscribers(ctx, "test:channel:ch0")
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+)
+
+// localBus is an in process backbone, it is used to run several nodes in one
+// process for testing without redis.
+type localBus struct {
+	mu    sync.RWMutex
+	nodes map[*localPubSub]struct{}
+}
+
+// NewLocalBus will setup and return a new in process backbone.
+func NewLocalBus() *localBus {
+	return &localBus{nodes: map[*localPubSub]struct{}{}}
+}
+
+// Node returns a new PubSub connected to the bus.
+func (b *localBus) Node() *localPubSub {
+	n := &localPubSub{
+		bus:    b,
+		topics: map[string]struct{}{},
+		msgs:   make(chan Message, 1024),
+	}
+	b.mu.Lock()
+	b.nodes[n] = struct{}{}
+	b.mu.Unlock()
+	return n
+}
+
+func (b *localBus) subscribers(topic string) []*localPubSub {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	nodes := make([]*localPubSub, 0)
+	for n := range b.nodes {
+		if n.subscribed(topic) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+type localPubSub struct {
+	bus    *localBus
+	mu     sync.RWMutex
+	topics map[string]struct{}
+	msgs   chan Message
+}
+
+// Publish will send payload to all nodes subscribed to topic.
+func (l *localPubSub) Publish(ctx context.Context, topic string, payload []byte) (int64, error) {
+	nodes := l.bus.subscribers(topic)
+	for _, n := range nodes {
+		select {
+		case n.msgs <- Message{Topic: topic, Payload: payload}:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return int64(len(nodes)), nil
+}
+
+// Subscribers returns the number of nodes subscribed to topic.
+func (l *localPubSub) Subscribers(ctx context.Context, topic string) (int64, error) {
+	return int64(len(l.bus.subscribers(topic))), nil
+}
+
+// Subscribe will add topics to the node's subscriptions.
+func (l *localPubSub) Subscribe(ctx context.Context, topics ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range topics {
+		l.topics[t] = struct{}{}
+	}
+	return nil
+}
+
+// Unsubscribe will remove topics from the node's subscriptions.
+func (l *localPubSub) Unsubscribe(ctx context.Context, topics ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range topics {
+		delete(l.topics, t)
+	}
+	return nil
+}
+
+// Messages returns messages published to the node's subscriptions.
+func (l *localPubSub) Messages() <-chan Message {
+	return l.msgs
+}
+
+// Close will remove the node from the bus.
+func (l *localPubSub) Close() error {
+	l.bus.mu.Lock()
+	delete(l.bus.nodes, l)
+	l.bus.mu.Unlock()
+	return nil
+}
+
+func (l *localPubSub) subscribed(topic string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.topics[topic]
+	return ok
+}
@@ -0,0 +1,292 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-dpp"
+	"github.com/libsv/go-dpp/modes/hybridmode"
+	"github.com/libsv/go-dpp/nativetypes"
+	"github.com/pkg/errors"
+)
+
+const (
+	// fixtureDefault is used for any paymentID without its own fixture.
+	fixtureDefault = "default"
+
+	defaultAmount  = 1000
+	defaultExpires = time.Hour
+)
+
+// fixture sets how the fake payee responds for a paymentID.
+type fixture struct {
+	// Latency is added before each response, overriding the configured latency.
+	Latency *duration `json:"latency"`
+	// ExpiresIn sets the PaymentTerms expiry from the time they are requested, a
+	// negative value returns already expired terms.
+	ExpiresIn *duration `json:"expiresIn"`
+	// PaymentTerms are returned signed with the dev key, any timestamps, url or
+	// modes not set are filled in.
+	PaymentTerms *dpp.PaymentTerms `json:"paymentTerms"`
+	// PaymentTermsError if set is returned instead of PaymentTerms.
+	PaymentTermsError *server.ClientError `json:"paymentTermsError"`
+	// PaymentACK is returned for a Payment, if not set an ACK for the payment's transactions is returned.
+	PaymentACK *dpp.PaymentACK `json:"paymentAck"`
+	// PaymentError if set is returned instead of a PaymentACK.
+	PaymentError *server.ClientError `json:"paymentError"`
+}
+
+// duration allows durations to be read from fixtures as strings such as "1.5s".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration should be a string such as '1.5s'")
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration '%s'", s)
+	}
+	*d = duration(dur)
+	return nil
+}
+
+// payee is a fake payee wallet, responses are read from fixture files so
+// frontend and payer wallet developers can exercise the proxy without a real wallet.
+//
+// Fixtures are read on each request so they can be edited without a restart.
+type payee struct {
+	l       log.Logger
+	cfg     config.FakePayee
+	fqdn    string
+	network string
+	key     *bec.PrivateKey
+}
+
+// NewPayee will setup and return a new fake payee.
+//
+// PaymentTerms are signed with the configured key, if none is set a new key is generated,
+// and are for the deployment's network unless set by a fixture.
+func NewPayee(l log.Logger, cfg config.FakePayee, srvCfg *config.Server, deployCfg *config.Deployment) (*payee, error) {
+	var key *bec.PrivateKey
+	if cfg.SigningKey != "" {
+		w, err := wif.DecodeWIF(cfg.SigningKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode fake payee signing key")
+		}
+		key = w.PrivKey
+	} else {
+		var err error
+		if key, err = bec.NewPrivateKey(bec.S256()); err != nil {
+			return nil, errors.Wrap(err, "failed to generate fake payee signing key")
+		}
+	}
+	l.Infof("using fake payee with fixtures '%s' and public key %s",
+		cfg.Fixtures, hex.EncodeToString(key.PubKey().SerialiseCompressed()))
+	return &payee{
+		l:       l,
+		cfg:     cfg,
+		fqdn:    srvCfg.FQDN,
+		network: deployCfg.Network,
+		key:     key,
+	}, nil
+}
+
+// PaymentTerms will return signed PaymentTerms for the paymentID, or the error set by its fixture.
+func (p *payee) PaymentTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+	f, err := p.fixture(args.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.wait(ctx, f); err != nil {
+		return nil, err
+	}
+	if f.PaymentTermsError != nil {
+		return nil, data.ToLathosErr(*f.PaymentTermsError)
+	}
+	return p.sign(p.paymentTerms(args.PaymentID, f))
+}
+
+// PaymentCreate will return the PaymentACK or error set by the paymentID's fixture.
+func (p *payee) PaymentCreate(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) (*dpp.PaymentACK, error) {
+	f, err := p.fixture(args.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.wait(ctx, f); err != nil {
+		return nil, err
+	}
+	if f.PaymentError != nil {
+		return nil, data.ToLathosErr(*f.PaymentError)
+	}
+	if f.PaymentACK != nil {
+		return f.PaymentACK, nil
+	}
+	txIDs := make([]string, 0, len(req.Mode.Transactions))
+	for _, rawTx := range req.Mode.Transactions {
+		tx, err := bt.NewTxFromString(rawTx)
+		if err != nil {
+			return nil, client_errors.NewErrUnprocessable("422", "transaction could not be parsed")
+		}
+		txIDs = append(txIDs, tx.TxID())
+	}
+	return &dpp.PaymentACK{
+		ModeID: req.ModeID,
+		Mode: &hybridmode.PaymentACK{
+			TransactionIds: txIDs,
+		},
+	}, nil
+}
+
+// ProofCreate will log and accept the proof.
+func (p *payee) ProofCreate(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	p.l.Infof("fake payee received proof for txid %s paymentReference %s", args.TxID, args.PaymentReference)
+	return nil
+}
+
+// fixture will read the fixture for paymentID, falling back to the default fixture
+// and then to an empty fixture if neither exist.
+func (p *payee) fixture(paymentID string) (*fixture, error) {
+	if paymentID == "" || filepath.Base(paymentID) != paymentID {
+		return nil, client_errors.NewErrNotFoundf("404", "invoice '%s' not found", paymentID)
+	}
+	var f fixture
+	if p.cfg.Fixtures == "" {
+		return &f, nil
+	}
+	for _, name := range []string{paymentID, fixtureDefault} {
+		bb, err := os.ReadFile(filepath.Join(p.cfg.Fixtures, name+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read fixture for paymentID %s", paymentID)
+		}
+		if err := json.Unmarshal(bb, &f); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode fixture %s.json", name)
+		}
+		return &f, nil
+	}
+	return &f, nil
+}
+
+// wait will delay the response by the fixture or configured latency.
+func (p *payee) wait(ctx context.Context, f *fixture) error {
+	latency := p.cfg.Latency
+	if f.Latency != nil {
+		latency = time.Duration(*f.Latency)
+	}
+	if latency <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(latency):
+		return nil
+	}
+}
+
+// paymentTerms returns the fixture's PaymentTerms with unset fields filled.
+//
+// If no outputs are set a single output paying to the signing key is requested.
+func (p *payee) paymentTerms(paymentID string, f *fixture) dpp.PaymentTerms {
+	var terms dpp.PaymentTerms
+	if f.PaymentTerms != nil {
+		terms = *f.PaymentTerms
+	}
+	now := time.Now().UTC()
+	expires := defaultExpires
+	if f.ExpiresIn != nil {
+		expires = time.Duration(*f.ExpiresIn)
+	}
+	if terms.Network == "" {
+		terms.Network = p.network
+	}
+	if terms.Version == "" {
+		terms.Version = "1.0"
+	}
+	if terms.CreationTimestamp == 0 {
+		terms.CreationTimestamp = now.Unix()
+	}
+	if terms.ExpirationTimestamp == 0 {
+		terms.ExpirationTimestamp = now.Add(expires).Unix()
+	}
+	if terms.PaymentURL == "" {
+		terms.PaymentURL = fmt.Sprintf("http://%s/api/v1/payment/%s", p.fqdn, paymentID)
+	}
+	if terms.Memo == "" {
+		terms.Memo = fmt.Sprintf("invoice %s", paymentID)
+	}
+	if terms.Beneficiary == nil {
+		terms.Beneficiary = &dpp.Beneficiary{
+			Name:             "fake payee",
+			PaymentReference: paymentID,
+		}
+	}
+	if terms.Modes == nil && len(terms.Outputs) == 0 {
+		ls, _ := bscript.NewP2PKHFromPubKeyEC(p.key.PubKey())
+		terms.Modes = &dpp.PaymentTermsModes{
+			Hybrid: hybridmode.PaymentTerms{
+				"choiceID0": {
+					"transactions": {
+						hybridmode.TransactionTerms{
+							Outputs: hybridmode.Outputs{NativeOutputs: []nativetypes.NativeOutput{{
+								Amount:        defaultAmount,
+								LockingScript: ls,
+								Description:   fmt.Sprintf("paymentReference %s", paymentID),
+							}}},
+							Policies: &hybridmode.Policies{},
+						},
+					},
+				},
+			},
+		}
+	}
+	return terms
+}
+
+// sign will return the payload in an envelope signed by the fake payee's key.
+func (p *payee) sign(payload interface{}) (*envelope.JSONEnvelope, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		return nil, errors.Wrap(err, "failed to encode payment terms")
+	}
+	pl := strings.TrimSuffix(buf.String(), "\n")
+	// envelopes with a json mimetype are verified with backslashes removed.
+	hash := sha256.Sum256([]byte(strings.ReplaceAll(pl, `\`, "")))
+	sig, err := p.key.Sign(hash[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign payment terms")
+	}
+	sigHex := hex.EncodeToString(sig.Serialise())
+	pubHex := hex.EncodeToString(p.key.PubKey().SerialiseCompressed())
+	return &envelope.JSONEnvelope{
+		Payload:   pl,
+		Signature: &sigHex,
+		PublicKey: &pubHex,
+		Encoding:  "UTF-8",
+		MimeType:  "application/json",
+	}, nil
+}
@@ -0,0 +1,177 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-dpp"
+	"github.com/libsv/go-dpp/modes/hybridmode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data/fake"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+)
+
+func fixtures(t *testing.T, ff map[string]string) string {
+	dir := t.TempDir()
+	for name, f := range ff {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".json"), []byte(f), 0o600))
+	}
+	return dir
+}
+
+func TestPayee_PaymentTerms(t *testing.T) {
+	tests := map[string]struct {
+		fixtures  map[string]string
+		paymentID string
+		expMemo   string
+		expExpiry time.Duration
+		expErr    error
+	}{
+		"no fixtures generates terms": {
+			paymentID: "abc123",
+			expMemo:   "invoice abc123",
+			expExpiry: time.Hour,
+		},
+		"fixture for paymentID is used": {
+			fixtures: map[string]string{
+				"abc123":  `{"expiresIn":"10m","paymentTerms":{"memo":"fixture & co"}}`,
+				"default": `{"paymentTerms":{"memo":"default"}}`,
+			},
+			paymentID: "abc123",
+			expMemo:   "fixture & co",
+			expExpiry: 10 * time.Minute,
+		},
+		"default fixture used when no fixture for paymentID": {
+			fixtures: map[string]string{
+				"default": `{"paymentTerms":{"memo":"default"}}`,
+			},
+			paymentID: "abc123",
+			expMemo:   "default",
+			expExpiry: time.Hour,
+		},
+		"fixture error returned": {
+			fixtures: map[string]string{
+				"abc123": `{"paymentTermsError":{"code":"404","message":"invoice paid"}}`,
+			},
+			paymentID: "abc123",
+			expErr:    errors.New("Not Found: invoice paid"),
+		},
+		"path in paymentID not found": {
+			fixtures:  map[string]string{},
+			paymentID: "../abc123",
+			expErr:    errors.New("Not Found: invoice '../abc123' not found"),
+		},
+		"invalid fixture errors": {
+			fixtures: map[string]string{
+				"abc123": `{"latency":100}`,
+			},
+			paymentID: "abc123",
+			expErr:    errors.New("failed to decode fixture abc123.json: duration should be a string such as '1.5s': json: cannot unmarshal number into Go value of type string"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.FakePayee{}
+			if test.fixtures != nil {
+				cfg.Fixtures = fixtures(t, test.fixtures)
+			}
+			p, err := fake.NewPayee(log.Noop{}, cfg, &config.Server{FQDN: "dpp:8445"}, &config.Deployment{Network: "regtest"})
+			assert.NoError(t, err)
+
+			env, err := p.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: test.paymentID})
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			ok, err := env.IsValid()
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			var terms dpp.PaymentTerms
+			assert.NoError(t, json.Unmarshal([]byte(env.Payload), &terms))
+			assert.Equal(t, test.expMemo, terms.Memo)
+			assert.Equal(t, "http://dpp:8445/api/v1/payment/"+test.paymentID, terms.PaymentURL)
+			assert.Equal(t, int64(test.expExpiry.Seconds()), terms.ExpirationTimestamp-terms.CreationTimestamp)
+			assert.NotNil(t, terms.Modes)
+		})
+	}
+}
+
+func TestPayee_PaymentCreate(t *testing.T) {
+	tests := map[string]struct {
+		fixtures map[string]string
+		timeout  time.Duration
+		expACK   *dpp.PaymentACK
+		expErr   error
+	}{
+		"ack returned for transactions": {
+			expACK: &dpp.PaymentACK{
+				ModeID: "ef63d9775da5",
+				Mode: &hybridmode.PaymentACK{
+					TransactionIds: []string{"d21633ba23f70118185227be58a63527675641ad37967e2aa461559f577aec43"},
+				},
+			},
+		},
+		"fixture ack returned": {
+			fixtures: map[string]string{
+				"abc123": `{"paymentAck":{"modeId":"ef63d9775da5","redirectUrl":"https://shop.local/thanks"}}`,
+			},
+			expACK: &dpp.PaymentACK{
+				ModeID:      "ef63d9775da5",
+				RedirectURL: "https://shop.local/thanks",
+			},
+		},
+		"fixture error returned": {
+			fixtures: map[string]string{
+				"abc123": `{"paymentError":{"code":"422","message":"not enough paid"}}`,
+			},
+			expErr: errors.New("Unprocessable Entity: not enough paid"),
+		},
+		"latency exceeding deadline errors": {
+			fixtures: map[string]string{
+				"abc123": `{"latency":"1s"}`,
+			},
+			timeout: 10 * time.Millisecond,
+			expErr:  context.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := fake.NewPayee(log.Noop{}, config.FakePayee{Fixtures: fixtures(t, test.fixtures)}, &config.Server{},
+				&config.Deployment{Network: "regtest"})
+			assert.NoError(t, err)
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			ack, err := p.PaymentCreate(ctx, dpp.PaymentCreateArgs{PaymentID: "abc123"}, dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID: "choiceID0",
+					Transactions: []string{
+						"01000000000000000000",
+					},
+				},
+			})
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expACK, ack)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package headers
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/libsv/go-bc"
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// headers is an in memory block header source, used to verify merkle proofs
+// without a connection to a node or header service.
+type headers struct {
+	mu      sync.RWMutex
+	headers map[string]*bc.BlockHeader
+}
+
+// NewMemory will setup and return a block header source holding hh.
+func NewMemory(hh ...*bc.BlockHeader) *headers {
+	h := &headers{headers: map[string]*bc.BlockHeader{}}
+	h.Add(hh...)
+	return h
+}
+
+// NewFile will setup and return a block header source holding the headers in
+// the file at path.
+//
+// The file has one 80 byte block header per line, hex encoded, blank lines
+// and lines starting with # are ignored.
+func NewFile(path string) (*headers, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open block headers file %s", path)
+	}
+	defer f.Close()
+	h := NewMemory()
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		bh, err := bc.NewBlockHeaderFromStr(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid block header on line %d of %s", line, path)
+		}
+		h.Add(bh)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read block headers file %s", path)
+	}
+	return h, nil
+}
+
+// Add will add block headers to the source.
+func (h *headers) Add(hh ...*bc.BlockHeader) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, bh := range hh {
+		h.headers[server.BlockHash(bh)] = bh
+	}
+}
+
+// BlockHeader returns the header with the block hash, bc.ErrHeaderNotFound is
+// returned if the header isn't held.
+func (h *headers) BlockHeader(ctx context.Context, blockHash string) (*bc.BlockHeader, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	bh, ok := h.headers[blockHash]
+	if !ok {
+		return nil, errors.Wrapf(bc.ErrHeaderNotFound, "block %s", blockHash)
+	}
+	return bh, nil
+}
@@ -0,0 +1,67 @@
+package headers_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libsv/go-bc"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/headers"
+)
+
+// genesis is the mainnet genesis block header and its hash.
+const (
+	genesis     = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+	genesisHash = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+)
+
+func TestNewFile(t *testing.T) {
+	tests := map[string]struct {
+		file    string
+		hash    string
+		expErr  error
+		readErr error
+	}{
+		"header in file found": {
+			file: "# mainnet\n\n" + genesis + "\n",
+			hash: genesisHash,
+		},
+		"header not in file not found": {
+			file:    genesis + "\n",
+			hash:    "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048",
+			readErr: bc.ErrHeaderNotFound,
+		},
+		"invalid header errors": {
+			file:   genesis + "\nabc123\n",
+			expErr: errors.New("invalid block header on line 2"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "headers")
+			assert.NoError(t, os.WriteFile(path, []byte(test.file), 0o600))
+			h, err := headers.NewFile(path)
+			if test.expErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			bh, err := h.BlockHeader(context.TODO(), test.hash)
+			if test.readErr != nil {
+				assert.ErrorIs(t, err, test.readErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, genesis, bh.String())
+			assert.Equal(t, test.hash, server.BlockHash(bh))
+		})
+	}
+}
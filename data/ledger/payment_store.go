@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	"context"
+	"strings"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-dpp"
+)
+
+// paymentStore decorates a PaymentStore, writing everything served to and
+// received from payers and payees to a ledger.
+//
+// Failing to write to the ledger is logged but never fails the request, the
+// ledger is an audit trail and should not stop a payment going through.
+type paymentStore struct {
+	l      log.Logger
+	ledger server.LedgerWriter
+	store  server.PaymentStore
+}
+
+// NewPaymentStore will wrap the store provided, recording each call in the ledger.
+func NewPaymentStore(l log.Logger, ledger server.LedgerWriter, store server.PaymentStore) *paymentStore {
+	return &paymentStore{
+		l:      l,
+		ledger: ledger,
+		store:  store,
+	}
+}
+
+// PaymentTerms will return the PaymentTerms from the wrapped store and record the served envelope.
+func (p *paymentStore) PaymentTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+	resp, err := p.store.PaymentTerms(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	p.record(ctx, server.LedgerEntryCreate{
+		PaymentID: args.PaymentID,
+		Event:     server.LedgerEventPaymentTerms,
+		Data:      resp,
+	})
+	return resp, nil
+}
+
+// PaymentCreate will record the payment, forward it to the wrapped store and then
+// record the PaymentACK or error returned, each with the ids of the transactions paid.
+func (p *paymentStore) PaymentCreate(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) (*dpp.PaymentACK, error) {
+	txIDs := paymentTxIDs(req)
+	p.record(ctx, server.LedgerEntryCreate{
+		PaymentID: args.PaymentID,
+		TxID:      txIDs,
+		Event:     server.LedgerEventPayment,
+		Data:      req,
+	})
+	ack, err := p.store.PaymentCreate(ctx, args, req)
+	if err != nil {
+		p.record(ctx, server.LedgerEntryCreate{
+			PaymentID: args.PaymentID,
+			TxID:      txIDs,
+			Event:     server.LedgerEventPaymentError,
+			Data:      map[string]string{"error": err.Error()},
+		})
+		return nil, err
+	}
+	p.record(ctx, server.LedgerEntryCreate{
+		PaymentID: args.PaymentID,
+		TxID:      txIDs,
+		Event:     server.LedgerEventPaymentACK,
+		Data:      ack,
+	})
+	return ack, nil
+}
+
+// ProofCreate will record the proof against the paymentReference and forward it to the wrapped store.
+func (p *paymentStore) ProofCreate(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	p.record(ctx, server.LedgerEntryCreate{
+		PaymentID: args.PaymentReference,
+		TxID:      args.TxID,
+		Event:     server.LedgerEventProof,
+		Data:      req,
+	})
+	return p.store.ProofCreate(ctx, args, req)
+}
+
+// paymentTxIDs returns the comma separated ids of the transactions in the
+// payment, transactions that can't be parsed are left out.
+func paymentTxIDs(req dpp.Payment) string {
+	txIDs := make([]string, 0, len(req.Mode.Transactions))
+	for _, txHex := range req.Mode.Transactions {
+		tx, err := bt.NewTxFromString(txHex)
+		if err != nil {
+			continue
+		}
+		txIDs = append(txIDs, tx.TxID())
+	}
+	return strings.Join(txIDs, ",")
+}
+
+func (p *paymentStore) record(ctx context.Context, req server.LedgerEntryCreate) {
+	if err := p.ledger.LedgerEntryCreate(ctx, req); err != nil {
+		p.l.Error(err, "failed to write ledger entry")
+	}
+}
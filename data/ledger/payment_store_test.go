@@ -0,0 +1,157 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-dpp"
+	"github.com/libsv/go-dpp/modes/hybridmode"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/ledger"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+)
+
+// memLedger is a simple slice backed LedgerWriter.
+type memLedger struct {
+	entries []server.LedgerEntryCreate
+	err     error
+}
+
+func (m *memLedger) LedgerEntryCreate(ctx context.Context, req server.LedgerEntryCreate) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, req)
+	return nil
+}
+
+func (m *memLedger) events() []server.LedgerEvent {
+	events := make([]server.LedgerEvent, 0, len(m.entries))
+	for _, e := range m.entries {
+		events = append(events, e.Event)
+	}
+	return events
+}
+
+func TestPaymentStore_PaymentTerms(t *testing.T) {
+	tests := map[string]struct {
+		storeErr  error
+		expEvents []server.LedgerEvent
+		expErr    error
+	}{
+		"served terms recorded": {
+			expEvents: []server.LedgerEvent{server.LedgerEventPaymentTerms},
+		},
+		"store error returned and nothing recorded": {
+			storeErr:  errors.New("oh no"),
+			expEvents: []server.LedgerEvent{},
+			expErr:    errors.New("oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := &memLedger{}
+			store := &mocks.PaymentStoreMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					if test.storeErr != nil {
+						return nil, test.storeErr
+					}
+					return &envelope.JSONEnvelope{Payload: "{}"}, nil
+				},
+			}
+
+			resp, err := ledger.NewPaymentStore(log.Noop{}, l, store).
+				PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			assert.Equal(t, test.expEvents, l.events())
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "{}", resp.Payload)
+			assert.Equal(t, "abc123", l.entries[0].PaymentID)
+			assert.Equal(t, resp, l.entries[0].Data)
+		})
+	}
+}
+
+func TestPaymentStore_PaymentCreate(t *testing.T) {
+	tests := map[string]struct {
+		storeErr  error
+		ledgerErr error
+		expEvents []server.LedgerEvent
+		expErr    error
+	}{
+		"payment and ack recorded": {
+			expEvents: []server.LedgerEvent{server.LedgerEventPayment, server.LedgerEventPaymentACK},
+		},
+		"store error recorded and returned": {
+			storeErr:  errors.New("wallet offline"),
+			expEvents: []server.LedgerEvent{server.LedgerEventPayment, server.LedgerEventPaymentError},
+			expErr:    errors.New("wallet offline"),
+		},
+		"ledger error doesn't fail the payment": {
+			ledgerErr: errors.New("disk full"),
+			expEvents: []server.LedgerEvent{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := &memLedger{err: test.ledgerErr}
+			store := &mocks.PaymentStoreMock{
+				PaymentCreateFunc: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+					if test.storeErr != nil {
+						return nil, test.storeErr
+					}
+					return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+				},
+			}
+
+			tx1, tx2 := bt.NewTx(), bt.NewTx()
+			_ = tx1.PayToAddress("1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb", 1000)
+			_ = tx2.PayToAddress("1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb", 2000)
+			ack, err := ledger.NewPaymentStore(log.Noop{}, l, store).
+				PaymentCreate(context.TODO(), dpp.PaymentCreateArgs{PaymentID: "abc123"}, dpp.Payment{
+					Mode: hybridmode.Payment{Transactions: []string{tx1.String(), tx2.String(), "nottx"}},
+				})
+			assert.Equal(t, test.expEvents, l.events())
+			assert.Len(t, store.PaymentCreateCalls(), 1)
+			for _, e := range l.entries {
+				assert.Equal(t, tx1.TxID()+","+tx2.TxID(), e.TxID)
+			}
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				assert.Equal(t, map[string]string{"error": test.expErr.Error()}, l.entries[1].Data)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "ef63d9775da5", ack.ModeID)
+		})
+	}
+}
+
+func TestPaymentStore_ProofCreate(t *testing.T) {
+	l := &memLedger{}
+	store := &mocks.PaymentStoreMock{
+		ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+			return errors.New("wallet offline")
+		},
+	}
+
+	err := ledger.NewPaymentStore(log.Noop{}, l, store).ProofCreate(context.TODO(), dpp.ProofCreateArgs{
+		TxID:             "txid",
+		PaymentReference: "abc123",
+	}, envelope.JSONEnvelope{})
+	assert.EqualError(t, err, "wallet offline")
+	assert.Equal(t, []server.LedgerEvent{server.LedgerEventProof}, l.events())
+	assert.Equal(t, "abc123", l.entries[0].PaymentID)
+	assert.Equal(t, "txid", l.entries[0].TxID)
+}
@@ -0,0 +1,97 @@
+package payd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+)
+
+// Payee wallet endpoints, these mirror the routes the proxy exposes to payers.
+const (
+	urlPaymentTerms = "%s/api/v1/payment/%s"
+	urlPayment      = "%s/api/v1/payment/%s"
+	urlProofs       = "%s/api/v1/proofs/%s?i=%s"
+
+	// merchantSeparator splits a paymentID into a merchantID and the invoiceID
+	// known to that merchant's wallet, ie "shop1.abc123".
+	merchantSeparator = "."
+)
+
+// payd is a data store that calls a payee wallet's REST api, for wallets
+// that cannot hold a websocket connection to the proxy.
+type payd struct {
+	cfg *config.PayD
+	c   data.HTTPClient
+}
+
+// NewPayD will setup and return a new http based payee wallet store.
+func NewPayD(cfg *config.PayD, c data.HTTPClient) *payd {
+	return &payd{
+		cfg: cfg,
+		c:   c,
+	}
+}
+
+// PaymentTerms will request signed PaymentTerms from the merchant wallet.
+func (p *payd) PaymentTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+	host, invoiceID, err := p.resolve(args.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	var resp envelope.JSONEnvelope
+	if err := p.c.Do(ctx, http.MethodGet, fmt.Sprintf(urlPaymentTerms, host, url.PathEscape(invoiceID)),
+		http.StatusOK, nil, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &resp, nil
+}
+
+// PaymentCreate will send the payment to the merchant wallet to be validated and processed.
+func (p *payd) PaymentCreate(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) (*dpp.PaymentACK, error) {
+	host, invoiceID, err := p.resolve(args.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	var resp dpp.PaymentACK
+	if err := p.c.Do(ctx, http.MethodPost, fmt.Sprintf(urlPayment, host, url.PathEscape(invoiceID)),
+		http.StatusCreated, req, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &resp, nil
+}
+
+// ProofCreate will send the merkle proof to the merchant wallet.
+func (p *payd) ProofCreate(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	host, invoiceID, err := p.resolve(args.PaymentReference)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(p.c.Do(ctx, http.MethodPost,
+		fmt.Sprintf(urlProofs, host, url.PathEscape(args.TxID), url.QueryEscape(invoiceID)),
+		http.StatusCreated, req, nil))
+}
+
+// resolve returns the wallet host and invoiceID for a paymentID.
+//
+// If the paymentID is prefixed with a configured merchantID that merchant's host is
+// used and the prefix removed, otherwise the default host is used.
+func (p *payd) resolve(paymentID string) (host, invoiceID string, err error) {
+	if parts := strings.SplitN(paymentID, merchantSeparator, 2); len(parts) == 2 {
+		if host, ok := p.cfg.Merchants[parts[0]]; ok {
+			return strings.TrimSuffix(host, "/"), parts[1], nil
+		}
+	}
+	if p.cfg.Host == "" {
+		return "", "", client_errors.NewErrNotFoundf("404", "no merchant found for payment '%s'", paymentID)
+	}
+	return strings.TrimSuffix(p.cfg.Host, "/"), paymentID, nil
+}
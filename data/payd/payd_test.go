@@ -0,0 +1,109 @@
+package payd_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data/payd"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+)
+
+func TestPayD_PaymentTerms(t *testing.T) {
+	tests := map[string]struct {
+		cfg         *config.PayD
+		paymentID   string
+		doErr       error
+		expEndpoint string
+		expErr      error
+	}{
+		"default host used when no merchant prefix": {
+			cfg:         &config.PayD{Host: "http://payd:8443/"},
+			paymentID:   "abc123",
+			expEndpoint: "http://payd:8443/api/v1/payment/abc123",
+		},
+		"merchant host used and prefix removed": {
+			cfg: &config.PayD{
+				Host:      "http://payd:8443",
+				Merchants: map[string]string{"shop1": "https://shop1.internal"},
+			},
+			paymentID:   "shop1.abc123",
+			expEndpoint: "https://shop1.internal/api/v1/payment/abc123",
+		},
+		"unknown merchant prefix uses default host": {
+			cfg: &config.PayD{
+				Host:      "http://payd:8443",
+				Merchants: map[string]string{"shop1": "https://shop1.internal"},
+			},
+			paymentID:   "shop2.abc123",
+			expEndpoint: "http://payd:8443/api/v1/payment/shop2.abc123",
+		},
+		"no host for payment returns not found": {
+			cfg: &config.PayD{
+				Merchants: map[string]string{"shop1": "https://shop1.internal"},
+			},
+			paymentID: "abc123",
+			expErr:    errors.New("Not Found: no merchant found for payment 'abc123'"),
+		},
+		"client error is returned": {
+			cfg:         &config.PayD{Host: "http://payd:8443"},
+			paymentID:   "abc123",
+			doErr:       errors.New("oh no"),
+			expEndpoint: "http://payd:8443/api/v1/payment/abc123",
+			expErr:      errors.New("oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &mocks.HTTPClientMock{
+				DoFunc: func(ctx context.Context, method, endpoint string, expStatus int, req, out interface{}) error {
+					assert.Equal(t, http.MethodGet, method)
+					assert.Equal(t, http.StatusOK, expStatus)
+					if test.doErr != nil {
+						return test.doErr
+					}
+					*out.(*envelope.JSONEnvelope) = envelope.JSONEnvelope{Payload: "{}"}
+					return nil
+				},
+			}
+			store := payd.NewPayD(test.cfg, c)
+
+			resp, err := store.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: test.paymentID})
+			if test.expEndpoint != "" {
+				assert.Equal(t, test.expEndpoint, c.DoCalls()[0].Endpoint)
+			}
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "{}", resp.Payload)
+		})
+	}
+}
+
+func TestPayD_ProofCreate(t *testing.T) {
+	c := &mocks.HTTPClientMock{
+		DoFunc: func(ctx context.Context, method, endpoint string, expStatus int, req, out interface{}) error {
+			return nil
+		},
+	}
+	store := payd.NewPayD(&config.PayD{
+		Merchants: map[string]string{"shop1": "https://shop1.internal"},
+	}, c)
+
+	assert.NoError(t, store.ProofCreate(context.TODO(), dpp.ProofCreateArgs{
+		TxID:             "txid",
+		PaymentReference: "shop1.abc123",
+	}, envelope.JSONEnvelope{}))
+	assert.Equal(t, http.MethodPost, c.DoCalls()[0].Method)
+	assert.Equal(t, "https://shop1.internal/api/v1/proofs/txid?i=abc123", c.DoCalls()[0].Endpoint)
+	assert.Equal(t, http.StatusCreated, c.DoCalls()[0].ExpStatus)
+}
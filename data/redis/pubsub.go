@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/bitcoin-sv/dpp-proxy/data/cluster"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+type pubSub struct {
+	c    *redis.Client
+	ps   *redis.PubSub
+	msgs chan cluster.Message
+}
+
+// NewPubSub will setup and return a redis backed cluster backbone.
+func NewPubSub(ctx context.Context, c *redis.Client) *pubSub {
+	p := &pubSub{
+		c:    c,
+		ps:   c.Subscribe(ctx),
+		msgs: make(chan cluster.Message, 1024),
+	}
+	go func() {
+		defer close(p.msgs)
+		for msg := range p.ps.Channel() {
+			p.msgs <- cluster.Message{Topic: msg.Channel, Payload: []byte(msg.Payload)}
+		}
+	}()
+	return p
+}
+
+// Publish will send payload to all subscribers of topic, returning the number reached.
+func (p *pubSub) Publish(ctx context.Context, topic string, payload []byte) (int64, error) {
+	n, err := p.c.Publish(ctx, topic, payload).Result()
+	return n, errors.WithStack(err)
+}
+
+// Subscribers returns the number of subscribers to a topic across all nodes.
+func (p *pubSub) Subscribers(ctx context.Context, topic string) (int64, error) {
+	subs, err := p.c.PubSubNumSub(ctx, topic).Result()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return subs[topic], nil
+}
+
+// Subscribe will add topics to this node's subscriptions.
+func (p *pubSub) Subscribe(ctx context.Context, topics ...string) error {
+	return errors.WithStack(p.ps.Subscribe(ctx, topics...))
+}
+
+// Unsubscribe will remove topics from this node's subscriptions.
+func (p *pubSub) Unsubscribe(ctx context.Context, topics ...string) error {
+	return errors.WithStack(p.ps.Unsubscribe(ctx, topics...))
+}
+
+// Messages returns messages received on this node's subscriptions.
+func (p *pubSub) Messages() <-chan cluster.Message {
+	return p.msgs
+}
+
+// Close will end all subscriptions.
+func (p *pubSub) Close() error {
+	return errors.WithStack(p.ps.Close())
+}
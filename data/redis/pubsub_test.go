@@ -0,0 +1,275 @@
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+
+	"github.com/bitcoin-sv/dpp-proxy/data/cluster"
+	redisData "github.com/bitcoin-sv/dpp-proxy/data/redis"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+)
+
+// fakeRedis is a redis server supporting just the pub/sub commands.
+type fakeRedis struct {
+	lis net.Listener
+
+	mu   sync.Mutex
+	subs map[string]map[*fakeConn]struct{}
+}
+
+// fakeConn is a client connection, writes are serialised as messages are
+// published from other connections.
+type fakeConn struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	topics map[string]struct{}
+}
+
+func (c *fakeConn) write(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.w.WriteString(s)
+	_ = c.w.Flush()
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{lis: lis, subs: map[string]map[*fakeConn]struct{}{}}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = lis.Close()
+	})
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	c := &fakeConn{w: bufio.NewWriter(conn), topics: map[string]struct{}{}}
+	defer f.unsubscribe(c)
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE":
+			for _, topic := range args[1:] {
+				f.mu.Lock()
+				if f.subs[topic] == nil {
+					f.subs[topic] = map[*fakeConn]struct{}{}
+				}
+				f.subs[topic][c] = struct{}{}
+				c.topics[topic] = struct{}{}
+				f.mu.Unlock()
+				c.write(fmt.Sprintf("*3\r\n%s%s:%d\r\n", bulk("subscribe"), bulk(topic), len(c.topics)))
+			}
+		case "UNSUBSCRIBE":
+			for _, topic := range args[1:] {
+				f.mu.Lock()
+				delete(f.subs[topic], c)
+				delete(c.topics, topic)
+				f.mu.Unlock()
+				c.write(fmt.Sprintf("*3\r\n%s%s:%d\r\n", bulk("unsubscribe"), bulk(topic), len(c.topics)))
+			}
+		case "PUBLISH":
+			f.mu.Lock()
+			subs := make([]*fakeConn, 0)
+			for s := range f.subs[args[1]] {
+				subs = append(subs, s)
+			}
+			f.mu.Unlock()
+			for _, s := range subs {
+				s.write(fmt.Sprintf("*3\r\n%s%s%s", bulk("message"), bulk(args[1]), bulk(args[2])))
+			}
+			c.write(fmt.Sprintf(":%d\r\n", len(subs)))
+		case "PUBSUB":
+			topics := args[2:]
+			resp := fmt.Sprintf("*%d\r\n", len(topics)*2)
+			f.mu.Lock()
+			for _, topic := range topics {
+				resp += fmt.Sprintf("%s:%d\r\n", bulk(topic), len(f.subs[topic]))
+			}
+			f.mu.Unlock()
+			c.write(resp)
+		case "PING":
+			c.write(fmt.Sprintf("*2\r\n%s%s", bulk("pong"), bulk("")))
+		default:
+			c.write("-ERR unknown command\r\n")
+		}
+	}
+}
+
+func (f *fakeRedis) unsubscribe(c *fakeConn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for topic := range c.topics {
+		delete(f.subs[topic], c)
+	}
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// wallet is a socket server holding channelID, the wallet on it replies to
+// every message.
+type wallet struct {
+	channelID string
+}
+
+func (w wallet) HasChannel(channelID string) bool {
+	return w.channelID != "" && channelID == w.channelID
+}
+
+func (w wallet) HasWallet(channelID string) bool {
+	return w.HasChannel(channelID)
+}
+
+func (w wallet) Broadcast(channelID string, msg *sockets.Message) {}
+
+func (w wallet) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	if !w.HasChannel(channelID) {
+		return nil, sockets.ErrChannelNotFound
+	}
+	return msg.NewFrom("paymentterms.response"), nil
+}
+
+func newPubSub(t *testing.T, f *fakeRedis) cluster.PubSub {
+	rc := redis.NewClient(&redis.Options{Addr: f.lis.Addr().String()})
+	ps := redisData.NewPubSub(context.Background(), rc)
+	t.Cleanup(func() {
+		_ = ps.Close()
+		_ = rc.Close()
+	})
+	return ps
+}
+
+func TestPubSub_Publish(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	f := newFakeRedis(t)
+	sub := newPubSub(t, f)
+	pub := newPubSub(t, f)
+
+	n, err := pub.Publish(ctx, "test:channel:abc123", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// subscribing doesn't wait for redis to confirm.
+	assert.NoError(t, sub.Subscribe(ctx, "test:channel:abc123"))
+	assert.Eventually(t, func() bool {
+		n, err := pub.Subscribers(ctx, "test:channel:abc123")
+		return err == nil && n == 1
+	}, time.Second, 10*time.Millisecond)
+
+	n, err = pub.Publish(ctx, "test:channel:abc123", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	select {
+	case msg := <-sub.Messages():
+		assert.Equal(t, cluster.Message{Topic: "test:channel:abc123", Payload: []byte("hello")}, msg)
+	case <-ctx.Done():
+		t.Fatal("message not received")
+	}
+
+	assert.NoError(t, sub.Unsubscribe(ctx, "test:channel:abc123"))
+	assert.Eventually(t, func() bool {
+		n, err := pub.Subscribers(ctx, "test:channel:abc123")
+		return err == nil && n == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPubSub_Close(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	f := newFakeRedis(t)
+	sub := newPubSub(t, f)
+	assert.NoError(t, sub.Subscribe(ctx, "test:node:node0"))
+
+	assert.NoError(t, sub.Close())
+	select {
+	case _, ok := <-sub.Messages():
+		assert.False(t, ok)
+	case <-ctx.Done():
+		t.Fatal("messages not closed")
+	}
+}
+
+func TestPubSub_Cluster(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	f := newFakeRedis(t)
+	holder := cluster.New(log.Noop{}, wallet{channelID: "abc123"}, newPubSub(t, f), "node0", "test")
+	requester := cluster.New(log.Noop{}, wallet{}, newPubSub(t, f), "node1", "test")
+	for _, c := range []interface{ Run(context.Context) error }{holder, requester} {
+		c := c
+		go func() {
+			_ = c.Run(ctx)
+		}()
+	}
+	holder.ChannelCreated("abc123")
+	ps := newPubSub(t, f)
+	assert.Eventually(t, func() bool {
+		n, _ := ps.Subscribers(ctx, "test:node:node1")
+		return requester.HasChannel("abc123") && n == 1
+	}, time.Second, 10*time.Millisecond)
+
+	msg := sockets.NewMessage("paymentterms.create", "", "abc123")
+	msg.CorrelationID = "corr1"
+	resp, err := requester.BroadcastAwait(ctx, "abc123", msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "paymentterms.response", resp.Key())
+	assert.Equal(t, "corr1", resp.CorrelationID)
+}
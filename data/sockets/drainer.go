@@ -0,0 +1,69 @@
+package sockets
+
+import (
+	"context"
+	"sync"
+
+	"github.com/theflyingcodr/sockets"
+)
+
+// drainer wraps a broadcaster and tracks the round trips waiting on a wallet
+// reply so they can be let finish before the server stops.
+type drainer struct {
+	b       sockets.ServerChannelBroadcaster
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{}
+}
+
+// NewDrainer will setup and return a broadcaster tracking the BroadcastAwait
+// calls made through b.
+func NewDrainer(b sockets.ServerChannelBroadcaster) *drainer {
+	idle := make(chan struct{})
+	close(idle)
+	return &drainer{b: b, idle: idle}
+}
+
+// Broadcast will send the message to the channel.
+func (d *drainer) Broadcast(channelID string, msg *sockets.Message) {
+	d.b.Broadcast(channelID, msg)
+}
+
+// BroadcastAwait will send the message to the channel and wait on a reply,
+// the call is pending until it returns.
+func (d *drainer) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	d.mu.Lock()
+	if d.pending == 0 {
+		d.idle = make(chan struct{})
+	}
+	d.pending++
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.pending--
+		if d.pending == 0 {
+			close(d.idle)
+		}
+		d.mu.Unlock()
+	}()
+	return d.b.BroadcastAwait(ctx, channelID, msg)
+}
+
+// Drain will wait until no BroadcastAwait calls are pending, or ctx is done.
+//
+// Calls made while draining are also waited on.
+func (d *drainer) Drain(ctx context.Context) error {
+	for {
+		d.mu.Lock()
+		idle, pending := d.idle, d.pending
+		d.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package sockets_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+
+	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+)
+
+// replier answers each BroadcastAwait once a reply is sent on its channel.
+type replier chan *sockets.Message
+
+func (r replier) Broadcast(channelID string, msg *sockets.Message) {}
+
+func (r replier) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	return <-r, nil
+}
+
+func TestDrainer_Drain(t *testing.T) {
+	tests := map[string]struct {
+		pending int
+		replies int
+		expErr  error
+	}{
+		"nothing pending returns": {},
+		"pending round trips are waited on": {
+			pending: 3,
+			replies: 3,
+		},
+		"unfinished round trips time out": {
+			pending: 2,
+			replies: 1,
+			expErr:  context.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := make(replier)
+			d := socData.NewDrainer(r)
+			started := make(chan struct{})
+			for i := 0; i < test.pending; i++ {
+				go func() {
+					started <- struct{}{}
+					_, _ = d.BroadcastAwait(context.Background(), "abc123", sockets.NewMessage("test", "", "abc123"))
+				}()
+				<-started
+			}
+			// the goroutines have started but may not yet be pending.
+			time.Sleep(10 * time.Millisecond)
+			go func(replies int) {
+				for i := 0; i < replies; i++ {
+					r <- sockets.NewMessage("reply", "", "abc123")
+				}
+			}(test.replies)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := d.Drain(ctx)
+			// answer any round trips left so they don't leak.
+			for i := test.replies; i < test.pending; i++ {
+				r <- sockets.NewMessage("reply", "", "abc123")
+			}
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
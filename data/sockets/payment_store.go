@@ -6,6 +6,8 @@ import (
 	"time"
 
 	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data"
 	"github.com/google/uuid"
 	"github.com/libsv/go-bk/envelope"
 	"github.com/pkg/errors"
@@ -30,12 +32,13 @@ const (
 
 // PaymentStore returns PaymentTerms and routes the Payment to the payee wallet.
 type PaymentStore struct {
-	s sockets.ServerChannelBroadcaster
+	s   sockets.ServerChannelBroadcaster
+	cfg *config.Socket
 }
 
 // NewPaymentStore will setup and return a new payd socket data store.
-func NewPaymentStore(b sockets.ServerChannelBroadcaster) *PaymentStore {
-	return &PaymentStore{s: b}
+func NewPaymentStore(b sockets.ServerChannelBroadcaster, cfg *config.Socket) *PaymentStore {
+	return &PaymentStore{s: b, cfg: cfg}
 }
 
 // ProofCreate will broadcast the proof to all currently listening clients on the socket channel.
@@ -47,6 +50,9 @@ func (p *PaymentStore) ProofCreate(ctx context.Context, args dpp.ProofCreateArgs
 		return err
 	}
 	msg.Headers.Add("x-tx-id", args.TxID)
+	if broadcaster := server.Broadcaster(ctx); broadcaster != "" {
+		msg.Headers.Add("x-broadcaster", broadcaster)
+	}
 	p.s.Broadcast(args.PaymentReference, msg)
 	return nil
 }
@@ -58,10 +64,7 @@ func (p *PaymentStore) PaymentTerms(ctx context.Context, args dpp.PaymentTermsAr
 	msg.AppID = appID
 	msg.CorrelationID = uuid.NewString()
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	resp, err := p.s.BroadcastAwait(ctx, args.PaymentID, msg)
+	resp, err := p.broadcastAwait(ctx, p.cfg.PaymentTerms, args.PaymentID, msg, true)
 	if err != nil {
 		if errors.Is(err, sockets.ErrChannelNotFound) {
 			return nil, client_errors.NewErrNotFound("404", "invoice not found")
@@ -80,7 +83,7 @@ func (p *PaymentStore) PaymentTerms(ctx context.Context, args dpp.PaymentTermsAr
 		if err := resp.Bind(&clientErr); err != nil {
 			return nil, errors.Wrap(err, "failed to bind error response from payee")
 		}
-		return nil, toLathosErr(clientErr)
+		return nil, data.ToLathosErr(clientErr)
 	}
 
 	return nil, fmt.Errorf("unexpected response key '%s'", resp.Key())
@@ -94,9 +97,8 @@ func (p *PaymentStore) PaymentCreate(ctx context.Context, args dpp.PaymentCreate
 	if err := msg.WithBody(req); err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	resp, err := p.s.BroadcastAwait(ctx, args.PaymentID, msg)
+	// a payment the wallet may have received isn't resent, it could be processed twice.
+	resp, err := p.broadcastAwait(ctx, p.cfg.Payment, args.PaymentID, msg, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to send payment message for payment")
 	}
@@ -112,26 +114,43 @@ func (p *PaymentStore) PaymentCreate(ctx context.Context, args dpp.PaymentCreate
 		if err := resp.Bind(&clientErr); err != nil {
 			return nil, errors.Wrap(err, "failed to bind error response from payee")
 		}
-		return nil, toLathosErr(clientErr)
+		return nil, data.ToLathosErr(clientErr)
 	}
 
 	return nil, fmt.Errorf("unexpected response key '%s'", resp.Key())
 }
 
-func toLathosErr(c server.ClientError) error {
-	switch c.Code {
-	case "400":
-		return client_errors.NewErrBadRequest(c.Code, c.Message)
-	case "401":
-		return client_errors.NewErrNotAuthorised(c.Code, c.Message)
-	case "403":
-		return client_errors.NewErrNotAuthenticated(c.Code, c.Message)
-	case "404", "N0001":
-		return client_errors.NewErrNotFound(c.Code, c.Message)
-	case "409":
-		return client_errors.NewErrDuplicate(c.Code, c.Message)
-	case "422":
-		return client_errors.NewErrUnprocessable(c.Code, c.Message)
+// broadcastAwait will send msg to the channel and wait on a reply, retrying as
+// set by the RoundTrip policy when the wallet is not connected or, if the msg is
+// idempotent, doesn't reply in time.
+//
+// The msg is resent unchanged so a reply to an earlier attempt can still be matched.
+func (p *PaymentStore) broadcastAwait(ctx context.Context, rt config.RoundTrip, channelID string,
+	msg *sockets.Message, idempotent bool) (*sockets.Message, error) {
+	backoff := rt.Backoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, rt.Timeout)
+		resp, err := p.s.BroadcastAwait(attemptCtx, channelID, msg)
+		timedOut := err != nil && attemptCtx.Err() != nil
+		cancel()
+		switch {
+		case err == nil:
+			return resp, nil
+		case errors.Is(err, sockets.ErrChannelNotFound):
+			if !rt.AwaitReconnect {
+				return nil, err
+			}
+		case !timedOut || !idempotent:
+			return nil, err
+		}
+		if attempt >= rt.Retries {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
-	return client_errors.NewErrBadRequest(c.Code, c.Message)
 }
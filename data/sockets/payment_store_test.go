@@ -0,0 +1,150 @@
+package sockets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+)
+
+// broadcaster replies to each BroadcastAwait with the next error in errs,
+// once all errors are used a reply is sent, PaymentTerms if no key is set.
+type broadcaster struct {
+	errs  []error
+	reply string
+	calls []string
+}
+
+func (b *broadcaster) Broadcast(channelID string, msg *sockets.Message) {}
+
+func (b *broadcaster) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	b.calls = append(b.calls, msg.CorrelationID)
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		if err == context.DeadlineExceeded {
+			<-ctx.Done()
+			return nil, errors.New("timeout waiting for message")
+		}
+		return nil, err
+	}
+	key := b.reply
+	if key == "" {
+		key = socData.RoutePaymentTermsResponse
+	}
+	resp := sockets.NewMessage(key, "", channelID)
+	if err := resp.WithBody(map[string]string{"payload": "{}"}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestPaymentStore_PaymentTermsRetries(t *testing.T) {
+	tests := map[string]struct {
+		rt       config.RoundTrip
+		errs     []error
+		expCalls int
+		expErr   error
+	}{
+		"no errors sends once": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 3},
+			expCalls: 1,
+		},
+		"wallet reconnects within retries": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 3, Backoff: time.Millisecond, AwaitReconnect: true},
+			errs:     []error{sockets.ErrChannelNotFound, sockets.ErrChannelNotFound},
+			expCalls: 3,
+		},
+		"wallet not connected and not awaiting reconnect fails immediately": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 3, Backoff: time.Millisecond},
+			errs:     []error{sockets.ErrChannelNotFound},
+			expCalls: 1,
+			expErr:   errors.New("Not Found: invoice not found"),
+		},
+		"wallet not reconnected within retries": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 1, Backoff: time.Millisecond, AwaitReconnect: true},
+			errs:     []error{sockets.ErrChannelNotFound, sockets.ErrChannelNotFound},
+			expCalls: 2,
+			expErr:   errors.New("Not Found: invoice not found"),
+		},
+		"timeout is retried": {
+			rt:       config.RoundTrip{Timeout: 10 * time.Millisecond, Retries: 1, Backoff: time.Millisecond},
+			errs:     []error{context.DeadlineExceeded},
+			expCalls: 2,
+		},
+		"timeout with no retries fails": {
+			rt:       config.RoundTrip{Timeout: 10 * time.Millisecond},
+			errs:     []error{context.DeadlineExceeded},
+			expCalls: 1,
+			expErr:   errors.New("failed to broadcast message for payment terms (secure): timeout waiting for message"),
+		},
+		"other errors are not retried": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 3, Backoff: time.Millisecond, AwaitReconnect: true},
+			errs:     []error{errors.New("oh no")},
+			expCalls: 1,
+			expErr:   errors.New("failed to broadcast message for payment terms (secure): oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &broadcaster{errs: test.errs}
+			store := socData.NewPaymentStore(b, &config.Socket{PaymentTerms: test.rt})
+
+			resp, err := store.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			assert.Len(t, b.calls, test.expCalls)
+			for _, id := range b.calls {
+				assert.Equal(t, b.calls[0], id)
+			}
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "{}", resp.Payload)
+		})
+	}
+}
+
+func TestPaymentStore_PaymentCreateRetries(t *testing.T) {
+	tests := map[string]struct {
+		rt       config.RoundTrip
+		errs     []error
+		expCalls int
+		expErr   error
+	}{
+		"wallet reconnects within retries": {
+			rt:       config.RoundTrip{Timeout: time.Second, Retries: 3, Backoff: time.Millisecond, AwaitReconnect: true},
+			errs:     []error{sockets.ErrChannelNotFound},
+			expCalls: 2,
+		},
+		"timeout is not retried": {
+			rt:       config.RoundTrip{Timeout: 10 * time.Millisecond, Retries: 3, Backoff: time.Millisecond},
+			errs:     []error{context.DeadlineExceeded},
+			expCalls: 1,
+			expErr:   errors.New("failed to send payment message for payment: timeout waiting for message"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &broadcaster{errs: test.errs, reply: socData.RoutePaymentACK}
+			store := socData.NewPaymentStore(b, &config.Socket{Payment: test.rt})
+
+			_, err := store.PaymentCreate(context.TODO(), dpp.PaymentCreateArgs{PaymentID: "abc123"}, dpp.Payment{})
+			assert.Len(t, b.calls, test.expCalls)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
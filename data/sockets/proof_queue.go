@@ -0,0 +1,92 @@
+package sockets
+
+import (
+	"context"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+)
+
+// ChannelChecker is used to check if a channel is open.
+type ChannelChecker interface {
+	HasChannel(channelID string) bool
+}
+
+// WalletChecker is used to check if a payee wallet is listening on a channel.
+type WalletChecker interface {
+	HasWallet(channelID string) bool
+}
+
+// proofQueueStore decorates a PaymentStore so proofs sent while no wallet is
+// listening on the paymentReference channel are queued rather than lost.
+//
+// Queued proofs are sent when ProofsDeliver is called for the channel, this
+// should be triggered when a client joins it.
+type proofQueueStore struct {
+	server.PaymentStore
+	w   WalletChecker
+	q   server.ProofQueueReaderWriter
+	ttl time.Duration
+}
+
+// NewProofQueueStore will wrap store, queueing proofs for ttl when no wallet is listening to receive them.
+func NewProofQueueStore(store server.PaymentStore, w WalletChecker, q server.ProofQueueReaderWriter, ttl time.Duration) *proofQueueStore {
+	return &proofQueueStore{
+		PaymentStore: store,
+		w:            w,
+		q:            q,
+		ttl:          ttl,
+	}
+}
+
+// ProofCreate will send the proof to the wallet if it is listening, otherwise the proof is queued.
+//
+// Payers can hold the channel without a wallet, their proofs are queued too.
+func (p *proofQueueStore) ProofCreate(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	if p.w.HasWallet(args.PaymentReference) {
+		return p.PaymentStore.ProofCreate(ctx, args, req)
+	}
+	if err := p.q.ProofQueueCreate(ctx, server.ProofQueueCreate{
+		Args:      args,
+		Envelope:  req,
+		ExpiresAt: time.Now().Add(p.ttl),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to queue proof for paymentReference %s", args.PaymentReference)
+	}
+	return nil
+}
+
+// ProofsDeliver will send all proofs queued for the channel.
+//
+// Each proof is removed from the queue before it is sent, so concurrent
+// deliveries can't send it twice, and queued again if it can't be sent.
+func (p *proofQueueStore) ProofsDeliver(ctx context.Context, channelID string) error {
+	proofs, err := p.q.ProofQueue(ctx, server.ProofQueueArgs{ChannelID: channelID})
+	if err != nil {
+		return err
+	}
+	for _, proof := range proofs {
+		claimed, err := p.q.ProofQueueDelete(ctx, proof.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := p.PaymentStore.ProofCreate(ctx, proof.Args, proof.Envelope); err != nil {
+			if qErr := p.q.ProofQueueCreate(ctx, server.ProofQueueCreate{
+				Args:      proof.Args,
+				Envelope:  proof.Envelope,
+				ExpiresAt: proof.ExpiresAt,
+			}); qErr != nil {
+				return errors.Wrapf(qErr, "failed to requeue proof for txid %s after send failed: %s",
+					proof.Args.TxID, err)
+			}
+			return errors.Wrapf(err, "failed to deliver queued proof for txid %s", proof.Args.TxID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package sockets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/sockets"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+)
+
+// wallets are the channels with a wallet connected, false if only payers are.
+type wallets map[string]bool
+
+func (w wallets) HasWallet(channelID string) bool {
+	return w[channelID]
+}
+
+func TestProofQueueStore_ProofCreate(t *testing.T) {
+	tests := map[string]struct {
+		wallets   wallets
+		queueErr  error
+		expSent   int
+		expQueued int
+		expErr    error
+	}{
+		"proof sent when wallet listening": {
+			wallets: wallets{"abc123": true},
+			expSent: 1,
+		},
+		"proof queued when wallet offline": {
+			wallets:   wallets{},
+			expQueued: 1,
+		},
+		"proof queued when only payers hold the channel": {
+			wallets:   wallets{"abc123": false},
+			expQueued: 1,
+		},
+		"queue error returned": {
+			wallets:   wallets{},
+			queueErr:  errors.New("oh no"),
+			expQueued: 1,
+			expErr:    errors.New("failed to queue proof for paymentReference abc123: oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &mocks.PaymentStoreMock{
+				ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+					return nil
+				},
+			}
+			q := &mocks.ProofQueueReaderWriterMock{
+				ProofQueueCreateFunc: func(ctx context.Context, req server.ProofQueueCreate) error {
+					assert.Equal(t, "abc123", req.Args.PaymentReference)
+					assert.False(t, req.ExpiresAt.IsZero())
+					return test.queueErr
+				},
+			}
+			err := sockets.NewProofQueueStore(store, test.wallets, q, time.Hour).ProofCreate(context.TODO(),
+				dpp.ProofCreateArgs{TxID: "txid", PaymentReference: "abc123"}, envelope.JSONEnvelope{})
+			assert.Len(t, store.ProofCreateCalls(), test.expSent)
+			assert.Len(t, q.ProofQueueCreateCalls(), test.expQueued)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProofQueueStore_ProofsDeliver(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		proofs      []server.QueuedProof
+		claimed     map[int64]bool
+		sendErr     error
+		expSent     []string
+		expRequeued []string
+		expErr      error
+	}{
+		"queued proofs sent and removed": {
+			proofs: []server.QueuedProof{
+				{ID: 1, Args: dpp.ProofCreateArgs{TxID: "tx1", PaymentReference: "abc123"}},
+				{ID: 2, Args: dpp.ProofCreateArgs{TxID: "tx2", PaymentReference: "abc123"}},
+			},
+			claimed: map[int64]bool{1: true, 2: true},
+			expSent: []string{"tx1", "tx2"},
+		},
+		"nothing queued": {
+			proofs: []server.QueuedProof{},
+		},
+		"proof claimed by another delivery not sent": {
+			proofs: []server.QueuedProof{
+				{ID: 1, Args: dpp.ProofCreateArgs{TxID: "tx1", PaymentReference: "abc123"}},
+				{ID: 2, Args: dpp.ProofCreateArgs{TxID: "tx2", PaymentReference: "abc123"}},
+			},
+			claimed: map[int64]bool{2: true},
+			expSent: []string{"tx2"},
+		},
+		"send error queues proof again": {
+			proofs: []server.QueuedProof{
+				{ID: 1, Args: dpp.ProofCreateArgs{TxID: "tx1", PaymentReference: "abc123"}, ExpiresAt: expires},
+			},
+			claimed:     map[int64]bool{1: true},
+			sendErr:     errors.New("oh no"),
+			expSent:     []string{"tx1"},
+			expRequeued: []string{"tx1"},
+			expErr:      errors.New("failed to deliver queued proof for txid tx1: oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &mocks.PaymentStoreMock{
+				ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+					return test.sendErr
+				},
+			}
+			q := &mocks.ProofQueueReaderWriterMock{
+				ProofQueueFunc: func(ctx context.Context, args server.ProofQueueArgs) ([]server.QueuedProof, error) {
+					assert.Equal(t, "abc123", args.ChannelID)
+					return test.proofs, nil
+				},
+				ProofQueueDeleteFunc: func(ctx context.Context, id int64) (bool, error) {
+					return test.claimed[id], nil
+				},
+				ProofQueueCreateFunc: func(ctx context.Context, req server.ProofQueueCreate) error {
+					assert.Equal(t, expires, req.ExpiresAt)
+					return nil
+				},
+			}
+			err := sockets.NewProofQueueStore(store, wallets{}, q, time.Hour).ProofsDeliver(context.TODO(), "abc123")
+			sent := make([]string, 0)
+			for _, c := range store.ProofCreateCalls() {
+				sent = append(sent, c.Args.TxID)
+			}
+			requeued := make([]string, 0)
+			for _, c := range q.ProofQueueCreateCalls() {
+				requeued = append(requeued, c.Req.Args.TxID)
+			}
+			assert.ElementsMatch(t, test.expSent, sent)
+			assert.ElementsMatch(t, test.expRequeued, requeued)
+			assert.Len(t, q.ProofQueueDeleteCalls(), len(test.proofs))
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
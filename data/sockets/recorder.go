@@ -0,0 +1,193 @@
+package sockets
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/sockets"
+)
+
+// Directions of a recorded message.
+const (
+	RecordOut   = "out"
+	RecordIn    = "in"
+	RecordError = "error"
+)
+
+// Recording is a single line of a socket recording.
+//
+// An out record is a message sent to a channel, the reply from the wallet is
+// recorded as in or, if no reply was received, as error. Replies have the
+// CorrelationID of the message they answer. Messages a wallet sends unasked,
+// such as paymentterms.push, are recorded as in and any response to them as out.
+type Recording struct {
+	Time          time.Time        `json:"time"`
+	Direction     string           `json:"direction"`
+	ChannelID     string           `json:"channelId"`
+	CorrelationID string           `json:"correlationId"`
+	Message       *sockets.Message `json:"message,omitempty"`
+	Error         string           `json:"error,omitempty"`
+}
+
+// recorder wraps a broadcaster and writes every message sent to, and
+// received from, a channel as a line of JSON.
+type recorder struct {
+	b   sockets.ServerChannelBroadcaster
+	mu  sync.Mutex
+	enc *json.Encoder
+	// awaiting are the correlationIDs of messages sent with BroadcastAwait,
+	// their replies are recorded there rather than by Middleware.
+	awaiting map[string]struct{}
+}
+
+// NewRecorder will setup and return a broadcaster recording all traffic through b to w.
+//
+// Messages received by the socket server are only recorded if Middleware is
+// added to it.
+func NewRecorder(b sockets.ServerChannelBroadcaster, w io.Writer) *recorder {
+	return &recorder{
+		b:        b,
+		enc:      json.NewEncoder(w),
+		awaiting: map[string]struct{}{},
+	}
+}
+
+// Broadcast will record and send the message to the channel.
+func (r *recorder) Broadcast(channelID string, msg *sockets.Message) {
+	r.record(Recording{Direction: RecordOut, ChannelID: channelID, CorrelationID: msg.CorrelationID, Message: msg})
+	r.b.Broadcast(channelID, msg)
+}
+
+// BroadcastAwait will record the message sent to the channel and the reply or error received.
+func (r *recorder) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	r.record(Recording{Direction: RecordOut, ChannelID: channelID, CorrelationID: msg.CorrelationID, Message: msg})
+	r.mu.Lock()
+	r.awaiting[msg.CorrelationID] = struct{}{}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.awaiting, msg.CorrelationID)
+		r.mu.Unlock()
+	}()
+	resp, err := r.b.BroadcastAwait(ctx, channelID, msg)
+	if err != nil {
+		r.record(Recording{Direction: RecordError, ChannelID: channelID, CorrelationID: msg.CorrelationID, Error: err.Error()})
+		return nil, err
+	}
+	r.record(Recording{Direction: RecordIn, ChannelID: channelID, CorrelationID: msg.CorrelationID, Message: resp})
+	return resp, nil
+}
+
+// Middleware is a socket middleware recording the messages received from
+// clients and the responses of their handlers.
+func (r *recorder) Middleware(next sockets.HandlerFunc) sockets.HandlerFunc {
+	return func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+		r.mu.Lock()
+		_, reply := r.awaiting[msg.CorrelationID]
+		r.mu.Unlock()
+		if !reply {
+			r.record(Recording{Direction: RecordIn, ChannelID: msg.ChannelID(), CorrelationID: msg.CorrelationID, Message: msg})
+		}
+		resp, err := next(ctx, msg)
+		switch {
+		case err != nil:
+			r.record(Recording{Direction: RecordError, ChannelID: msg.ChannelID(), CorrelationID: msg.CorrelationID, Error: err.Error()})
+		case resp != nil:
+			r.record(Recording{Direction: RecordOut, ChannelID: msg.ChannelID(), CorrelationID: resp.CorrelationID, Message: resp})
+		}
+		return resp, err
+	}
+}
+
+// record writes the recording, errors are ignored so a failing recorder
+// doesn't affect payments.
+func (r *recorder) record(rec Recording) {
+	rec.Time = time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(rec)
+}
+
+// replay is a broadcaster that answers BroadcastAwait from a recording.
+type replay struct {
+	mu      sync.Mutex
+	records []Recording
+	used    map[int]struct{}
+}
+
+// NewReplay will read a recording made by a recorder and return a broadcaster replaying it.
+func NewReplay(r io.Reader) (*replay, error) {
+	rp := &replay{used: map[int]struct{}{}}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode recording line %d", len(rp.records)+1)
+		}
+		rp.records = append(rp.records, rec)
+	}
+	return rp, errors.WithStack(sc.Err())
+}
+
+// Broadcast does nothing, messages without a reply have nothing to replay.
+func (r *replay) Broadcast(channelID string, msg *sockets.Message) {}
+
+// BroadcastAwait will return the recorded reply to the next unused message of
+// the same type sent to the channel, the reply is given msg's correlationID.
+//
+// If no wallet was connected sockets.ErrChannelNotFound is returned, this is also
+// returned when there is no matching message. Any other recorded error was a
+// timeout, so the call waits on ctx before returning it.
+func (r *replay) BroadcastAwait(ctx context.Context, channelID string, msg *sockets.Message) (*sockets.Message, error) {
+	reply := r.reply(channelID, msg.Key())
+	switch {
+	case reply == nil:
+		return nil, sockets.ErrChannelNotFound
+	case reply.Direction == RecordIn:
+		resp := *reply.Message
+		resp.CorrelationID = msg.CorrelationID
+		return &resp, nil
+	case reply.Error == sockets.ErrChannelNotFound.Error():
+		return nil, sockets.ErrChannelNotFound
+	}
+	<-ctx.Done()
+	return nil, errors.New(reply.Error)
+}
+
+// reply finds the next unused message of type key sent to the channel and returns
+// the record of its reply or error.
+func (r *replay) reply(channelID, key string) *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rec := range r.records {
+		if _, ok := r.used[i]; ok {
+			continue
+		}
+		if rec.Direction != RecordOut || rec.ChannelID != channelID || rec.Message.Key() != key {
+			continue
+		}
+		r.used[i] = struct{}{}
+		for _, reply := range r.records[i+1:] {
+			if reply.ChannelID != channelID || reply.CorrelationID != rec.CorrelationID {
+				continue
+			}
+			if reply.Direction == RecordOut {
+				// resent before a reply was recorded.
+				return &Recording{Direction: RecordError, Error: "timeout waiting for message"}
+			}
+			return &reply
+		}
+		// the recording ended before a reply.
+		return &Recording{Direction: RecordError, Error: "timeout waiting for message"}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sockets_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/sockets"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	socData "github.com/bitcoin-sv/dpp-proxy/data/sockets"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	cfg := &config.Socket{PaymentTerms: config.RoundTrip{
+		Timeout:        time.Second,
+		Retries:        1,
+		Backoff:        time.Millisecond,
+		AwaitReconnect: true,
+	}}
+	buf := &bytes.Buffer{}
+	b := &broadcaster{errs: []error{sockets.ErrChannelNotFound}}
+	recorded, err := socData.NewPaymentStore(socData.NewRecorder(b, buf), cfg).
+		PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	dirs := make([]string, 0, len(lines))
+	for _, l := range lines {
+		var rec socData.Recording
+		assert.NoError(t, json.Unmarshal([]byte(l), &rec))
+		assert.Equal(t, "abc123", rec.ChannelID)
+		dirs = append(dirs, rec.Direction)
+	}
+	assert.Equal(t, []string{socData.RecordOut, socData.RecordError, socData.RecordOut, socData.RecordIn}, dirs)
+
+	rp, err := socData.NewReplay(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	store := socData.NewPaymentStore(rp, cfg)
+	replayed, err := store.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+
+	// the recording has been used up.
+	_, err = store.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+	assert.EqualError(t, err, "Not Found: invoice not found")
+}
+
+func TestRecorder_Middleware(t *testing.T) {
+	tests := map[string]struct {
+		resp    bool
+		err     error
+		expDirs []string
+	}{
+		"message without response recorded as in": {
+			expDirs: []string{socData.RecordIn},
+		},
+		"response recorded as out": {
+			resp:    true,
+			expDirs: []string{socData.RecordIn, socData.RecordOut},
+		},
+		"handler error recorded": {
+			err:     errors.New("invalid terms"),
+			expDirs: []string{socData.RecordIn, socData.RecordError},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			r := socData.NewRecorder(&broadcaster{}, buf)
+			msg := sockets.NewMessage("paymentterms.push", "client1", "abc123")
+			msg.CorrelationID = "corr1"
+			_, err := r.Middleware(func(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+				if test.resp {
+					return msg.NewFrom("paymentterms.pushed"), test.err
+				}
+				return nil, test.err
+			})(context.TODO(), msg)
+			assert.Equal(t, test.err, err)
+
+			dirs := make([]string, 0)
+			for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var rec socData.Recording
+				assert.NoError(t, json.Unmarshal([]byte(l), &rec))
+				assert.Equal(t, "abc123", rec.ChannelID)
+				assert.Equal(t, "corr1", rec.CorrelationID)
+				dirs = append(dirs, rec.Direction)
+			}
+			assert.Equal(t, test.expDirs, dirs)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/pkg/errors"
+)
+
+const (
+	sqlLedgerEntryInsert = `
+	INSERT INTO ledger(payment_id, tx_id, event, data, created_at)
+	VALUES(?, ?, ?, ?, ?)
+	`
+
+	sqlLedgerEntriesByPaymentID = `
+	SELECT id, payment_id, tx_id, event, data, created_at
+	FROM ledger
+	WHERE payment_id = ?
+	ORDER BY id
+	`
+)
+
+type ledger struct {
+	db *sql.DB
+}
+
+// NewLedger will setup and return a sqlite backed payment ledger.
+func NewLedger(db *sql.DB) *ledger {
+	return &ledger{db: db}
+}
+
+// LedgerEntryCreate will serialise the entry data and store it against the paymentID.
+func (l *ledger) LedgerEntryCreate(ctx context.Context, req server.LedgerEntryCreate) error {
+	bb, err := json.Marshal(req.Data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s ledger data for paymentID %s", req.Event, req.PaymentID)
+	}
+	if _, err := l.db.ExecContext(ctx, sqlLedgerEntryInsert,
+		req.PaymentID, req.TxID, string(req.Event), string(bb), time.Now().UTC()); err != nil {
+		return errors.Wrapf(err, "failed to insert %s ledger entry for paymentID %s", req.Event, req.PaymentID)
+	}
+	return nil
+}
+
+// LedgerEntries will return all entries for a paymentID in the order they were written.
+func (l *ledger) LedgerEntries(ctx context.Context, args server.LedgerArgs) ([]server.LedgerEntry, error) {
+	rows, err := l.db.QueryContext(ctx, sqlLedgerEntriesByPaymentID, args.PaymentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read ledger for paymentID %s", args.PaymentID)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	entries := make([]server.LedgerEntry, 0)
+	for rows.Next() {
+		var e server.LedgerEntry
+		var data string
+		if err := rows.Scan(&e.ID, &e.PaymentID, &e.TxID, &e.Event, &data, &e.CreatedAt); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan ledger entry for paymentID %s", args.PaymentID)
+		}
+		e.Data = json.RawMessage(data)
+		entries = append(entries, e)
+	}
+	return entries, errors.WithStack(rows.Err())
+}
@@ -0,0 +1,113 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/sqlite"
+)
+
+func TestLedger_LedgerEntries(t *testing.T) {
+	tests := map[string]struct {
+		entries   []server.LedgerEntryCreate
+		paymentID string
+		expEvents []server.LedgerEvent
+		expData   []string
+	}{
+		"no entries returns empty slice": {
+			paymentID: "abc123",
+			expEvents: []server.LedgerEvent{},
+			expData:   []string{},
+		},
+		"entries returned in the order written": {
+			entries: []server.LedgerEntryCreate{{
+				PaymentID: "abc123",
+				Event:     server.LedgerEventPayment,
+				Data:      map[string]string{"memo": "hi"},
+			}, {
+				PaymentID: "abc123",
+				Event:     server.LedgerEventPaymentACK,
+				Data:      map[string]string{"memo": "ack"},
+			}},
+			paymentID: "abc123",
+			expEvents: []server.LedgerEvent{server.LedgerEventPayment, server.LedgerEventPaymentACK},
+			expData:   []string{`{"memo":"hi"}`, `{"memo":"ack"}`},
+		},
+		"entries of other payments not returned": {
+			entries: []server.LedgerEntryCreate{{
+				PaymentID: "def456",
+				Event:     server.LedgerEventPayment,
+				Data:      "other",
+			}, {
+				PaymentID: "abc123",
+				TxID:      "txid",
+				Event:     server.LedgerEventProof,
+				Data:      "proof",
+			}},
+			paymentID: "abc123",
+			expEvents: []server.LedgerEvent{server.LedgerEventProof},
+			expData:   []string{`"proof"`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := sqlite.NewLedger(newDB(t))
+			for _, e := range test.entries {
+				assert.NoError(t, l.LedgerEntryCreate(context.TODO(), e))
+			}
+
+			entries, err := l.LedgerEntries(context.TODO(), server.LedgerArgs{PaymentID: test.paymentID})
+			assert.NoError(t, err)
+			events := make([]server.LedgerEvent, 0)
+			data := make([]string, 0)
+			for _, e := range entries {
+				assert.Equal(t, test.paymentID, e.PaymentID)
+				assert.WithinDuration(t, time.Now(), e.CreatedAt, time.Minute)
+				events = append(events, e.Event)
+				data = append(data, string(e.Data))
+			}
+			assert.Equal(t, test.expEvents, events)
+			assert.Equal(t, test.expData, data)
+		})
+	}
+}
+
+func TestLedger_LedgerEntryCreate(t *testing.T) {
+	l := sqlite.NewLedger(newDB(t))
+
+	err := l.LedgerEntryCreate(context.TODO(), server.LedgerEntryCreate{
+		PaymentID: "abc123",
+		Event:     server.LedgerEventPayment,
+		Data:      make(chan int),
+	})
+	assert.EqualError(t, err, "failed to encode payment ledger data for paymentID abc123: json: unsupported type: chan int")
+
+	assert.NoError(t, l.LedgerEntryCreate(context.TODO(), server.LedgerEntryCreate{
+		PaymentID: "abc123",
+		TxID:      "txid",
+		Event:     server.LedgerEventProof,
+	}))
+	entries, err := l.LedgerEntries(context.TODO(), server.LedgerArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "txid", entries[0].TxID)
+	assert.Equal(t, "null", string(entries[0].Data))
+}
+
+// newDB returns a migrated in-memory database closed when the test ends.
+func newDB(t *testing.T) *sql.DB {
+	db, err := sqlite.NewSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
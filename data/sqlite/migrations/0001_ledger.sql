@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ledger (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    payment_id  TEXT NOT NULL,
+    tx_id       TEXT NOT NULL DEFAULT '',
+    event       TEXT NOT NULL,
+    data        TEXT,
+    created_at  DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_payment_id ON ledger (payment_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS proof_queue (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id  TEXT NOT NULL,
+    tx_id       TEXT NOT NULL,
+    envelope    TEXT NOT NULL,
+    created_at  INTEGER NOT NULL,
+    expires_at  INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_proof_queue_channel_id ON proof_queue (channel_id);
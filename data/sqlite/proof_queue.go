@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/pkg/errors"
+)
+
+const (
+	sqlProofQueueInsert = `
+	INSERT INTO proof_queue(channel_id, tx_id, envelope, created_at, expires_at)
+	VALUES(?, ?, ?, ?, ?)
+	`
+
+	sqlProofQueueByChannel = `
+	SELECT id, channel_id, tx_id, envelope, created_at, expires_at
+	FROM proof_queue
+	WHERE channel_id = ? AND expires_at > ?
+	ORDER BY id
+	`
+
+	sqlProofQueueDelete = `
+	DELETE FROM proof_queue WHERE id = ?
+	`
+
+	sqlProofQueuePrune = `
+	DELETE FROM proof_queue WHERE expires_at <= ?
+	`
+
+	sqlProofQueueCount = `
+	SELECT COUNT(*) FROM proof_queue WHERE expires_at > ?
+	`
+)
+
+type proofQueue struct {
+	db *sql.DB
+}
+
+// NewProofQueue will setup and return a sqlite backed proof queue.
+func NewProofQueue(db *sql.DB) *proofQueue {
+	return &proofQueue{db: db}
+}
+
+// ProofQueueCreate will add a proof to the queue for the paymentReference channel.
+func (p *proofQueue) ProofQueueCreate(ctx context.Context, req server.ProofQueueCreate) error {
+	bb, err := json.Marshal(req.Envelope)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode proof for txid %s", req.Args.TxID)
+	}
+	if _, err := p.db.ExecContext(ctx, sqlProofQueueInsert, req.Args.PaymentReference, req.Args.TxID,
+		string(bb), time.Now().UTC().Unix(), req.ExpiresAt.UTC().Unix()); err != nil {
+		return errors.Wrapf(err, "failed to queue proof for txid %s", req.Args.TxID)
+	}
+	return nil
+}
+
+// ProofQueue returns unexpired proofs queued for the channel.
+func (p *proofQueue) ProofQueue(ctx context.Context, args server.ProofQueueArgs) ([]server.QueuedProof, error) {
+	rows, err := p.db.QueryContext(ctx, sqlProofQueueByChannel, args.ChannelID, time.Now().UTC().Unix())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read proof queue for channel %s", args.ChannelID)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	proofs := make([]server.QueuedProof, 0)
+	for rows.Next() {
+		var q server.QueuedProof
+		var env string
+		var created, expires int64
+		if err := rows.Scan(&q.ID, &q.Args.PaymentReference, &q.Args.TxID, &env, &created, &expires); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan queued proof for channel %s", args.ChannelID)
+		}
+		if err := json.Unmarshal([]byte(env), &q.Envelope); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode queued proof for txid %s", q.Args.TxID)
+		}
+		q.CreatedAt = time.Unix(created, 0).UTC()
+		q.ExpiresAt = time.Unix(expires, 0).UTC()
+		proofs = append(proofs, q)
+	}
+	return proofs, errors.WithStack(rows.Err())
+}
+
+// ProofQueueDelete will remove a proof from the queue, returning false if it
+// was already removed.
+func (p *proofQueue) ProofQueueDelete(ctx context.Context, id int64) (bool, error) {
+	res, err := p.db.ExecContext(ctx, sqlProofQueueDelete, id)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to delete queued proof %d", id)
+	}
+	n, err := res.RowsAffected()
+	return n == 1, errors.WithStack(err)
+}
+
+// ProofQueuePrune will remove all expired proofs.
+func (p *proofQueue) ProofQueuePrune(ctx context.Context) (int64, error) {
+	res, err := p.db.ExecContext(ctx, sqlProofQueuePrune, time.Now().UTC().Unix())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to prune proof queue")
+	}
+	n, err := res.RowsAffected()
+	return n, errors.WithStack(err)
+}
+
+// ProofQueueCount returns the number of proofs waiting to be delivered.
+func (p *proofQueue) ProofQueueCount(ctx context.Context) (int64, error) {
+	var n int64
+	if err := p.db.QueryRowContext(ctx, sqlProofQueueCount, time.Now().UTC().Unix()).Scan(&n); err != nil {
+		return 0, errors.Wrap(err, "failed to count proof queue")
+	}
+	return n, nil
+}
@@ -0,0 +1,118 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/sqlite"
+)
+
+func queueProof(channelID, txID string, expires time.Time) server.ProofQueueCreate {
+	return server.ProofQueueCreate{
+		Args:      dpp.ProofCreateArgs{PaymentReference: channelID, TxID: txID},
+		Envelope:  envelope.JSONEnvelope{Payload: `{"txOrId":"` + txID + `"}`},
+		ExpiresAt: expires,
+	}
+}
+
+func TestProofQueue_ProofQueue(t *testing.T) {
+	hour := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		queued    []server.ProofQueueCreate
+		channelID string
+		expTxIDs  []string
+	}{
+		"empty queue returns empty slice": {
+			channelID: "abc123",
+			expTxIDs:  []string{},
+		},
+		"proofs returned oldest first": {
+			queued: []server.ProofQueueCreate{
+				queueProof("abc123", "tx1", hour),
+				queueProof("abc123", "tx2", hour),
+			},
+			channelID: "abc123",
+			expTxIDs:  []string{"tx1", "tx2"},
+		},
+		"other channels and expired proofs not returned": {
+			queued: []server.ProofQueueCreate{
+				queueProof("def456", "tx1", hour),
+				queueProof("abc123", "tx2", time.Now().Add(-time.Second)),
+				queueProof("abc123", "tx3", hour),
+			},
+			channelID: "abc123",
+			expTxIDs:  []string{"tx3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := sqlite.NewProofQueue(newDB(t))
+			for _, p := range test.queued {
+				assert.NoError(t, q.ProofQueueCreate(context.TODO(), p))
+			}
+
+			proofs, err := q.ProofQueue(context.TODO(), server.ProofQueueArgs{ChannelID: test.channelID})
+			assert.NoError(t, err)
+			txIDs := make([]string, 0)
+			for _, p := range proofs {
+				assert.Equal(t, test.channelID, p.Args.PaymentReference)
+				assert.Equal(t, `{"txOrId":"`+p.Args.TxID+`"}`, p.Envelope.Payload)
+				assert.Equal(t, hour.Unix(), p.ExpiresAt.Unix())
+				assert.WithinDuration(t, time.Now(), p.CreatedAt, time.Minute)
+				txIDs = append(txIDs, p.Args.TxID)
+			}
+			assert.Equal(t, test.expTxIDs, txIDs)
+		})
+	}
+}
+
+func TestProofQueue_ProofQueueDelete(t *testing.T) {
+	q := sqlite.NewProofQueue(newDB(t))
+	args := server.ProofQueueArgs{ChannelID: "abc123"}
+	assert.NoError(t, q.ProofQueueCreate(context.TODO(), queueProof("abc123", "tx1", time.Now().Add(time.Hour))))
+	assert.NoError(t, q.ProofQueueCreate(context.TODO(), queueProof("abc123", "tx2", time.Now().Add(time.Hour))))
+
+	proofs, err := q.ProofQueue(context.TODO(), args)
+	assert.NoError(t, err)
+	deleted, err := q.ProofQueueDelete(context.TODO(), proofs[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = q.ProofQueueDelete(context.TODO(), proofs[0].ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	proofs, err = q.ProofQueue(context.TODO(), args)
+	assert.NoError(t, err)
+	assert.Len(t, proofs, 1)
+	assert.Equal(t, "tx2", proofs[0].Args.TxID)
+}
+
+func TestProofQueue_ProofQueuePrune(t *testing.T) {
+	q := sqlite.NewProofQueue(newDB(t))
+	assert.NoError(t, q.ProofQueueCreate(context.TODO(), queueProof("abc123", "tx1", time.Now().Add(-time.Second))))
+	assert.NoError(t, q.ProofQueueCreate(context.TODO(), queueProof("def456", "tx2", time.Now().Add(-time.Minute))))
+	assert.NoError(t, q.ProofQueueCreate(context.TODO(), queueProof("abc123", "tx3", time.Now().Add(time.Hour))))
+
+	n, err := q.ProofQueueCount(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = q.ProofQueuePrune(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = q.ProofQueuePrune(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = q.ProofQueueCount(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	// Pure go sqlite driver so we can still build with CGO_ENABLED=0.
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+const (
+	sqlMigrationsCreate = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		name        TEXT PRIMARY KEY,
+		applied_at  DATETIME NOT NULL
+	)
+	`
+
+	sqlMigrationApplied = `
+	SELECT COUNT(*) FROM schema_migrations WHERE name = ?
+	`
+
+	sqlMigrationInsert = `
+	INSERT INTO schema_migrations(name, applied_at)
+	VALUES(?, ?)
+	`
+)
+
+// NewSQLite will open a sqlite database using the dsn provided and
+// ensure the schema is up to date before returning it.
+func NewSQLite(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open sqlite database")
+	}
+	// sqlite only supports a single writer.
+	db.SetMaxOpenConns(1)
+	if err := migrate(context.Background(), db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrate runs each embedded migration file not yet applied in name order,
+// applied migrations are recorded in the schema_migrations table.
+//
+// Databases created before migrations were recorded rerun them once, the
+// first migrations are idempotent so this is safe.
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlMigrationsCreate); err != nil {
+		return errors.Wrap(err, "failed to create migrations table")
+	}
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return errors.Wrap(err, "failed to list migrations")
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := migrateFile(ctx, db, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateFile runs and records the migration in a transaction if it hasn't
+// been applied.
+func migrateFile(ctx context.Context, db *sql.DB, f string) error {
+	name := path.Base(f)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin migration %s", name)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	var n int
+	if err := tx.QueryRowContext(ctx, sqlMigrationApplied, name).Scan(&n); err != nil {
+		return errors.Wrapf(err, "failed to check migration %s", name)
+	}
+	if n > 0 {
+		return nil
+	}
+	bb, err := migrations.ReadFile(f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read migration %s", name)
+	}
+	if _, err := tx.ExecContext(ctx, string(bb)); err != nil {
+		return errors.Wrapf(err, "failed to run migration %s", name)
+	}
+	if _, err := tx.ExecContext(ctx, sqlMigrationInsert, name, time.Now().UTC()); err != nil {
+		return errors.Wrapf(err, "failed to record migration %s", name)
+	}
+	return errors.Wrapf(tx.Commit(), "failed to commit migration %s", name)
+}
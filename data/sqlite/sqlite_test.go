@@ -0,0 +1,47 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/sqlite"
+)
+
+func TestNewSQLite_Migrations(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "dpp.db")
+
+	db, err := sqlite.NewSQLite(dsn)
+	assert.NoError(t, err)
+	assert.NoError(t, sqlite.NewLedger(db).LedgerEntryCreate(context.TODO(), server.LedgerEntryCreate{
+		PaymentID: "abc123",
+		Event:     server.LedgerEventPayment,
+	}))
+	assert.NoError(t, db.Close())
+
+	// reopening applies nothing and keeps the data.
+	db, err = sqlite.NewSQLite(dsn)
+	assert.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+	rows, err := db.Query("SELECT name FROM schema_migrations ORDER BY name")
+	assert.NoError(t, err)
+	defer func() {
+		_ = rows.Close()
+	}()
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"0001_ledger.sql", "0002_proof_queue.sql"}, names)
+
+	entries, err := sqlite.NewLedger(db).LedgerEntries(context.TODO(), server.LedgerArgs{PaymentID: "abc123"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
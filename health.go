@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// HealthChecker checks a dependency the server needs to serve requests.
+type HealthChecker interface {
+	// HealthCheck returns an error if the dependency can't be used.
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheckFunc allows a func to be used as a HealthChecker.
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthCheck calls f(ctx).
+func (f HealthCheckFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+// SocketStats are counts of the websockets held by a node.
+type SocketStats struct {
+	Channels    int `json:"channels"`
+	Connections int `json:"connections"`
+}
+
+// SocketStatsReader returns the counts of the websockets held by a node.
+type SocketStatsReader interface {
+	SocketStats() SocketStats
+}
+
+// Readiness is whether the server can serve requests, with the result of
+// each dependency check.
+type Readiness struct {
+	Ready bool `json:"ready"`
+	// Checks maps each dependency to ok or the reason it failed.
+	Checks map[string]string `json:"checks"`
+	// Sockets is only set in socket and hybrid transport modes.
+	Sockets *SocketStats `json:"sockets,omitempty"`
+}
+
+// Version describes the build and deployment of the server.
+type Version struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	BuildDate time.Time `json:"buildDate"`
+	Region    string    `json:"region"`
+}
+
+// HealthService returns the readiness and version of the server.
+type HealthService interface {
+	// Readiness runs every dependency check, the server is ready if all pass.
+	Readiness(ctx context.Context) Readiness
+	Version(ctx context.Context) Version
+}
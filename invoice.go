@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// InvoiceState is where a paymentID stands in the payment lifecycle.
+type InvoiceState string
+
+// Invoice states a paymentID moves through.
+const (
+	InvoiceStateTermsRequested   InvoiceState = "terms-requested"
+	InvoiceStateTermsServed      InvoiceState = "terms-served"
+	InvoiceStatePaymentSubmitted InvoiceState = "payment-submitted"
+	InvoiceStateAcked            InvoiceState = "acked"
+	InvoiceStateRejected         InvoiceState = "rejected"
+	InvoiceStateProofReceived    InvoiceState = "proof-received"
+	InvoiceStateExpired          InvoiceState = "expired"
+)
+
+// invoiceTransitions lists the states each state can move to, the empty state
+// is a paymentID not seen before.
+//
+// Payments and proofs are accepted for unseen paymentIDs as the terms may have
+// been served by another node, or before a restart. Payers can request terms
+// again at any point, and proofs are sent by the broadcaster once a payment is
+// mined so are accepted in every state.
+var invoiceTransitions = map[InvoiceState][]InvoiceState{
+	"": {InvoiceStateTermsRequested, InvoiceStatePaymentSubmitted, InvoiceStateProofReceived},
+	InvoiceStateTermsRequested: {InvoiceStateTermsRequested, InvoiceStateTermsServed, InvoiceStatePaymentSubmitted,
+		InvoiceStateProofReceived},
+	InvoiceStateTermsServed: {InvoiceStateTermsRequested, InvoiceStatePaymentSubmitted, InvoiceStateProofReceived,
+		InvoiceStateExpired},
+	InvoiceStatePaymentSubmitted: {InvoiceStateTermsRequested, InvoiceStateAcked, InvoiceStateRejected,
+		InvoiceStateProofReceived},
+	InvoiceStateAcked: {InvoiceStateTermsRequested, InvoiceStateProofReceived},
+	InvoiceStateRejected: {InvoiceStateTermsRequested, InvoiceStatePaymentSubmitted, InvoiceStateProofReceived,
+		InvoiceStateExpired},
+	InvoiceStateProofReceived: {InvoiceStateTermsRequested, InvoiceStateProofReceived},
+	InvoiceStateExpired:       {InvoiceStateTermsRequested, InvoiceStateProofReceived},
+}
+
+// CanMoveTo returns true if an invoice in state s can move to state to.
+func (s InvoiceState) CanMoveTo(to InvoiceState) bool {
+	for _, t := range invoiceTransitions[s] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InvoiceTransition is a single change of state of a paymentID.
+type InvoiceTransition struct {
+	State     InvoiceState `json:"state"`
+	Detail    string       `json:"detail,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// InvoiceStatus is the current state of a paymentID and the transitions that
+// led to it, oldest first.
+type InvoiceStatus struct {
+	PaymentID string              `json:"paymentId"`
+	State     InvoiceState        `json:"state"`
+	ExpiresAt *time.Time          `json:"expiresAt,omitempty"`
+	History   []InvoiceTransition `json:"history"`
+}
+
+// InvoiceArgs are used to identify an invoice.
+type InvoiceArgs struct {
+	PaymentID string `param:"paymentID"`
+}
+
+// InvoiceStateUpdate is used to move an invoice to a new state.
+type InvoiceStateUpdate struct {
+	State  InvoiceState
+	Detail string
+	// ExpiresAt if set is when served terms expire, an invoice that isn't
+	// paid by then moves to expired.
+	ExpiresAt time.Time
+}
+
+// InvoiceStatusReader returns the status of invoices.
+type InvoiceStatusReader interface {
+	// InvoiceStatus returns the state and history of a paymentID, a NotFound
+	// error is returned if the paymentID hasn't been seen.
+	InvoiceStatus(ctx context.Context, args InvoiceArgs) (*InvoiceStatus, error)
+}
+
+// InvoiceStateWriter moves invoices between states.
+type InvoiceStateWriter interface {
+	// InvoiceStateUpdate will move the paymentID to a new state, a Duplicate
+	// error is returned if the new state can't be reached from the current one.
+	InvoiceStateUpdate(ctx context.Context, args InvoiceArgs, req InvoiceStateUpdate) error
+}
+
+// InvoiceStateReaderWriter combines the reader and writer interfaces.
+type InvoiceStateReaderWriter interface {
+	InvoiceStatusReader
+	InvoiceStateWriter
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// LedgerEvent identifies the type of action recorded against a paymentID.
+type LedgerEvent string
+
+// Ledger events written as a payment moves through the proxy.
+const (
+	LedgerEventPaymentTerms LedgerEvent = "paymentterms"
+	LedgerEventPayment      LedgerEvent = "payment"
+	LedgerEventPaymentACK   LedgerEvent = "payment.ack"
+	LedgerEventPaymentError LedgerEvent = "payment.error"
+	LedgerEventProof        LedgerEvent = "proof"
+)
+
+// LedgerEntry is a single timestamped record of something the proxy
+// served, received or returned for a paymentID.
+//
+// TxID is set on proofs and on payment events, where it holds the comma
+// separated ids of the transactions paid.
+type LedgerEntry struct {
+	ID        int64           `json:"id"`
+	PaymentID string          `json:"paymentId"`
+	TxID      string          `json:"txId,omitempty"`
+	Event     LedgerEvent     `json:"event"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// LedgerEntryCreate is used to add a new entry to the ledger.
+type LedgerEntryCreate struct {
+	PaymentID string
+	TxID      string
+	Event     LedgerEvent
+	Data      interface{}
+}
+
+// LedgerArgs are used to identify the ledger entries for a payment.
+type LedgerArgs struct {
+	PaymentID string `param:"paymentID"`
+}
+
+// LedgerWriter will add entries to a payment ledger.
+type LedgerWriter interface {
+	// LedgerEntryCreate will record a new ledger entry.
+	LedgerEntryCreate(ctx context.Context, req LedgerEntryCreate) error
+}
+
+// LedgerReader will return entries from a payment ledger.
+type LedgerReader interface {
+	// LedgerEntries returns all entries for a paymentID, oldest first.
+	LedgerEntries(ctx context.Context, args LedgerArgs) ([]LedgerEntry, error)
+}
+
+// LedgerReaderWriter combines the reader and writer interfaces.
+type LedgerReaderWriter interface {
+	LedgerReader
+	LedgerWriter
+}
@@ -1,3 +1,10 @@
 package mocks
 
 //go:generate moq -pkg mocks -out http_client.go ../data HTTPClient
+//go:generate moq -pkg mocks -out payment_terms_cache.go ../ PaymentTermsCacheWriter PaymentTermsCacheService
+//go:generate moq -pkg mocks -out payment_result.go ../ PaymentResultReaderWriter
+//go:generate moq -pkg mocks -out proof_queue.go ../ ProofQueueReaderWriter
+//go:generate moq -pkg mocks -out payment_store.go ../ PaymentStore
+//go:generate moq -pkg mocks -out served_terms.go ../ ServedTermsReader
+//go:generate moq -pkg mocks -out block_headers.go ../ BlockHeaderReader
+//go:generate moq -pkg mocks -out socket_admin.go ../ SocketAdminReaderWriter
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+
+	"github.com/libsv/go-dpp"
+)
+
+type ctxKey int
+
+const (
+	ctxKeyIdempotency ctxKey = iota
+	ctxKeyBroadcaster
+)
+
+// WithIdempotencyKey will add a payer supplied idempotency key to the context.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxKeyIdempotency, key)
+}
+
+// IdempotencyKey returns the idempotency key stored in the context, or an
+// empty string if none was set.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(ctxKeyIdempotency).(string)
+	return key
+}
+
+// PaymentResult is the outcome of forwarding a Payment to a payee, stored so
+// a repeated submission can be answered without contacting the payee again.
+type PaymentResult struct {
+	// Fingerprint identifies the Payment this result was created for.
+	Fingerprint string
+	ACK         *dpp.PaymentACK
+	Err         error
+}
+
+// PaymentResultReaderWriter stores PaymentResults against an idempotency key.
+type PaymentResultReaderWriter interface {
+	// PaymentResult returns the result stored for the key, nil is returned if
+	// there is no result.
+	PaymentResult(ctx context.Context, key string) (*PaymentResult, error)
+	// PaymentResultSet will store a result against the key.
+	PaymentResultSet(ctx context.Context, key string, req PaymentResult) error
+}
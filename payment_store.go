@@ -0,0 +1,11 @@
+package server
+
+import "github.com/libsv/go-dpp"
+
+// PaymentStore combines the data layer interfaces used to relay
+// PaymentTerms, Payments and proofs between payers and a payee wallet.
+type PaymentStore interface {
+	dpp.PaymentTermsReader
+	dpp.PaymentWriter
+	dpp.ProofsWriter
+}
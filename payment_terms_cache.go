@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+)
+
+// PaymentTermsCacheService is used by a payee wallet to push new PaymentTerms
+// to the proxy or to invalidate the ones currently cached.
+type PaymentTermsCacheService interface {
+	// PaymentTermsPush will validate and cache the signed PaymentTerms for a paymentID.
+	PaymentTermsPush(ctx context.Context, args dpp.PaymentTermsArgs, req envelope.JSONEnvelope) error
+	// PaymentTermsInvalidate will remove any cached PaymentTerms for a paymentID.
+	PaymentTermsInvalidate(ctx context.Context, args dpp.PaymentTermsArgs) error
+}
+
+// PaymentTermsCacheWriter will add or remove cached PaymentTerms.
+type PaymentTermsCacheWriter interface {
+	// PaymentTermsSet will cache the envelope until the PaymentTerms expire.
+	PaymentTermsSet(ctx context.Context, args dpp.PaymentTermsArgs, req envelope.JSONEnvelope) error
+	// PaymentTermsDelete will remove the cached PaymentTerms for a paymentID.
+	PaymentTermsDelete(ctx context.Context, args dpp.PaymentTermsArgs) error
+}
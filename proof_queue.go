@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+)
+
+// QueuedProof is a merkle proof waiting to be delivered to a payee wallet.
+type QueuedProof struct {
+	ID        int64
+	Args      dpp.ProofCreateArgs
+	Envelope  envelope.JSONEnvelope
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ProofQueueCreate is used to add a proof to the queue.
+type ProofQueueCreate struct {
+	Args      dpp.ProofCreateArgs
+	Envelope  envelope.JSONEnvelope
+	ExpiresAt time.Time
+}
+
+// ProofQueueArgs identify the queue for a channel, the channel is
+// the paymentReference the proof was sent for.
+type ProofQueueArgs struct {
+	ChannelID string
+}
+
+// ProofQueueReaderWriter stores proofs until a payee wallet is available to receive them.
+type ProofQueueReaderWriter interface {
+	// ProofQueueCreate will add a proof to the queue.
+	ProofQueueCreate(ctx context.Context, req ProofQueueCreate) error
+	// ProofQueue returns the unexpired proofs queued for a channel, oldest first.
+	ProofQueue(ctx context.Context, args ProofQueueArgs) ([]QueuedProof, error)
+	// ProofQueueDelete will remove a proof, false is returned if it had
+	// already been removed so each proof is only claimed for delivery once.
+	ProofQueueDelete(ctx context.Context, id int64) (bool, error)
+	// ProofQueuePrune will remove expired proofs and return the number removed.
+	ProofQueuePrune(ctx context.Context) (int64, error)
+	// ProofQueueCount returns the number of undelivered, unexpired proofs.
+	ProofQueueCount(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,43 @@
+package server
+
+import "context"
+
+// ProofSourceArgs are the credentials a proof callback is sent with.
+type ProofSourceArgs struct {
+	// APIKey is a broadcaster API key, sent in the X-API-Key header.
+	APIKey string
+	// Signature is the hex HMAC-SHA256 of the signed request, sent in the
+	// X-Signature header.
+	Signature string
+	// Timestamp is the unix time the request was signed at, sent in the
+	// X-Timestamp header.
+	Timestamp string
+	Method    string
+	// URI is the path and query of the request.
+	URI  string
+	Body []byte
+	// IP is the address the request came from.
+	IP string
+}
+
+// ProofSourceAuthenticator checks proof callbacks come from a known broadcaster.
+type ProofSourceAuthenticator interface {
+	// ProofSourceAuthenticate returns the identity of the broadcaster, a
+	// NotAuthenticated error is returned if the credentials are missing or
+	// invalid and a NotAuthorised error if the request came from an address
+	// that isn't allowed.
+	ProofSourceAuthenticate(ctx context.Context, args ProofSourceArgs) (string, error)
+}
+
+// WithBroadcaster will add the identity of the broadcaster a proof was sent by
+// to the context.
+func WithBroadcaster(ctx context.Context, broadcaster string) context.Context {
+	return context.WithValue(ctx, ctxKeyBroadcaster, broadcaster)
+}
+
+// Broadcaster returns the broadcaster identity stored in the context, or an
+// empty string if the proof wasn't authenticated.
+func Broadcaster(ctx context.Context) string {
+	b, _ := ctx.Value(ctxKeyBroadcaster).(string)
+	return b
+}
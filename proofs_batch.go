@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+)
+
+// ProofBatchItem is the proof for a single transaction sent in a batch.
+type ProofBatchItem struct {
+	Args  dpp.ProofCreateArgs
+	Proof envelope.JSONEnvelope
+}
+
+// ProofsBatchService validates and stores many proofs at once, such as the
+// proofs for every payment transaction in a newly mined block.
+type ProofsBatchService interface {
+	// ProofsCreate will validate and store each proof, an error is returned for
+	// each item in the same order, nil if the proof was stored.
+	ProofsCreate(ctx context.Context, items []ProofBatchItem) []error
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+
+	"github.com/libsv/go-dpp"
+)
+
+// ServedTermsReader returns the PaymentTerms served to payers, used to check
+// a Payment pays the outputs it was quoted.
+type ServedTermsReader interface {
+	// ServedTerms returns the unexpired PaymentTerms last served for the paymentID,
+	// nil is returned if none have been served by this proxy.
+	ServedTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*dpp.PaymentTerms, error)
+}
+
+// ServedTermsWriter keeps the PaymentTerms served to payers.
+type ServedTermsWriter interface {
+	// ServedTermsCreate will keep the PaymentTerms served for the paymentID
+	// until they expire, they must already have been verified.
+	ServedTermsCreate(ctx context.Context, args dpp.PaymentTermsArgs, terms dpp.PaymentTerms) error
+}
+
+// ServedTermsReaderWriter combines the reader and writer interfaces.
+type ServedTermsReaderWriter interface {
+	ServedTermsReader
+	ServedTermsWriter
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// anyMerchant is the merchantID whose API key can open any channel.
+const anyMerchant = "*"
+
+// channelAuth authenticates websocket connections with merchant API keys or
+// HMAC tokens bound to a channel.
+type channelAuth struct {
+	cfg *config.Auth
+}
+
+// NewChannelAuth will setup and return a new channel authenticator.
+func NewChannelAuth(cfg *config.Auth) *channelAuth {
+	return &channelAuth{cfg: cfg}
+}
+
+// ChannelAuthenticate returns the role the credentials grant on the channel.
+//
+// A merchant API key grants the wallet role on channels for the merchant's
+// paymentIDs, of the form merchantID.invoiceID, the key of merchant * can open
+// any channel. A token grants the role it was signed for.
+func (a *channelAuth) ChannelAuthenticate(ctx context.Context, args server.ChannelAuthArgs) (server.ChannelRole, error) {
+	switch {
+	case args.APIKey != "":
+		merchantID, ok := a.merchant(args.APIKey)
+		if !ok {
+			return "", client_errors.NewErrNotAuthenticated("401", "api key is invalid")
+		}
+		if merchantID != anyMerchant && !strings.HasPrefix(args.ChannelID, merchantID+".") {
+			return "", client_errors.NewErrNotAuthorisedf("403", "api key can't open channel %s", args.ChannelID)
+		}
+		return server.ChannelRoleWallet, nil
+	case args.Token != "":
+		return a.verifyToken(args.ChannelID, args.Token)
+	}
+	return "", client_errors.NewErrNotAuthenticated("401", "an api key or channel token is required")
+}
+
+// merchant returns the merchantID the API key belongs to, every key is
+// compared so the time taken doesn't reveal a match.
+func (a *channelAuth) merchant(apiKey string) (string, bool) {
+	var merchantID string
+	found := false
+	for id, key := range a.cfg.WalletKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			merchantID, found = id, true
+		}
+	}
+	return merchantID, found
+}
+
+// verifyToken checks the token was signed for the channel and hasn't expired,
+// returning the role it grants.
+func (a *channelAuth) verifyToken(channelID, token string) (server.ChannelRole, error) {
+	parts := strings.Split(token, ".")
+	if a.cfg.ChannelSecret == "" || len(parts) != 3 {
+		return "", client_errors.NewErrNotAuthenticated("401", "channel token is invalid")
+	}
+	role, expiry, mac := server.ChannelRole(parts[0]), parts[1], parts[2]
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || (role != server.ChannelRoleWallet && role != server.ChannelRolePayer) {
+		return "", client_errors.NewErrNotAuthenticated("401", "channel token is invalid")
+	}
+	if !hmac.Equal([]byte(channelMAC(a.cfg.ChannelSecret, role, channelID, expires)), []byte(mac)) {
+		return "", client_errors.NewErrNotAuthenticated("401", "channel token is invalid")
+	}
+	if time.Now().Unix() >= expires {
+		return "", client_errors.NewErrNotAuthenticated("401", "channel token has expired")
+	}
+	return role, nil
+}
+
+// ChannelToken returns a token granting role on the channel until expires.
+//
+// The token is role.expires.hmac where expires is a unix timestamp and hmac is
+// the hex HMAC-SHA256 of role:channelID:expires keyed with secret.
+func ChannelToken(secret string, role server.ChannelRole, channelID string, expires time.Time) string {
+	return fmt.Sprintf("%s.%d.%s", role, expires.Unix(), channelMAC(secret, role, channelID, expires.Unix()))
+}
+
+// channelMAC returns the hex HMAC signing a channel token.
+func channelMAC(secret string, role server.ChannelRole, channelID string, expires int64) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(h, "%s:%s:%d", role, channelID, expires)
+	return hex.EncodeToString(h.Sum(nil))
+}
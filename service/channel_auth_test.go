@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/lathos"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+)
+
+func TestChannelAuth_ChannelAuthenticate(t *testing.T) {
+	const secret = "sssh"
+	hour := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		args     server.ChannelAuthArgs
+		secret   string
+		expRole  server.ChannelRole
+		expErr   error
+		expAuthN bool
+	}{
+		"merchant key opens its channels": {
+			args:    server.ChannelAuthArgs{ChannelID: "acme.abc123", APIKey: "acme-key"},
+			expRole: server.ChannelRoleWallet,
+		},
+		"any merchant key opens any channel": {
+			args:    server.ChannelAuthArgs{ChannelID: "abc123", APIKey: "root-key"},
+			expRole: server.ChannelRoleWallet,
+		},
+		"merchant key can't open another merchant's channel": {
+			args:   server.ChannelAuthArgs{ChannelID: "globex.abc123", APIKey: "acme-key"},
+			expErr: errors.New("Permission Denied: api key can't open channel globex.abc123"),
+		},
+		"merchant key can't open unprefixed channel": {
+			args:   server.ChannelAuthArgs{ChannelID: "acmeabc123", APIKey: "acme-key"},
+			expErr: errors.New("Permission Denied: api key can't open channel acmeabc123"),
+		},
+		"unknown key rejected": {
+			args:     server.ChannelAuthArgs{ChannelID: "abc123", APIKey: "nope"},
+			expErr:   errors.New("Not Authenticated: api key is invalid"),
+			expAuthN: true,
+		},
+		"wallet token opens channel": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     service.ChannelToken(secret, server.ChannelRoleWallet, "abc123", hour),
+			},
+			secret:  secret,
+			expRole: server.ChannelRoleWallet,
+		},
+		"payer token joins channel": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     service.ChannelToken(secret, server.ChannelRolePayer, "abc123", hour),
+			},
+			secret:  secret,
+			expRole: server.ChannelRolePayer,
+		},
+		"token for another channel rejected": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "def456",
+				Token:     service.ChannelToken(secret, server.ChannelRoleWallet, "abc123", hour),
+			},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: channel token is invalid"),
+			expAuthN: true,
+		},
+		"token with changed role rejected": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     "wallet" + service.ChannelToken(secret, server.ChannelRolePayer, "abc123", hour)[len("payer"):],
+			},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: channel token is invalid"),
+			expAuthN: true,
+		},
+		"token signed with another secret rejected": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     service.ChannelToken("other", server.ChannelRoleWallet, "abc123", hour),
+			},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: channel token is invalid"),
+			expAuthN: true,
+		},
+		"expired token rejected": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     service.ChannelToken(secret, server.ChannelRoleWallet, "abc123", time.Now().Add(-time.Minute)),
+			},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: channel token has expired"),
+			expAuthN: true,
+		},
+		"token rejected without a secret": {
+			args: server.ChannelAuthArgs{
+				ChannelID: "abc123",
+				Token:     service.ChannelToken("", server.ChannelRoleWallet, "abc123", hour),
+			},
+			expErr:   errors.New("Not Authenticated: channel token is invalid"),
+			expAuthN: true,
+		},
+		"malformed token rejected": {
+			args:     server.ChannelAuthArgs{ChannelID: "abc123", Token: "wallet.abc"},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: channel token is invalid"),
+			expAuthN: true,
+		},
+		"no credentials rejected": {
+			args:     server.ChannelAuthArgs{ChannelID: "abc123"},
+			secret:   secret,
+			expErr:   errors.New("Not Authenticated: an api key or channel token is required"),
+			expAuthN: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := service.NewChannelAuth(&config.Auth{
+				Enabled:       true,
+				WalletKeys:    map[string]string{"acme": "acme-key", "*": "root-key"},
+				ChannelSecret: test.secret,
+			})
+			role, err := svc.ChannelAuthenticate(context.TODO(), test.args)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				assert.Equal(t, test.expAuthN, lathos.IsNotAuthenticated(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expRole, role)
+		})
+	}
+}
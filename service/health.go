@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+)
+
+// healthCheckTimeout is how long each dependency check can take before it fails.
+const healthCheckTimeout = 2 * time.Second
+
+// health reports whether the server is ready and which build it is.
+type health struct {
+	deployment *config.Deployment
+	checks     map[string]server.HealthChecker
+	stats      server.SocketStatsReader
+}
+
+// NewHealth will setup and return a new health service.
+//
+// The server is ready when every check passes, stats can be nil if the server
+// holds no websockets.
+func NewHealth(deployment *config.Deployment, checks map[string]server.HealthChecker,
+	stats server.SocketStatsReader) *health {
+	return &health{
+		deployment: deployment,
+		checks:     checks,
+		stats:      stats,
+	}
+}
+
+// Readiness will run each check at once, returning ok or the error it failed with.
+func (h *health) Readiness(ctx context.Context) server.Readiness {
+	r := server.Readiness{
+		Ready:  true,
+		Checks: make(map[string]string, len(h.checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check server.HealthChecker) {
+			defer wg.Done()
+			err := runCheck(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				r.Ready = false
+				r.Checks[name] = err.Error()
+				return
+			}
+			r.Checks[name] = "ok"
+		}(name, check)
+	}
+	wg.Wait()
+	if h.stats != nil {
+		stats := h.stats.SocketStats()
+		r.Sockets = &stats
+	}
+	return r
+}
+
+// runCheck will run the check, failing if it takes longer than healthCheckTimeout
+// even if the check doesn't stop when its context is done.
+func runCheck(ctx context.Context, check server.HealthChecker) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- check.HealthCheck(ctx)
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check timed out")
+	}
+}
+
+// Version returns the build and deployment the server is running.
+func (h *health) Version(ctx context.Context) server.Version {
+	return server.Version{
+		Version:   h.deployment.Version,
+		Commit:    h.deployment.Commit,
+		BuildDate: h.deployment.BuildDate,
+		Region:    h.deployment.Region,
+	}
+}
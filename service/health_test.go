@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+)
+
+type socketStats server.SocketStats
+
+func (s socketStats) SocketStats() server.SocketStats {
+	return server.SocketStats(s)
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	ok := server.HealthCheckFunc(func(ctx context.Context) error {
+		return nil
+	})
+	tests := map[string]struct {
+		checks map[string]server.HealthChecker
+		stats  server.SocketStatsReader
+		exp    server.Readiness
+	}{
+		"all checks passing should be ready": {
+			checks: map[string]server.HealthChecker{
+				"db":       ok,
+				"shutdown": ok,
+			},
+			exp: server.Readiness{
+				Ready: true,
+				Checks: map[string]string{
+					"db":       "ok",
+					"shutdown": "ok",
+				},
+			},
+		},
+		"no checks should be ready": {
+			exp: server.Readiness{
+				Ready:  true,
+				Checks: map[string]string{},
+			},
+		},
+		"failing check should not be ready": {
+			checks: map[string]server.HealthChecker{
+				"db": ok,
+				"shutdown": server.HealthCheckFunc(func(ctx context.Context) error {
+					return errors.New("server is shutting down")
+				}),
+			},
+			exp: server.Readiness{
+				Checks: map[string]string{
+					"db":       "ok",
+					"shutdown": "server is shutting down",
+				},
+			},
+		},
+		"check ignoring its deadline should time out": {
+			checks: map[string]server.HealthChecker{
+				"db": server.HealthCheckFunc(func(ctx context.Context) error {
+					time.Sleep(3 * time.Second)
+					return nil
+				}),
+			},
+			exp: server.Readiness{
+				Checks: map[string]string{
+					"db": "check timed out: context deadline exceeded",
+				},
+			},
+		},
+		"socket stats should be reported": {
+			checks: map[string]server.HealthChecker{
+				"sockets": ok,
+			},
+			stats: socketStats{Channels: 2, Connections: 3},
+			exp: server.Readiness{
+				Ready: true,
+				Checks: map[string]string{
+					"sockets": "ok",
+				},
+				Sockets: &server.SocketStats{Channels: 2, Connections: 3},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := service.NewHealth(&config.Deployment{}, test.checks, test.stats)
+			assert.Equal(t, test.exp, svc.Readiness(context.Background()))
+		})
+	}
+}
+
+func TestHealth_Version(t *testing.T) {
+	built := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := service.NewHealth(&config.Deployment{
+		Environment: "prod",
+		Region:      "eu-west-1",
+		Version:     "v1.2.3",
+		Commit:      "abc123",
+		BuildDate:   built,
+	}, nil, nil)
+	assert.Equal(t, server.Version{
+		Version:   "v1.2.3",
+		Commit:    "abc123",
+		BuildDate: built,
+		Region:    "eu-west-1",
+	}, svc.Version(context.Background()))
+}
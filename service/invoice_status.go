@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// invoiceStatus returns where an invoice stands in the payment lifecycle.
+type invoiceStatus struct {
+	rdr server.InvoiceStatusReader
+}
+
+// NewInvoiceStatus will setup and return a new invoice status service.
+func NewInvoiceStatus(rdr server.InvoiceStatusReader) *invoiceStatus {
+	return &invoiceStatus{rdr: rdr}
+}
+
+// InvoiceStatus will validate the args and return the state and history of the invoice.
+func (i *invoiceStatus) InvoiceStatus(ctx context.Context, args server.InvoiceArgs) (*server.InvoiceStatus, error) {
+	if err := validator.New().
+		Validate("paymentID", validator.NotEmpty(args.PaymentID)).Err(); err != nil {
+		return nil, err
+	}
+	status, err := i.rdr.InvoiceStatus(ctx, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read status of invoice %s", args.PaymentID)
+	}
+	return status, nil
+}
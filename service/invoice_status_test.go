@@ -0,0 +1,253 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	dppMocks "github.com/libsv/go-dpp/mocks"
+	"github.com/libsv/go-dpp/modes/hybridmode"
+	"github.com/libsv/go-dpp/nativetypes"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data/cache"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+func TestInvoiceStatus_Lifecycle(t *testing.T) {
+	const txID = "3e6f4e7f5a6b2b0c8d9e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e"
+	type step struct {
+		action    string
+		walletErr error
+		expErr    error
+	}
+	terms, err := envelope.NewJSONEnvelope(&dpp.PaymentTerms{
+		Network:             "regtest",
+		Version:             "1.0",
+		CreationTimestamp:   time.Now().Unix(),
+		ExpirationTimestamp: time.Now().Add(time.Hour).Unix(),
+		Outputs:             []nativetypes.NativeOutput{{Amount: 1000}},
+	})
+	assert.NoError(t, err)
+	proof, err := envelope.NewJSONEnvelope(&dpp.ProofWrapper{
+		CallbackPayload: &bc.MerkleProof{TxOrID: txID, Target: "abc", TargetType: "hash"},
+		BlockHash:       "abc",
+		CallbackTxID:    txID,
+		CallbackReason:  "merkleProof",
+	})
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		steps      []step
+		expState   server.InvoiceState
+		expSteps   []server.InvoiceState
+		expDetails []string
+	}{
+		"invoice paid and proven": {
+			steps: []step{
+				{action: "terms"},
+				{action: "pay"},
+				{action: "proof"},
+				{action: "proof"},
+			},
+			expState: server.InvoiceStateProofReceived,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateAcked, server.InvoiceStateProofReceived, server.InvoiceStateProofReceived,
+			},
+			expDetails: []string{"", "", "", "", txID, txID},
+		},
+		"paying twice after an ack rejected": {
+			steps: []step{
+				{action: "terms"},
+				{action: "pay"},
+				{action: "pay", expErr: errors.New("Conflict: invoice abc123 is acked and can't be moved to payment-submitted")},
+			},
+			expState: server.InvoiceStateAcked,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateAcked,
+			},
+			expDetails: []string{"", "", "", ""},
+		},
+		"terms requested again after payment and proof": {
+			steps: []step{
+				{action: "terms"},
+				{action: "pay"},
+				{action: "terms"},
+				{action: "proof"},
+				{action: "terms"},
+			},
+			expState: server.InvoiceStateTermsServed,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateAcked, server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed,
+				server.InvoiceStateProofReceived, server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed,
+			},
+			expDetails: []string{"", "", "", "", "", "", txID, "", ""},
+		},
+		"rejected payment can be retried": {
+			steps: []step{
+				{action: "terms"},
+				{
+					action:    "pay",
+					walletErr: client_errors.NewErrUnprocessable("422", "nope"),
+					expErr:    errors.New("Unprocessable Entity: nope"),
+				},
+				{action: "pay"},
+			},
+			expState: server.InvoiceStateAcked,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateRejected, server.InvoiceStatePaymentSubmitted, server.InvoiceStateAcked,
+			},
+			expDetails: []string{"", "", "", "nope", "", ""},
+		},
+		"internal wallet error not detailed": {
+			steps: []step{
+				{action: "terms"},
+				{
+					action:    "pay",
+					walletErr: errors.New("dial tcp 10.0.0.1:8443: connection refused"),
+					expErr:    errors.New("dial tcp 10.0.0.1:8443: connection refused"),
+				},
+			},
+			expState: server.InvoiceStateRejected,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateRejected,
+			},
+			expDetails: []string{"", "", "", "the payment could not be processed by the payee"},
+		},
+		"proof not recorded if it can't be stored": {
+			steps: []step{
+				{action: "terms"},
+				{action: "pay"},
+				{action: "bad proof", expErr: errors.New("failed to add proof with txid '" + txID + "' and invoiceID 'abc123': store down")},
+			},
+			expState: server.InvoiceStateAcked,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStatePaymentSubmitted,
+				server.InvoiceStateAcked,
+			},
+			expDetails: []string{"", "", "", ""},
+		},
+		"expired invoice can't be paid": {
+			steps: []step{
+				{action: "terms"},
+				{action: "expire"},
+				{action: "pay", expErr: errors.New("Conflict: invoice abc123 is expired and can't be moved to payment-submitted")},
+				{action: "terms"},
+			},
+			expState: server.InvoiceStateTermsServed,
+			expSteps: []server.InvoiceState{
+				server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed, server.InvoiceStateTermsRequested,
+				server.InvoiceStateTermsServed, server.InvoiceStateExpired, server.InvoiceStateTermsRequested,
+				server.InvoiceStateTermsServed,
+			},
+			expDetails: []string{"", "", "", "", "", "", ""},
+		},
+		"payment for unseen invoice accepted": {
+			steps: []step{
+				{action: "pay"},
+			},
+			expState:   server.InvoiceStateAcked,
+			expSteps:   []server.InvoiceState{server.InvoiceStatePaymentSubmitted, server.InvoiceStateAcked},
+			expDetails: []string{"", ""},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			states := cache.NewInvoiceStates(time.Hour)
+			termsSvc := service.NewPaymentTermsProxy(&dppMocks.PaymentTermsServiceMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					return terms, nil
+				},
+			}, &config.Transports{}, &config.Server{}, &config.Deployment{Network: "regtest"},
+				&config.PaymentTerms{RequireSignature: true}, nil, states)
+			proofSvc := service.NewProof(&mocks.PaymentStoreMock{
+				ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+					return nil
+				},
+			}, nil, states)
+			badProofSvc := service.NewProof(&mocks.PaymentStoreMock{
+				ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+					return errors.New("store down")
+				},
+			}, nil, states)
+
+			for i, s := range test.steps {
+				var err error
+				switch s.action {
+				case "terms":
+					_, err = termsSvc.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+				case "expire":
+					// terms are served again that expire before the payer returns.
+					args := server.InvoiceArgs{PaymentID: "abc123"}
+					assert.NoError(t, states.InvoiceStateUpdate(context.TODO(), args,
+						server.InvoiceStateUpdate{State: server.InvoiceStateTermsRequested}))
+					err = states.InvoiceStateUpdate(context.TODO(), args, server.InvoiceStateUpdate{
+						State:     server.InvoiceStateTermsServed,
+						ExpiresAt: time.Now().Add(-time.Minute),
+					})
+				case "pay":
+					walletErr := s.walletErr
+					paymentSvc := service.NewPayment(log.Noop{}, &dppMocks.PaymentWriterMock{
+						PaymentCreateFunc: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+							if walletErr != nil {
+								return nil, walletErr
+							}
+							return &dpp.PaymentACK{}, nil
+						},
+					}, memResults(), noTerms(), nil, states)
+					_, err = paymentSvc.PaymentCreate(context.TODO(), dpp.PaymentCreateArgs{PaymentID: "abc123"}, dpp.Payment{
+						ModeID: "ef63d9775da5",
+						Mode: hybridmode.Payment{
+							OptionID:     "choiceID0",
+							Transactions: []string{"tx hex"},
+						},
+					})
+				case "proof":
+					err = proofSvc.Create(context.TODO(), dpp.ProofCreateArgs{TxID: txID, PaymentReference: "abc123"}, *proof)
+				case "bad proof":
+					err = badProofSvc.Create(context.TODO(), dpp.ProofCreateArgs{TxID: txID, PaymentReference: "abc123"}, *proof)
+				}
+				if s.expErr != nil {
+					assert.EqualError(t, err, s.expErr.Error(), "step %d", i)
+				} else {
+					assert.NoError(t, err, "step %d", i)
+				}
+			}
+
+			status, err := service.NewInvoiceStatus(states).InvoiceStatus(context.TODO(), server.InvoiceArgs{PaymentID: "abc123"})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expState, status.State)
+			steps := make([]server.InvoiceState, 0, len(status.History))
+			details := make([]string, 0, len(status.History))
+			for _, h := range status.History {
+				steps = append(steps, h.State)
+				details = append(details, h.Detail)
+			}
+			assert.Equal(t, test.expSteps, steps)
+			assert.Equal(t, test.expDetails, details)
+		})
+	}
+}
+
+func TestInvoiceStatus_NotFound(t *testing.T) {
+	svc := service.NewInvoiceStatus(cache.NewInvoiceStates(time.Hour))
+	_, err := svc.InvoiceStatus(context.TODO(), server.InvoiceArgs{PaymentID: "abc123"})
+	assert.EqualError(t, err, "failed to read status of invoice abc123: Not Found: invoice abc123 not found")
+	_, err = svc.InvoiceStatus(context.TODO(), server.InvoiceArgs{})
+	assert.EqualError(t, err, "[paymentID: value cannot be empty]")
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// ledger lets operators read the payment traffic recorded for a paymentID.
+type ledger struct {
+	rdr server.LedgerReader
+}
+
+// NewLedger will setup and return a new ledger service.
+func NewLedger(rdr server.LedgerReader) *ledger {
+	return &ledger{rdr: rdr}
+}
+
+// LedgerEntries will validate the args and return the entries recorded for the paymentID, oldest first.
+func (l *ledger) LedgerEntries(ctx context.Context, args server.LedgerArgs) ([]server.LedgerEntry, error) {
+	if err := validator.New().
+		Validate("paymentID", validator.NotEmpty(args.PaymentID)).Err(); err != nil {
+		return nil, err
+	}
+	entries, err := l.rdr.LedgerEntries(ctx, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read ledger for paymentID %s", args.PaymentID)
+	}
+	return entries, nil
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+)
+
+// ledgerReaderFunc is a LedgerReader calling itself.
+type ledgerReaderFunc func(ctx context.Context, args server.LedgerArgs) ([]server.LedgerEntry, error)
+
+func (fn ledgerReaderFunc) LedgerEntries(ctx context.Context, args server.LedgerArgs) ([]server.LedgerEntry, error) {
+	return fn(ctx, args)
+}
+
+func TestLedger_LedgerEntries(t *testing.T) {
+	tests := map[string]struct {
+		args       server.LedgerArgs
+		readErr    error
+		expEntries []server.LedgerEntry
+		expErr     error
+	}{
+		"entries returned": {
+			args: server.LedgerArgs{PaymentID: "abc123"},
+			expEntries: []server.LedgerEntry{
+				{ID: 1, PaymentID: "abc123", Event: server.LedgerEventPaymentTerms},
+				{ID: 2, PaymentID: "abc123", TxID: "tx1", Event: server.LedgerEventPayment},
+			},
+		},
+		"missing paymentID rejected": {
+			expErr: errors.New("[paymentID: value cannot be empty]"),
+		},
+		"read error returned": {
+			args:    server.LedgerArgs{PaymentID: "abc123"},
+			readErr: errors.New("oh no"),
+			expErr:  errors.New("failed to read ledger for paymentID abc123: oh no"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := service.NewLedger(ledgerReaderFunc(func(ctx context.Context, args server.LedgerArgs) ([]server.LedgerEntry, error) {
+				assert.Equal(t, test.args, args)
+				return test.expEntries, test.readErr
+			}))
+			entries, err := svc.LedgerEntries(context.TODO(), test.args)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expEntries, entries)
+		})
+	}
+}
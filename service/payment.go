@@ -2,23 +2,79 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bc/spv"
+	"github.com/libsv/go-bt/v2"
 	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+	"github.com/theflyingcodr/lathos"
 )
 
+// hybridModeID is the BRFCID of the hybrid payment mode, the only mode supported.
+const hybridModeID = "ef63d9775da5"
+
 // payment is a layer on top of the payment services of which we currently support:
 // * wallet payments, that are handled by the wallet and transmitted to the network
 // * paymail payments, that use the paymail protocol for making the payments.
+//
+// Payments are checked against the PaymentTerms served for the paymentID, and
+// rejected without contacting the wallet if they don't pay the quoted outputs.
+//
+// If a verifier is set, the ancestry of each transaction is checked with SPV
+// and payments with invalid ancestry are rejected.
+//
+// Payments are idempotent, a Payment repeated with the same Idempotency-Key or
+// containing the same transactions is answered with the original result rather
+// than being sent to the wallet again.
+//
+// If states are tracked, a Payment for an invoice that has already been paid or
+// has expired is rejected.
 type payment struct {
 	l          log.Logger
 	paymentWtr dpp.PaymentWriter
+	resultRW   server.PaymentResultReaderWriter
+	termsRdr   server.ServedTermsReader
+	verifier   spv.PaymentVerifier
+	states     server.InvoiceStateWriter
+
+	mu       sync.Mutex
+	inflight map[string]*paymentCall
+}
+
+// paymentCall is a payment currently being processed by the wallet, duplicates
+// wait on done and then read the result.
+type paymentCall struct {
+	done   chan struct{}
+	result server.PaymentResult
 }
 
-// NewPayment will create and return a new payment service.
-func NewPayment(l log.Logger, paymentWtr dpp.PaymentWriter) *payment {
+// NewPayment will create and return a new payment service, verifier can be nil
+// to skip ancestry verification and states nil to not track invoice states.
+//
+// resultRW can be nil to not replay the results of earlier submissions and
+// termsRdr nil to not check payments against served PaymentTerms, duplicates
+// in-flight on this instance are still sent to the wallet once.
+func NewPayment(l log.Logger, paymentWtr dpp.PaymentWriter, resultRW server.PaymentResultReaderWriter,
+	termsRdr server.ServedTermsReader, verifier spv.PaymentVerifier, states server.InvoiceStateWriter) *payment {
 	return &payment{
 		l:          l,
 		paymentWtr: paymentWtr,
+		resultRW:   resultRW,
+		termsRdr:   termsRdr,
+		verifier:   verifier,
+		states:     states,
+		inflight:   map[string]*paymentCall{},
 	}
 }
 
@@ -30,11 +86,284 @@ func (p *payment) PaymentCreate(ctx context.Context, args dpp.PaymentCreateArgs,
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
+	if err := p.validateAgainstServed(ctx, args, req); err != nil {
+		return nil, err
+	}
+	if p.verifier != nil {
+		if err := p.verifyAncestry(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	fingerprint := paymentFingerprint(req)
+	keys := idempotencyKeys(ctx, args, req)
+	if p.resultRW != nil {
+		for _, key := range keys {
+			res, err := p.resultRW.PaymentResult(ctx, key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read payment result for paymentID %s", args.PaymentID)
+			}
+			if res != nil {
+				return replay(*res, fingerprint, isTxIDsKey(args, key))
+			}
+		}
+	}
+
+	call, key, leader := p.join(keys)
+	if !leader {
+		select {
+		case <-call.done:
+			return replay(call.result, fingerprint, isTxIDsKey(args, key))
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "cancelled waiting on duplicate payment for paymentID %s", args.PaymentID)
+		}
+	}
+	defer p.leave(keys, call)
+
+	if err := p.updateState(ctx, args, server.InvoiceStateUpdate{State: server.InvoiceStatePaymentSubmitted}); err != nil {
+		call.result = server.PaymentResult{Fingerprint: fingerprint, Err: err}
+		return nil, err
+	}
 	// broadcast it to a wallet for processing.
 	ack, err := p.paymentWtr.PaymentCreate(ctx, args, req)
+	call.result = server.PaymentResult{Fingerprint: fingerprint, ACK: ack, Err: err}
+	outcome := server.InvoiceStateUpdate{State: server.InvoiceStateAcked}
 	if err != nil {
 		p.l.Error(err, "failed to create payment")
-		return nil, err
+		outcome = server.InvoiceStateUpdate{State: server.InvoiceStateRejected, Detail: rejectedReason(err)}
+	}
+	if sErr := p.updateState(ctx, args, outcome); sErr != nil {
+		p.l.Error(sErr, "failed to update invoice state")
+	}
+	// only store outcomes decided by the wallet, internal errors such as a
+	// timeout can be retried.
+	if p.resultRW != nil && (err == nil || lathos.IsClientError(err)) {
+		for _, key := range keys {
+			if sErr := p.resultRW.PaymentResultSet(ctx, key, call.result); sErr != nil {
+				p.l.Error(sErr, "failed to store payment result")
+			}
+		}
+	}
+	return ack, err
+}
+
+// validateAgainstServed will check the payment pays the PaymentTerms served for
+// the paymentID, if they are kept.
+func (p *payment) validateAgainstServed(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) error {
+	if p.termsRdr == nil {
+		return nil
+	}
+	terms, err := p.termsRdr.ServedTerms(ctx, dpp.PaymentTermsArgs{PaymentID: args.PaymentID})
+	if err != nil {
+		return errors.Wrapf(err, "failed to read payment terms served for paymentID %s", args.PaymentID)
+	}
+	// without terms, such as after a restart, the payee is left to check the payment.
+	if terms == nil {
+		return nil
+	}
+	return validateAgainstTerms(*terms, req)
+}
+
+// rejectedReason returns the reason a payment was rejected that can be shown
+// to clients, only errors returned by the wallet to the payer are detailed.
+func rejectedReason(err error) string {
+	var cErr lathos.ClientError
+	if errors.As(err, &cErr) {
+		return cErr.Detail()
+	}
+	return "the payment could not be processed by the payee"
+}
+
+// updateState will move the invoice to a new state if states are tracked.
+func (p *payment) updateState(ctx context.Context, args dpp.PaymentCreateArgs, req server.InvoiceStateUpdate) error {
+	if p.states == nil {
+		return nil
+	}
+	return errors.WithStack(p.states.InvoiceStateUpdate(ctx, server.InvoiceArgs{PaymentID: args.PaymentID}, req))
+}
+
+// verifyAncestry will check each transaction in the payment is anchored by its
+// ancestors to blocks known to the verifier.
+//
+// Proofs targeting a block header are checked against the block hash, so the header
+// must be known, proofs targeting a merkle root can't be checked and are rejected.
+func (p *payment) verifyAncestry(ctx context.Context, req dpp.Payment) error {
+	if len(req.Mode.Ancestors) == 0 {
+		return client_errors.NewErrUnprocessable("422", "payment ancestry is required")
+	}
+	ancestors := make(spv.TSCAncestriesJSON, 0, len(req.Mode.Ancestors))
+	for txID, a := range req.Mode.Ancestors {
+		if a.Proof != nil {
+			switch a.Proof.TargetType {
+			case "header":
+				bh, err := bc.NewBlockHeaderFromStr(a.Proof.Target)
+				if err != nil {
+					return client_errors.NewErrUnprocessablef("422", "ancestor %s proof target is not a block header", txID)
+				}
+				proof := *a.Proof
+				proof.Target, proof.TargetType = server.BlockHash(bh), ""
+				a.Proof = &proof
+			case "merkleRoot":
+				return client_errors.NewErrUnprocessablef("422", "ancestor %s proof must target a block hash or header", txID)
+			}
+			if len(a.Proof.TxOrID) < 64 || len(a.Proof.Target) != 64 {
+				return client_errors.NewErrUnprocessablef("422", "ancestor %s proof is malformed", txID)
+			}
+		}
+		ancestors = append(ancestors, a)
+	}
+	bb, err := ancestors.Bytes()
+	if err != nil {
+		return client_errors.NewErrUnprocessablef("422", "payment ancestry could not be read: %s", err)
+	}
+	for i, txHex := range req.Mode.Transactions {
+		tx, err := bt.NewTxFromString(txHex)
+		if err != nil {
+			return client_errors.NewErrUnprocessablef("422", "transaction %d could not be parsed", i)
+		}
+		if err := p.verifier.VerifyPayment(ctx, &spv.Payment{PaymentTx: tx, Ancestry: bb}); err != nil {
+			return client_errors.NewErrUnprocessablef("422", "transaction %s ancestry is invalid: %s", tx.TxID(), err)
+		}
+	}
+	return nil
+}
+
+// join will return the in-flight call for any of the keys along with the key
+// it was found by, if there is none a new call is registered and leader is true.
+func (p *payment) join(keys []string) (call *paymentCall, key string, leader bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		if c, ok := p.inflight[key]; ok {
+			return c, key, false
+		}
+	}
+	call = &paymentCall{done: make(chan struct{})}
+	for _, key := range keys {
+		p.inflight[key] = call
+	}
+	return call, "", true
+}
+
+// leave will release any callers waiting on the call.
+func (p *payment) leave(keys []string, call *paymentCall) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		if p.inflight[key] == call {
+			delete(p.inflight, key)
+		}
+	}
+	close(call.done)
+}
+
+// replay returns a previous result, provided it was for the same payment.
+// byTxIDs is true when the result was found by the transactions paid rather
+// than by the Idempotency-Key.
+func replay(res server.PaymentResult, fingerprint string, byTxIDs bool) (*dpp.PaymentACK, error) {
+	if res.Fingerprint == fingerprint {
+		return res.ACK, res.Err
+	}
+	if byTxIDs {
+		return nil, client_errors.NewErrDuplicate("409", "a different payment has already been submitted with these transactions")
+	}
+	return nil, client_errors.NewErrDuplicate("409", "a different payment has already been submitted with this idempotency key")
+}
+
+// isTxIDsKey returns true if key is the key for the transactions paid.
+func isTxIDsKey(args dpp.PaymentCreateArgs, key string) bool {
+	return strings.HasPrefix(key, args.PaymentID+":tx:")
+}
+
+// idempotencyKeys returns the keys a payment is identified by, these are the
+// Idempotency-Key supplied by the payer and the ids of the transactions paid.
+func idempotencyKeys(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) []string {
+	keys := make([]string, 0, 2)
+	if key := server.IdempotencyKey(ctx); key != "" {
+		keys = append(keys, args.PaymentID+":key:"+key)
+	}
+	txIDs := make([]string, 0, len(req.Mode.Transactions))
+	for _, txHex := range req.Mode.Transactions {
+		tx, err := bt.NewTxFromString(txHex)
+		if err != nil {
+			// unparseable transactions are left for the payee to reject.
+			return keys
+		}
+		txIDs = append(txIDs, tx.TxID())
+	}
+	sort.Strings(txIDs)
+	return append(keys, args.PaymentID+":tx:"+strings.Join(txIDs, ","))
+}
+
+// paymentFingerprint returns a hash of the transactions paid, used to detect an
+// idempotency key being reused for a different payment.
+func paymentFingerprint(req dpp.Payment) string {
+	h := sha256.Sum256([]byte(req.ModeID + ":" + req.Mode.OptionID + ":" + strings.Join(req.Mode.Transactions, ",")))
+	return hex.EncodeToString(h[:])
+}
+
+// validateAgainstTerms checks the payment uses a mode and option offered by the
+// terms and that its transactions pay every output quoted for that option.
+func validateAgainstTerms(terms dpp.PaymentTerms, req dpp.Payment) error {
+	v := validator.New()
+	outputs := make([]*bt.Output, 0)
+	for i, txHex := range req.Mode.Transactions {
+		v = v.Validate(fmt.Sprintf("mode.transactions[%d]", i), func() error {
+			tx, err := bt.NewTxFromString(txHex)
+			if err != nil {
+				return errors.New("transaction could not be parsed")
+			}
+			outputs = append(outputs, tx.Outputs...)
+			return nil
+		})
+	}
+	if len(v) > 0 {
+		return v.Err()
+	}
+
+	quoted := terms.Outputs
+	if terms.Modes != nil {
+		quoted = nil
+		v = v.Validate("modeId", func() error {
+			if req.ModeID != hybridModeID {
+				return fmt.Errorf("mode '%s' is not offered by the payment terms", req.ModeID)
+			}
+			return nil
+		})
+		option, ok := terms.Modes.Hybrid[req.Mode.OptionID]
+		v = v.Validate("mode.optionId", func() error {
+			if !ok {
+				return fmt.Errorf("option '%s' is not offered by the payment terms", req.Mode.OptionID)
+			}
+			return nil
+		})
+		for _, txTerms := range option["transactions"] {
+			quoted = append(quoted, txTerms.Outputs.NativeOutputs...)
+		}
+	}
+
+	used := make(map[int]struct{}, len(outputs))
+	missing := make([]validator.ValidationFunc, 0)
+	for _, q := range quoted {
+		q := q
+		if q.LockingScript == nil {
+			continue
+		}
+		found := false
+		for i, o := range outputs {
+			if _, ok := used[i]; ok {
+				continue
+			}
+			if o.Satoshis == q.Amount && o.LockingScript.Equals(q.LockingScript) {
+				used[i] = struct{}{}
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, func() error {
+				return fmt.Errorf("output of %d satoshis to script %s is missing", q.Amount, q.LockingScript)
+			})
+		}
 	}
-	return ack, nil
+	return v.Validate("mode.transactions", missing...).Err()
 }
@@ -2,16 +2,29 @@ package service_test
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/libsv/go-bc/spv"
 	"github.com/libsv/go-dpp/modes/hybridmode"
+	"sync"
 	"testing"
+	"time"
 
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/headers"
 	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
 	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/libsv/go-dpp"
 	dppMocks "github.com/libsv/go-dpp/mocks"
+	"github.com/libsv/go-dpp/nativetypes"
 	"github.com/stretchr/testify/assert"
+	validator "github.com/theflyingcodr/govalidator"
 )
 
 func TestPayment_Create(t *testing.T) {
@@ -113,7 +126,16 @@ func TestPayment_Create(t *testing.T) {
 				log.Noop{},
 				&dppMocks.PaymentWriterMock{
 					PaymentCreateFunc: test.paymentCreateFn,
-				})
+				},
+				&mocks.PaymentResultReaderWriterMock{
+					PaymentResultFunc: func(context.Context, string) (*server.PaymentResult, error) {
+						return nil, nil
+					},
+					PaymentResultSetFunc: func(context.Context, string, server.PaymentResult) error {
+						return nil
+					},
+				},
+				noTerms(), nil, nil)
 
 			_, err := svc.PaymentCreate(context.TODO(), test.args, test.req)
 			if test.expErr != nil {
@@ -125,3 +147,498 @@ func TestPayment_Create(t *testing.T) {
 		})
 	}
 }
+
+// noTerms returns a reader with no served PaymentTerms, payments are not checked against terms.
+func noTerms() *mocks.ServedTermsReaderMock {
+	return &mocks.ServedTermsReaderMock{
+		ServedTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*dpp.PaymentTerms, error) {
+			return nil, nil
+		},
+	}
+}
+
+// memResults is a simple map backed PaymentResultReaderWriter.
+func memResults() *mocks.PaymentResultReaderWriterMock {
+	var mu sync.Mutex
+	results := map[string]server.PaymentResult{}
+	return &mocks.PaymentResultReaderWriterMock{
+		PaymentResultFunc: func(ctx context.Context, key string) (*server.PaymentResult, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			res, ok := results[key]
+			if !ok {
+				return nil, nil
+			}
+			return &res, nil
+		},
+		PaymentResultSetFunc: func(ctx context.Context, key string, req server.PaymentResult) error {
+			mu.Lock()
+			defer mu.Unlock()
+			results[key] = req
+			return nil
+		},
+	}
+}
+
+func TestPayment_CreateIdempotent(t *testing.T) {
+	newPayment := func(satoshis uint64) dpp.Payment {
+		tx := bt.NewTx()
+		_ = tx.PayToAddress("1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb", satoshis)
+		return dpp.Payment{
+			ModeID: "ef63d9775da5",
+			Mode: hybridmode.Payment{
+				OptionID:     "choiceID0",
+				Transactions: []string{tx.String()},
+			},
+		}
+	}
+	tests := map[string]struct {
+		paymentCreateFn func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error)
+		first           dpp.Payment
+		firstKey        string
+		second          dpp.Payment
+		secondKey       string
+		noResults       bool
+		expCalls        int
+		expErr          error
+	}{
+		"same idempotency key replays ack": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first:     newPayment(1000),
+			firstKey:  "key1",
+			second:    newPayment(1000),
+			secondKey: "key1",
+			expCalls:  1,
+		},
+		"same transaction without key replays ack": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first:    newPayment(1000),
+			second:   newPayment(1000),
+			expCalls: 1,
+		},
+		"different transactions are both sent": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first:    newPayment(1000),
+			second:   newPayment(2000),
+			expCalls: 2,
+		},
+		"payee client error is replayed": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return nil, client_errors.NewErrUnprocessable("422", "not enough")
+			},
+			first:     newPayment(1000),
+			firstKey:  "key1",
+			second:    newPayment(1000),
+			secondKey: "key1",
+			expCalls:  1,
+			expErr:    errors.New("Unprocessable Entity: not enough"),
+		},
+		"internal error is not replayed": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return nil, errors.New("timeout")
+			},
+			first:     newPayment(1000),
+			firstKey:  "key1",
+			second:    newPayment(1000),
+			secondKey: "key1",
+			expCalls:  2,
+			expErr:    errors.New("timeout"),
+		},
+		"same idempotency key sent again without result store": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first:     newPayment(1000),
+			firstKey:  "key1",
+			second:    newPayment(1000),
+			secondKey: "key1",
+			noResults: true,
+			expCalls:  2,
+		},
+		"key reused for different payment is rejected": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first:     newPayment(1000),
+			firstKey:  "key1",
+			second:    newPayment(2000),
+			secondKey: "key1",
+			expCalls:  1,
+			expErr:    errors.New("Conflict: a different payment has already been submitted with this idempotency key"),
+		},
+		"same transaction without key for different payment is rejected": {
+			paymentCreateFn: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+				return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+			},
+			first: newPayment(1000),
+			second: func() dpp.Payment {
+				p := newPayment(1000)
+				p.ModeID = "other"
+				return p
+			}(),
+			expCalls: 1,
+			expErr:   errors.New("Conflict: a different payment has already been submitted with these transactions"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			wtr := &dppMocks.PaymentWriterMock{PaymentCreateFunc: test.paymentCreateFn}
+			var results server.PaymentResultReaderWriter = memResults()
+			if test.noResults {
+				results = nil
+			}
+			svc := service.NewPayment(log.Noop{}, wtr, results, noTerms(), nil, nil)
+			args := dpp.PaymentCreateArgs{PaymentID: "abc123"}
+
+			_, _ = svc.PaymentCreate(server.WithIdempotencyKey(context.TODO(), test.firstKey), args, test.first)
+			_, err := svc.PaymentCreate(server.WithIdempotencyKey(context.TODO(), test.secondKey), args, test.second)
+			assert.Equal(t, test.expCalls, len(wtr.PaymentCreateCalls()))
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPayment_CreateConcurrentDuplicates(t *testing.T) {
+	release := make(chan struct{})
+	wtr := &dppMocks.PaymentWriterMock{
+		PaymentCreateFunc: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+			<-release
+			return &dpp.PaymentACK{ModeID: "ef63d9775da5"}, nil
+		},
+	}
+	svc := service.NewPayment(log.Noop{}, wtr, memResults(), noTerms(), nil, nil)
+	req := dpp.Payment{
+		ModeID: "ef63d9775da5",
+		Mode: hybridmode.Payment{
+			OptionID:     "choiceID0",
+			Transactions: []string{"not a tx"},
+		},
+	}
+	ctx := server.WithIdempotencyKey(context.TODO(), "key1")
+
+	var wg sync.WaitGroup
+	acks := make(chan *dpp.PaymentACK, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ack, err := svc.PaymentCreate(ctx, dpp.PaymentCreateArgs{PaymentID: "abc123"}, req)
+			assert.NoError(t, err)
+			acks <- ack
+		}()
+	}
+	// wait for the first call to reach the wallet before releasing it.
+	assert.Eventually(t, func() bool { return len(wtr.PaymentCreateCalls()) == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(acks)
+
+	assert.Equal(t, 1, len(wtr.PaymentCreateCalls()))
+	for ack := range acks {
+		assert.Equal(t, "ef63d9775da5", ack.ModeID)
+	}
+}
+
+func TestPayment_CreateAgainstTerms(t *testing.T) {
+	script := func(h string) *bscript.Script {
+		s, err := bscript.NewFromHexString(h)
+		assert.NoError(t, err)
+		return s
+	}
+	payTo := script("76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac")
+	change := script("76a914b8e6a6a8b8c4bf8d7ab0e0e4b1a6c0e07b7a0e7c88ac")
+	txHex := func(outputs ...*bt.Output) string {
+		tx := bt.NewTx()
+		tx.Outputs = outputs
+		return tx.String()
+	}
+	terms := &dpp.PaymentTerms{
+		Network: "regtest",
+		Modes: &dpp.PaymentTermsModes{
+			Hybrid: hybridmode.PaymentTerms{
+				"choiceID0": {
+					"transactions": {
+						hybridmode.TransactionTerms{
+							Outputs: hybridmode.Outputs{NativeOutputs: []nativetypes.NativeOutput{
+								{Amount: 1000, LockingScript: payTo},
+								{Amount: 500, LockingScript: payTo},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		terms     *dpp.PaymentTerms
+		req       dpp.Payment
+		expErr    error
+		expWallet bool
+	}{
+		"payment paying quoted outputs sent to wallet": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID: "choiceID0",
+					Transactions: []string{txHex(
+						&bt.Output{Satoshis: 500, LockingScript: payTo},
+						&bt.Output{Satoshis: 1000, LockingScript: payTo},
+						&bt.Output{Satoshis: 200, LockingScript: change},
+					)},
+				},
+			},
+			expWallet: true,
+		},
+		"payment with outputs over several transactions sent to wallet": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID: "choiceID0",
+					Transactions: []string{
+						txHex(&bt.Output{Satoshis: 500, LockingScript: payTo}),
+						txHex(&bt.Output{Satoshis: 1000, LockingScript: payTo}),
+					},
+				},
+			},
+			expWallet: true,
+		},
+		"payment without served terms sent to wallet": {
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID0",
+					Transactions: []string{"not a tx"},
+				},
+			},
+			expWallet: true,
+		},
+		"payment with unparseable transaction rejected": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID0",
+					Transactions: []string{txHex(&bt.Output{Satoshis: 1500, LockingScript: payTo}), "not a tx"},
+				},
+			},
+			expErr: errors.New("[mode.transactions[1]: transaction could not be parsed]"),
+		},
+		"payment missing an output rejected": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID0",
+					Transactions: []string{txHex(&bt.Output{Satoshis: 1000, LockingScript: payTo})},
+				},
+			},
+			expErr: errors.New("[mode.transactions: output of 500 satoshis to script " +
+				"76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac is missing]"),
+		},
+		"payment paying wrong amount rejected": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID: "choiceID0",
+					Transactions: []string{txHex(
+						&bt.Output{Satoshis: 1000, LockingScript: payTo},
+						&bt.Output{Satoshis: 499, LockingScript: payTo},
+					)},
+				},
+			},
+			expErr: errors.New("[mode.transactions: output of 500 satoshis to script " +
+				"76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac is missing]"),
+		},
+		"payment paying wrong script rejected": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID: "choiceID0",
+					Transactions: []string{txHex(
+						&bt.Output{Satoshis: 1000, LockingScript: change},
+						&bt.Output{Satoshis: 500, LockingScript: payTo},
+					)},
+				},
+			},
+			expErr: errors.New("[mode.transactions: output of 1000 satoshis to script " +
+				"76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac is missing]"),
+		},
+		"payment with unknown mode and option rejected": {
+			terms: terms,
+			req: dpp.Payment{
+				ModeID: "abc",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID9",
+					Transactions: []string{txHex(&bt.Output{Satoshis: 200, LockingScript: change})},
+				},
+			},
+			expErr: errors.New("[mode.optionId: option 'choiceID9' is not offered by the payment terms], " +
+				"[modeId: mode 'abc' is not offered by the payment terms]"),
+		},
+		"payment checked against legacy outputs": {
+			terms: &dpp.PaymentTerms{
+				Network: "regtest",
+				Outputs: []nativetypes.NativeOutput{{Amount: 1000, LockingScript: payTo}},
+			},
+			req: dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID0",
+					Transactions: []string{txHex(&bt.Output{Satoshis: 999, LockingScript: payTo})},
+				},
+			},
+			expErr: errors.New("[mode.transactions: output of 1000 satoshis to script " +
+				"76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac is missing]"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			wtr := &dppMocks.PaymentWriterMock{
+				PaymentCreateFunc: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+					return &dpp.PaymentACK{}, nil
+				},
+			}
+			svc := service.NewPayment(log.Noop{}, wtr, memResults(), &mocks.ServedTermsReaderMock{
+				ServedTermsFunc: func(ctx context.Context, args dpp.PaymentTermsArgs) (*dpp.PaymentTerms, error) {
+					assert.Equal(t, "abc123", args.PaymentID)
+					return test.terms, nil
+				},
+			}, nil, nil)
+
+			_, err := svc.PaymentCreate(context.TODO(), dpp.PaymentCreateArgs{PaymentID: "abc123"}, test.req)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				var valErr validator.ErrValidation
+				assert.ErrorAs(t, err, &valErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expWallet, len(wtr.PaymentCreateCalls()) == 1)
+		})
+	}
+}
+
+func TestPayment_CreateAncestry(t *testing.T) {
+	payTo, err := bscript.NewFromHexString("76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac")
+	assert.NoError(t, err)
+	parent := bt.NewTx()
+	assert.NoError(t, parent.From("b1ba8a9e3c7c4c6a1b3dd8e7e2cf09a0a5c5f5fbf3a2a1e3c8d9e1f0a3b4c5d6", 0,
+		"76a91493d0d43918a5df78f08cfe22a4e022846b6736c288ac", 2000))
+	parent.AddOutput(&bt.Output{Satoshis: 1500, LockingScript: payTo})
+	tx := bt.NewTx()
+	assert.NoError(t, tx.From(parent.TxID(), 0, payTo.String(), 1500))
+	tx.AddOutput(&bt.Output{Satoshis: 1000, LockingScript: payTo})
+
+	// a block holding only the parent has the parent's txid as its merkle root.
+	merkleRoot, err := hex.DecodeString(parent.TxID())
+	assert.NoError(t, err)
+	header := &bc.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: merkleRoot,
+		Time:           1666000000,
+		Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+	}
+	blockHash := server.BlockHash(header)
+	unknown := *header
+	unknown.Nonce = 1
+
+	ancestors := func(proof *bc.MerkleProof) map[string]spv.TSCAncestryJSON {
+		return map[string]spv.TSCAncestryJSON{
+			parent.TxID(): {RawTx: parent.String(), Proof: proof},
+		}
+	}
+	tests := map[string]struct {
+		ancestors map[string]spv.TSCAncestryJSON
+		expErr    error
+	}{
+		"payment anchored to known block sent to wallet": {
+			ancestors: ancestors(&bc.MerkleProof{TxOrID: parent.TxID(), Target: blockHash, Nodes: []string{}}),
+		},
+		"payment anchored to known block header sent to wallet": {
+			ancestors: ancestors(&bc.MerkleProof{
+				TxOrID: parent.TxID(), Target: header.String(), TargetType: "header", Nodes: []string{},
+			}),
+		},
+		"payment anchored to unknown block rejected": {
+			ancestors: ancestors(&bc.MerkleProof{TxOrID: parent.TxID(), Target: server.BlockHash(&unknown), Nodes: []string{}}),
+			expErr: fmt.Errorf("Unprocessable Entity: transaction %s ancestry is invalid: "+
+				"invalid merkle proof, payment invalid", tx.TxID()),
+		},
+		"payment anchored to unknown block header rejected": {
+			ancestors: ancestors(&bc.MerkleProof{
+				TxOrID: parent.TxID(), Target: unknown.String(), TargetType: "header", Nodes: []string{},
+			}),
+			expErr: fmt.Errorf("Unprocessable Entity: transaction %s ancestry is invalid: "+
+				"invalid merkle proof, payment invalid", tx.TxID()),
+		},
+		"proof for another tx rejected": {
+			ancestors: ancestors(&bc.MerkleProof{TxOrID: tx.TxID(), Target: blockHash, Nodes: []string{}}),
+			expErr: fmt.Errorf("Unprocessable Entity: transaction %s ancestry is invalid: "+
+				"input and proof ID mismatch", tx.TxID()),
+		},
+		"proof targeting merkle root rejected": {
+			ancestors: ancestors(&bc.MerkleProof{
+				TxOrID: parent.TxID(), Target: parent.TxID(), TargetType: "merkleRoot", Nodes: []string{},
+			}),
+			expErr: fmt.Errorf("Unprocessable Entity: ancestor %s proof must target a block hash or header", parent.TxID()),
+		},
+		"malformed proof rejected": {
+			ancestors: ancestors(&bc.MerkleProof{Target: blockHash}),
+			expErr:    fmt.Errorf("Unprocessable Entity: ancestor %s proof is malformed", parent.TxID()),
+		},
+		"ancestor without proof rejected": {
+			ancestors: ancestors(nil),
+			expErr: fmt.Errorf("Unprocessable Entity: transaction %s ancestry is invalid: "+
+				"break in the ancestry missing either a parent transaction or a proof", tx.TxID()),
+		},
+		"payment without ancestry rejected": {
+			expErr: errors.New("Unprocessable Entity: payment ancestry is required"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifier, err := spv.NewPaymentVerifier(headers.NewMemory(header))
+			assert.NoError(t, err)
+			wtr := &dppMocks.PaymentWriterMock{
+				PaymentCreateFunc: func(context.Context, dpp.PaymentCreateArgs, dpp.Payment) (*dpp.PaymentACK, error) {
+					return &dpp.PaymentACK{}, nil
+				},
+			}
+			svc := service.NewPayment(log.Noop{}, wtr, memResults(), noTerms(), verifier, nil)
+
+			_, err = svc.PaymentCreate(context.TODO(), dpp.PaymentCreateArgs{PaymentID: "abc123"}, dpp.Payment{
+				ModeID: "ef63d9775da5",
+				Mode: hybridmode.Payment{
+					OptionID:     "choiceID0",
+					Transactions: []string{tx.String()},
+					Ancestors:    test.ancestors,
+				},
+			})
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				assert.Empty(t, wtr.PaymentCreateCalls())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, wtr.PaymentCreateCalls(), 1)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
+	server "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
 	"github.com/libsv/go-bk/envelope"
 	"github.com/libsv/go-dpp"
 	"github.com/pkg/errors"
@@ -17,19 +21,43 @@ type paymentTermsProxy struct {
 	preqRdr   dpp.PaymentTermsReader
 	transCfg  *config.Transports
 	walletCfg *config.Server
+	deployCfg *config.Deployment
+	termsCfg  *config.PaymentTerms
+	served    server.ServedTermsWriter
+	states    server.InvoiceStateWriter
 }
 
 // NewPaymentTermsProxy will setup and return a new PaymentTerms service that will generate outputs
 // using the provided outputter which is defined in server config.
-func NewPaymentTermsProxy(preqRdr dpp.PaymentTermsReader, transCfg *config.Transports, walletCfg *config.Server) *paymentTermsProxy {
+//
+// PaymentTerms returned by the payee wallet are checked before being passed on,
+// termsCfg sets whether a signature and the deployment's network are required.
+//
+// If served is not nil the terms are kept once checked, so Payments can be
+// checked against them.
+//
+// If states is not nil each request the wallet returns terms for, and the
+// terms served, are recorded against the invoice, if nil invoice states aren't
+// tracked.
+func NewPaymentTermsProxy(preqRdr dpp.PaymentTermsReader, transCfg *config.Transports, walletCfg *config.Server,
+	deployCfg *config.Deployment, termsCfg *config.PaymentTerms, served server.ServedTermsWriter,
+	states server.InvoiceStateWriter) *paymentTermsProxy {
 	return &paymentTermsProxy{
 		preqRdr:   preqRdr,
 		transCfg:  transCfg,
 		walletCfg: walletCfg,
+		deployCfg: deployCfg,
+		termsCfg:  termsCfg,
+		served:    served,
+		states:    states,
 	}
 }
 
 // PaymentTerms will call to the data layer to return a signed JSON envelope containing payment terms.
+//
+// If the envelope isn't validly signed, or the terms are malformed or expired a
+// BadGateway error is returned, as are unsigned terms or terms for another
+// network when required.
 func (p *paymentTermsProxy) PaymentTerms(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
 	if err := validator.New().
 		Validate("paymentID", validator.NotEmpty(args.PaymentID)); err.Err() != nil {
@@ -39,5 +67,75 @@ func (p *paymentTermsProxy) PaymentTerms(ctx context.Context, args dpp.PaymentTe
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read payment request for paymentID %s", args.PaymentID)
 	}
+	// only invoices the wallet knows are tracked, so unknown paymentIDs
+	// don't hold state.
+	if err := p.updateState(ctx, args, server.InvoiceStateUpdate{State: server.InvoiceStateTermsRequested}); err != nil {
+		return nil, err
+	}
+	terms, err := p.verify(resp)
+	if err != nil {
+		return nil, client_errors.NewErrBadGatewayf("502",
+			"payee wallet returned invalid payment terms for paymentID %s: %s", args.PaymentID, err)
+	}
+	if p.served != nil {
+		if err := p.served.ServedTermsCreate(ctx, args, *terms); err != nil {
+			return nil, errors.Wrapf(err, "failed to keep payment terms for paymentID %s", args.PaymentID)
+		}
+	}
+	served := server.InvoiceStateUpdate{State: server.InvoiceStateTermsServed}
+	if terms.ExpirationTimestamp != 0 {
+		served.ExpiresAt = time.Unix(terms.ExpirationTimestamp, 0)
+	}
+	if err := p.updateState(ctx, args, served); err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
+
+// updateState will move the invoice to a new state if states are tracked.
+func (p *paymentTermsProxy) updateState(ctx context.Context, args dpp.PaymentTermsArgs, req server.InvoiceStateUpdate) error {
+	if p.states == nil {
+		return nil
+	}
+	return errors.WithStack(p.states.InvoiceStateUpdate(ctx, server.InvoiceArgs{PaymentID: args.PaymentID}, req))
+}
+
+// verify checks the envelope signature and decodes, checks and returns the terms it holds.
+func (p *paymentTermsProxy) verify(env *envelope.JSONEnvelope) (*dpp.PaymentTerms, error) {
+	if env == nil {
+		return nil, errors.New("no payment terms returned")
+	}
+	switch {
+	case env.Signature == nil && env.PublicKey == nil:
+		if p.termsCfg.RequireSignature {
+			return nil, errors.New("payment terms are not signed")
+		}
+	case env.Signature == nil || env.PublicKey == nil:
+		return nil, errors.New("payment terms envelope must have both a signature and publicKey")
+	default:
+		ok, err := env.IsValid()
+		if err != nil {
+			return nil, errors.Wrap(err, "malformed envelope")
+		}
+		if !ok {
+			return nil, errors.New("signature is invalid")
+		}
+	}
+	var terms dpp.PaymentTerms
+	if err := json.Unmarshal([]byte(env.Payload), &terms); err != nil {
+		return nil, errors.Wrap(err, "malformed payload")
+	}
+	switch {
+	case terms.Version == "":
+		return nil, errors.New("version is missing")
+	case terms.CreationTimestamp <= 0:
+		return nil, errors.New("creationTimestamp is missing")
+	case terms.Modes == nil && len(terms.Outputs) == 0:
+		return nil, errors.New("no payment modes or outputs are set")
+	case p.termsCfg.RequireNetwork && terms.Network != p.deployCfg.Network:
+		return nil, errors.Errorf("network '%s' does not match '%s'", terms.Network, p.deployCfg.Network)
+	case terms.ExpirationTimestamp != 0 && terms.ExpirationTimestamp <= time.Now().Unix():
+		return nil, errors.New("payment terms have expired")
+	}
+	return &terms, nil
+}
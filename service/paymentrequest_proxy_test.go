@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	dppMocks "github.com/libsv/go-dpp/mocks"
+	"github.com/libsv/go-dpp/nativetypes"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/data/cache"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+func TestPaymentTermsProxy_PaymentTerms(t *testing.T) {
+	terms := func(fn func(*dpp.PaymentTerms)) *dpp.PaymentTerms {
+		pt := &dpp.PaymentTerms{
+			Network:             "regtest",
+			Version:             "1.0",
+			CreationTimestamp:   time.Now().Unix(),
+			ExpirationTimestamp: time.Now().Add(time.Hour).Unix(),
+			Outputs:             []nativetypes.NativeOutput{{Amount: 1000}},
+			PaymentURL:          "http://iamsotest/api/v1/payment/abc123",
+		}
+		if fn != nil {
+			fn(pt)
+		}
+		return pt
+	}
+	signed := func(pt *dpp.PaymentTerms) *envelope.JSONEnvelope {
+		env, err := envelope.NewJSONEnvelope(pt)
+		assert.NoError(t, err)
+		return env
+	}
+	tests := map[string]struct {
+		env           *envelope.JSONEnvelope
+		readErr       error
+		unsignedOK    bool
+		anyNetwork    bool
+		expErr        error
+		expGatewayErr bool
+	}{
+		"signed terms returned": {
+			env: signed(terms(nil)),
+		},
+		"terms without expiry returned": {
+			env: signed(terms(func(pt *dpp.PaymentTerms) {
+				pt.ExpirationTimestamp = 0
+			})),
+		},
+		"unsigned terms returned when signature not required": {
+			env:        &envelope.JSONEnvelope{Payload: `{"network":"regtest","version":"1.0","creationTimestamp":1,"modes":{}}`},
+			unsignedOK: true,
+		},
+		"unsigned terms rejected when signature required": {
+			env: &envelope.JSONEnvelope{Payload: `{"network":"regtest","version":"1.0","creationTimestamp":1,"modes":{}}`},
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"payment terms are not signed"),
+			expGatewayErr: true,
+		},
+		"terms with signature but no public key rejected": {
+			env: func() *envelope.JSONEnvelope {
+				env := signed(terms(nil))
+				env.PublicKey = nil
+				return env
+			}(),
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"payment terms envelope must have both a signature and publicKey"),
+			expGatewayErr: true,
+		},
+		"tampered terms rejected": {
+			env: func() *envelope.JSONEnvelope {
+				env := signed(terms(nil))
+				env.Payload = `{"network":"regtest","version":"1.0","creationTimestamp":1,"modes":{}}`
+				return env
+			}(),
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"signature is invalid"),
+			expGatewayErr: true,
+		},
+		"malformed payload rejected": {
+			env:        &envelope.JSONEnvelope{Payload: `{"network":`},
+			unsignedOK: true,
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"malformed payload: unexpected end of JSON input"),
+			expGatewayErr: true,
+		},
+		"terms without outputs rejected": {
+			env: signed(terms(func(pt *dpp.PaymentTerms) {
+				pt.Outputs = nil
+			})),
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"no payment modes or outputs are set"),
+			expGatewayErr: true,
+		},
+		"expired terms rejected": {
+			env: signed(terms(func(pt *dpp.PaymentTerms) {
+				pt.ExpirationTimestamp = time.Now().Add(-time.Minute).Unix()
+			})),
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"payment terms have expired"),
+			expGatewayErr: true,
+		},
+		"terms for wrong network rejected": {
+			env: signed(terms(func(pt *dpp.PaymentTerms) {
+				pt.Network = "mainnet"
+			})),
+			expErr: errors.New("Bad Gateway: payee wallet returned invalid payment terms for paymentID abc123: " +
+				"network 'mainnet' does not match 'regtest'"),
+			expGatewayErr: true,
+		},
+		"terms for other network returned when network not required": {
+			env: signed(terms(func(pt *dpp.PaymentTerms) {
+				pt.Network = "mainnet"
+			})),
+			anyNetwork: true,
+		},
+		"reader error returned": {
+			readErr: errors.New("oh boi"),
+			expErr:  errors.New("failed to read payment request for paymentID abc123: oh boi"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			served := cache.NewServedTerms()
+			svc := service.NewPaymentTermsProxy(&dppMocks.PaymentTermsServiceMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					return test.env, test.readErr
+				},
+			}, &config.Transports{}, &config.Server{}, &config.Deployment{Network: "regtest"},
+				&config.PaymentTerms{RequireSignature: !test.unsignedOK, RequireNetwork: !test.anyNetwork}, served, nil)
+
+			resp, err := svc.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			// only terms that pass verification are kept to check payments against.
+			kept, keptErr := served.ServedTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			assert.NoError(t, keptErr)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				assert.Equal(t, test.expGatewayErr, client_errors.IsBadGateway(err))
+				assert.Nil(t, kept)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.env, resp)
+			assert.NotNil(t, kept)
+		})
+	}
+}
+
+func TestPaymentTermsProxy_PaymentTerms_InvoiceStates(t *testing.T) {
+	env, err := envelope.NewJSONEnvelope(&dpp.PaymentTerms{
+		Network:           "regtest",
+		Version:           "1.0",
+		CreationTimestamp: time.Now().Unix(),
+		Outputs:           []nativetypes.NativeOutput{{Amount: 1000}},
+	})
+	assert.NoError(t, err)
+	tests := map[string]struct {
+		env       *envelope.JSONEnvelope
+		readErr   error
+		expStates []server.InvoiceState
+	}{
+		"served terms recorded": {
+			env:       env,
+			expStates: []server.InvoiceState{server.InvoiceStateTermsRequested, server.InvoiceStateTermsServed},
+		},
+		"invalid terms recorded as requested": {
+			env:       &envelope.JSONEnvelope{Payload: `{"network":`},
+			expStates: []server.InvoiceState{server.InvoiceStateTermsRequested},
+		},
+		"unknown invoice leaves no state": {
+			readErr: client_errors.NewErrNotFoundf("404", "not found"),
+		},
+		"reader error leaves no state": {
+			readErr: errors.New("oh boi"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			states := cache.NewInvoiceStates(time.Hour)
+			svc := service.NewPaymentTermsProxy(&dppMocks.PaymentTermsServiceMock{
+				PaymentTermsFunc: func(context.Context, dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					return test.env, test.readErr
+				},
+			}, &config.Transports{}, &config.Server{}, &config.Deployment{Network: "regtest"},
+				&config.PaymentTerms{}, nil, states)
+
+			_, _ = svc.PaymentTerms(context.TODO(), dpp.PaymentTermsArgs{PaymentID: "abc123"})
+			status, err := states.InvoiceStatus(context.TODO(), server.InvoiceArgs{PaymentID: "abc123"})
+			if test.expStates == nil {
+				assert.EqualError(t, err, "Not Found: invoice abc123 not found")
+				return
+			}
+			assert.NoError(t, err)
+			var got []server.InvoiceState
+			for _, h := range status.History {
+				got = append(got, h.State)
+			}
+			assert.Equal(t, test.expStates, got)
+		})
+	}
+}
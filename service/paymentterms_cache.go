@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// paymentTermsCache lets a payee wallet manage the PaymentTerms the proxy
+// serves without waiting to be asked for them.
+type paymentTermsCache struct {
+	wtr server.PaymentTermsCacheWriter
+}
+
+// NewPaymentTermsCache will setup and return a new PaymentTerms cache service.
+func NewPaymentTermsCache(wtr server.PaymentTermsCacheWriter) *paymentTermsCache {
+	return &paymentTermsCache{wtr: wtr}
+}
+
+// PaymentTermsPush will validate the signed PaymentTerms and cache them, replacing
+// any already held for the paymentID.
+func (p *paymentTermsCache) PaymentTermsPush(ctx context.Context, args dpp.PaymentTermsArgs, req envelope.JSONEnvelope) error {
+	var terms dpp.PaymentTerms
+	if err := validator.New().
+		Validate("paymentID", validator.NotEmpty(args.PaymentID)).
+		Validate("jsonEnvelope", func() error {
+			ok, err := req.IsValid()
+			if err != nil {
+				return errors.Wrap(err, "invalid payment terms envelope")
+			}
+			if !ok {
+				return errors.New("payment terms envelope signature is invalid")
+			}
+			return nil
+		}).
+		Validate("payload", func() error {
+			if err := json.Unmarshal([]byte(req.Payload), &terms); err != nil {
+				return errors.Wrap(err, "failed to parse payment terms")
+			}
+			if terms.ExpirationTimestamp <= time.Now().Unix() {
+				return errors.New("payment terms have expired")
+			}
+			return nil
+		}).Err(); err != nil {
+		return err
+	}
+	if err := p.wtr.PaymentTermsSet(ctx, args, req); err != nil {
+		return errors.Wrapf(err, "failed to cache payment terms for paymentID %s", args.PaymentID)
+	}
+	return nil
+}
+
+// PaymentTermsInvalidate will remove any cached PaymentTerms for the paymentID,
+// the next request will be read from the payee wallet.
+func (p *paymentTermsCache) PaymentTermsInvalidate(ctx context.Context, args dpp.PaymentTermsArgs) error {
+	if err := validator.New().
+		Validate("paymentID", validator.NotEmpty(args.PaymentID)).Err(); err != nil {
+		return err
+	}
+	if err := p.wtr.PaymentTermsDelete(ctx, args); err != nil {
+		return errors.Wrapf(err, "failed to invalidate payment terms for paymentID %s", args.PaymentID)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+)
+
+func TestPaymentTermsCache_PaymentTermsPush(t *testing.T) {
+	validEnvelope := func(expires time.Time) envelope.JSONEnvelope {
+		env, _ := envelope.NewJSONEnvelope(&dpp.PaymentTerms{
+			Network:             "regtest",
+			Version:             "1.0",
+			CreationTimestamp:   time.Now().Unix(),
+			ExpirationTimestamp: expires.Unix(),
+			PaymentURL:          "http://iamsotest/api/v1/payment/abc123",
+		})
+		return *env
+	}
+	tests := map[string]struct {
+		setFunc   func(context.Context, dpp.PaymentTermsArgs, envelope.JSONEnvelope) error
+		args      dpp.PaymentTermsArgs
+		req       envelope.JSONEnvelope
+		expCalled bool
+		expErr    error
+	}{
+		"successful push is cached": {
+			setFunc: func(context.Context, dpp.PaymentTermsArgs, envelope.JSONEnvelope) error {
+				return nil
+			},
+			args:      dpp.PaymentTermsArgs{PaymentID: "abc123"},
+			req:       validEnvelope(time.Now().Add(time.Hour)),
+			expCalled: true,
+		},
+		"missing paymentID rejected": {
+			req:    validEnvelope(time.Now().Add(time.Hour)),
+			expErr: errors.New("[paymentID: value cannot be empty]"),
+		},
+		"expired terms rejected": {
+			args:   dpp.PaymentTermsArgs{PaymentID: "abc123"},
+			req:    validEnvelope(time.Now().Add(-time.Hour)),
+			expErr: errors.New("[payload: payment terms have expired]"),
+		},
+		"tampered payload rejected": {
+			args: dpp.PaymentTermsArgs{PaymentID: "abc123"},
+			req: func() envelope.JSONEnvelope {
+				env := validEnvelope(time.Now().Add(time.Hour))
+				env.Payload = `{"network":"mainnet","expirationTimestamp":9999999999}`
+				return env
+			}(),
+			expErr: errors.New("[jsonEnvelope: payment terms envelope signature is invalid]"),
+		},
+		"cache error is reported": {
+			setFunc: func(context.Context, dpp.PaymentTermsArgs, envelope.JSONEnvelope) error {
+				return errors.New("oh no")
+			},
+			args:      dpp.PaymentTermsArgs{PaymentID: "abc123"},
+			req:       validEnvelope(time.Now().Add(time.Hour)),
+			expCalled: true,
+			expErr:    errors.New("failed to cache payment terms for paymentID abc123: oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			wtr := &mocks.PaymentTermsCacheWriterMock{
+				PaymentTermsSetFunc: test.setFunc,
+			}
+			svc := service.NewPaymentTermsCache(wtr)
+
+			err := svc.PaymentTermsPush(context.TODO(), test.args, test.req)
+			assert.Equal(t, test.expCalled, len(wtr.PaymentTermsSetCalls()) == 1)
+			if test.expErr != nil {
+				assert.Error(t, err)
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPaymentTermsCache_PaymentTermsInvalidate(t *testing.T) {
+	tests := map[string]struct {
+		deleteFunc func(context.Context, dpp.PaymentTermsArgs) error
+		args       dpp.PaymentTermsArgs
+		expErr     error
+	}{
+		"successful invalidate": {
+			deleteFunc: func(context.Context, dpp.PaymentTermsArgs) error {
+				return nil
+			},
+			args: dpp.PaymentTermsArgs{PaymentID: "abc123"},
+		},
+		"missing paymentID rejected": {
+			expErr: errors.New("[paymentID: value cannot be empty]"),
+		},
+		"cache error is reported": {
+			deleteFunc: func(context.Context, dpp.PaymentTermsArgs) error {
+				return errors.New("oh no")
+			},
+			args:   dpp.PaymentTermsArgs{PaymentID: "abc123"},
+			expErr: errors.New("failed to invalidate payment terms for paymentID abc123: oh no"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := service.NewPaymentTermsCache(&mocks.PaymentTermsCacheWriterMock{
+				PaymentTermsDeleteFunc: test.deleteFunc,
+			})
+
+			err := svc.PaymentTermsInvalidate(context.TODO(), test.args)
+			if test.expErr != nil {
+				assert.Error(t, err)
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -3,22 +3,41 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
 
+	"github.com/libsv/go-bc"
 	"github.com/libsv/go-bk/envelope"
 	"github.com/libsv/go-dpp"
 	"github.com/pkg/errors"
 	validator "github.com/theflyingcodr/govalidator"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
 )
 
+// proofBatchWorkers is the number of proofs in a batch created at once.
+const proofBatchWorkers = 8
+
 // proof enforces business rules.
 type proof struct {
-	store dpp.ProofsWriter
+	store   dpp.ProofsWriter
+	headers server.BlockHeaderReader
+	states  server.InvoiceStateWriter
 }
 
 // NewProof will setup a new proof service.
-func NewProof(store dpp.ProofsWriter) *proof {
+//
+// If headers is not nil the merkle path of each proof is checked against the
+// header of the block it claims to be for, if nil proofs aren't verified.
+//
+// If states is not nil, proofs are recorded against the invoice of their
+// paymentReference once stored.
+func NewProof(store dpp.ProofsWriter, headers server.BlockHeaderReader, states server.InvoiceStateWriter) *proof {
 	return &proof{
-		store: store,
+		store:   store,
+		headers: headers,
+		states:  states,
 	}
 }
 
@@ -40,8 +59,118 @@ func (s *proof) Create(ctx context.Context, args dpp.ProofCreateArgs, req envelo
 	if err := proof.Validate(args); err != nil {
 		return err
 	}
+	if s.headers != nil {
+		if err := s.verify(ctx, args, proof); err != nil {
+			return err
+		}
+	}
 	if err := s.store.ProofCreate(ctx, args, req); err != nil {
 		return errors.Wrapf(err, "failed to add proof with txid '%s' and invoiceID '%s'", args.TxID, args.PaymentReference)
 	}
+	if s.states != nil && args.PaymentReference != "" {
+		if err := s.states.InvoiceStateUpdate(ctx, server.InvoiceArgs{PaymentID: args.PaymentReference},
+			server.InvoiceStateUpdate{State: server.InvoiceStateProofReceived, Detail: args.TxID}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 	return nil
 }
+
+// ProofsCreate will create each proof in the batch, proofBatchWorkers at a time,
+// returning the error for each item in the order sent.
+func (s *proof) ProofsCreate(ctx context.Context, items []server.ProofBatchItem) []error {
+	errs := make([]error, len(items))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < proofBatchWorkers && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = s.Create(ctx, items[i].Args, items[i].Proof)
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+// verify will recompute the merkle root from the proof and check it matches
+// the header of the block the proof is for.
+//
+// The proof target must identify the same block as the blockHash it's sent with.
+func (s *proof) verify(ctx context.Context, args dpp.ProofCreateArgs, proof *dpp.ProofWrapper) error {
+	mp := proof.CallbackPayload
+	if mp.Composite || strings.ToLower(mp.ProofType) == "tree" {
+		return client_errors.NewErrUnprocessable("422", "only branch merkle proofs can be verified")
+	}
+	bh, err := s.headers.BlockHeader(ctx, proof.BlockHash)
+	if errors.Is(err, bc.ErrHeaderNotFound) {
+		return client_errors.NewErrUnprocessablef("422", "block %s is not known", proof.BlockHash)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read block header %s", proof.BlockHash)
+	}
+	switch mp.TargetType {
+	case "hash":
+		if mp.Target != proof.BlockHash {
+			return client_errors.NewErrUnprocessablef("422",
+				"merkle proof target %s does not match blockHash %s", mp.Target, proof.BlockHash)
+		}
+	case "header":
+		target, err := bc.NewBlockHeaderFromStr(mp.Target)
+		if err != nil || server.BlockHash(target) != proof.BlockHash {
+			return client_errors.NewErrUnprocessablef("422",
+				"merkle proof target is not the header of block %s", proof.BlockHash)
+		}
+	case "merkleRoot":
+		if mp.Target != bh.HashMerkleRootStr() {
+			return client_errors.NewErrUnprocessablef("422",
+				"merkle proof target is not the merkle root of block %s", proof.BlockHash)
+		}
+	}
+	root, err := merkleRoot(args.TxID, mp.Index, mp.Nodes)
+	if err != nil {
+		return client_errors.NewErrUnprocessablef("422", "merkle proof is malformed: %s", err)
+	}
+	if root != bh.HashMerkleRootStr() {
+		return client_errors.NewErrUnprocessablef("422",
+			"merkle proof for tx %s does not lead to block %s", args.TxID, proof.BlockHash)
+	}
+	return nil
+}
+
+// merkleRoot returns the merkle root reached by hashing txID up the tree with
+// the branch nodes, a node of * duplicates the hash it's paired with.
+func merkleRoot(txID string, index uint64, nodes []string) (string, error) {
+	if len(txID) != 64 {
+		return "", errors.New("txid must be 64 hex characters")
+	}
+	root := txID
+	for i, n := range nodes {
+		if n == "*" {
+			n = root
+		}
+		if len(n) != 64 {
+			return "", errors.Errorf("node %d must be 64 hex characters", i)
+		}
+		var err error
+		if index%2 == 0 {
+			root, err = bc.MerkleTreeParentStr(root, n)
+		} else {
+			root, err = bc.MerkleTreeParentStr(n, root)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "node %d is not hex", i)
+		}
+		index /= 2
+	}
+	if index != 0 {
+		return "", errors.New("index is beyond the nodes in the branch")
+	}
+	return root, nil
+}
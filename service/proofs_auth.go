@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"time"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// proofSignatureWindow is how far the timestamp of a signed request can be from
+// the time it's received.
+const proofSignatureWindow = 5 * time.Minute
+
+// proofSourceAuth authenticates proof callbacks with broadcaster API keys or
+// HMAC signed requests, restricted to an allowlist of addresses.
+type proofSourceAuth struct {
+	cfg       *config.ProofsAuth
+	allowlist []*net.IPNet
+}
+
+// NewProofSourceAuth will setup and return a new proof source authenticator,
+// allowlist entries that aren't an IP or CIDR are ignored.
+func NewProofSourceAuth(cfg *config.ProofsAuth) *proofSourceAuth {
+	a := &proofSourceAuth{cfg: cfg}
+	for _, entry := range cfg.Allowlist {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			a.allowlist = append(a.allowlist, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			a.allowlist = append(a.allowlist, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return a
+}
+
+// ProofSourceAuthenticate returns the broadcaster a proof was sent by.
+//
+// If an allowlist is set the request must come from an address on it. If no
+// keys or secrets are set the address identifies the broadcaster, otherwise
+// an API key or a request signed with the secret of a broadcaster is required.
+// The secret the request is signed with identifies the broadcaster.
+func (a *proofSourceAuth) ProofSourceAuthenticate(ctx context.Context, args server.ProofSourceArgs) (string, error) {
+	if len(a.allowlist) > 0 && !a.allowed(args.IP) {
+		return "", client_errors.NewErrNotAuthorisedf("403", "address %s can't send proofs", args.IP)
+	}
+	if len(a.cfg.Keys) == 0 && len(a.cfg.Secrets) == 0 {
+		return args.IP, nil
+	}
+	switch {
+	case args.APIKey != "":
+		broadcaster, ok := a.broadcaster(args.APIKey)
+		if !ok {
+			return "", client_errors.NewErrNotAuthenticated("401", "api key is invalid")
+		}
+		return broadcaster, nil
+	case args.Signature != "":
+		signed, err := strconv.ParseInt(args.Timestamp, 10, 64)
+		if err != nil {
+			return "", client_errors.NewErrNotAuthenticated("401", "a unix timestamp is required with a signature")
+		}
+		if age := time.Since(time.Unix(signed, 0)); age > proofSignatureWindow || age < -proofSignatureWindow {
+			return "", client_errors.NewErrNotAuthenticated("401", "signature has expired")
+		}
+		broadcaster, ok := a.signer(args)
+		if !ok {
+			return "", client_errors.NewErrNotAuthenticated("401", "signature is invalid")
+		}
+		return broadcaster, nil
+	}
+	return "", client_errors.NewErrNotAuthenticated("401", "an api key or signature is required")
+}
+
+// allowed returns true if ip is on the allowlist.
+func (a *proofSourceAuth) allowed(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range a.allowlist {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcaster returns the broadcaster the API key belongs to, every key is
+// compared so the time taken doesn't reveal a match.
+func (a *proofSourceAuth) broadcaster(apiKey string) (string, bool) {
+	var broadcaster string
+	found := false
+	for id, key := range a.cfg.Keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			broadcaster, found = id, true
+		}
+	}
+	return broadcaster, found
+}
+
+// signer returns the broadcaster whose secret the request was signed with,
+// every secret is checked so the time taken doesn't reveal a match.
+func (a *proofSourceAuth) signer(args server.ProofSourceArgs) (string, bool) {
+	var broadcaster string
+	found := false
+	for id, secret := range a.cfg.Secrets {
+		sig := ProofSignature(secret, args.Method, args.URI, args.Timestamp, args.Body)
+		if hmac.Equal([]byte(sig), []byte(args.Signature)) {
+			broadcaster, found = id, true
+		}
+	}
+	return broadcaster, found
+}
+
+// ProofSignature returns the hex HMAC-SHA256, keyed with secret, of the method,
+// path and query, timestamp and body of a proof request, each separated by a
+// newline.
+func ProofSignature(secret, method, uri, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n"))
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
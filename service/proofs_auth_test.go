@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+)
+
+func TestProofSourceAuth_ProofSourceAuthenticate(t *testing.T) {
+	body := []byte(`{"payload":"{}"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	expired := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	signed := func(secret, timestamp string, body []byte) server.ProofSourceArgs {
+		return server.ProofSourceArgs{
+			Signature: service.ProofSignature(secret, "POST", "/api/v1/proofs/abc?i=1", timestamp, body),
+			Timestamp: timestamp,
+			Method:    "POST",
+			URI:       "/api/v1/proofs/abc?i=1",
+			Body:      body,
+		}
+	}
+	keys := map[string]string{"arc": "arc-key", "taal": "taal-key"}
+	secrets := map[string]string{"mapi": "sssh"}
+	tests := map[string]struct {
+		cfg            config.ProofsAuth
+		args           server.ProofSourceArgs
+		expBroadcaster string
+		expErr         error
+	}{
+		"api key identifies broadcaster": {
+			cfg:            config.ProofsAuth{Keys: keys},
+			args:           server.ProofSourceArgs{APIKey: "taal-key"},
+			expBroadcaster: "taal",
+		},
+		"unknown api key rejected": {
+			cfg:    config.ProofsAuth{Keys: keys},
+			args:   server.ProofSourceArgs{APIKey: "nope"},
+			expErr: errors.New("Not Authenticated: api key is invalid"),
+		},
+		"signed request identifies broadcaster": {
+			cfg:            config.ProofsAuth{Secrets: secrets},
+			args:           signed("sssh", now, body),
+			expBroadcaster: "mapi",
+		},
+		"request signed with another secret rejected": {
+			cfg:    config.ProofsAuth{Secrets: secrets},
+			args:   signed("other", now, body),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"signature of another body rejected": {
+			cfg: config.ProofsAuth{Secrets: secrets},
+			args: func() server.ProofSourceArgs {
+				args := signed("sssh", now, body)
+				args.Body = []byte(`{}`)
+				return args
+			}(),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"signature for another path rejected": {
+			cfg: config.ProofsAuth{Secrets: secrets},
+			args: func() server.ProofSourceArgs {
+				args := signed("sssh", now, body)
+				args.URI = "/api/v1/proofs/def?i=1"
+				return args
+			}(),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"signature for another query rejected": {
+			cfg: config.ProofsAuth{Secrets: secrets},
+			args: func() server.ProofSourceArgs {
+				args := signed("sssh", now, body)
+				args.URI = "/api/v1/proofs/abc?i=2"
+				return args
+			}(),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"signature for another method rejected": {
+			cfg: config.ProofsAuth{Secrets: secrets},
+			args: func() server.ProofSourceArgs {
+				args := signed("sssh", now, body)
+				args.Method = "PUT"
+				return args
+			}(),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"signature with a changed timestamp rejected": {
+			cfg: config.ProofsAuth{Secrets: secrets},
+			args: func() server.ProofSourceArgs {
+				args := signed("sssh", now, body)
+				args.Timestamp = strconv.FormatInt(time.Now().Unix()-1, 10)
+				return args
+			}(),
+			expErr: errors.New("Not Authenticated: signature is invalid"),
+		},
+		"expired signature rejected": {
+			cfg:    config.ProofsAuth{Secrets: secrets},
+			args:   signed("sssh", expired, body),
+			expErr: errors.New("Not Authenticated: signature has expired"),
+		},
+		"signature without timestamp rejected": {
+			cfg:    config.ProofsAuth{Secrets: secrets},
+			args:   signed("sssh", "", body),
+			expErr: errors.New("Not Authenticated: a unix timestamp is required with a signature"),
+		},
+		"missing credentials rejected": {
+			cfg:    config.ProofsAuth{Keys: keys, Secrets: secrets},
+			args:   server.ProofSourceArgs{IP: "10.0.0.1"},
+			expErr: errors.New("Not Authenticated: an api key or signature is required"),
+		},
+		"allowlisted cidr identifies broadcaster by address": {
+			cfg:            config.ProofsAuth{Allowlist: []string{"10.0.0.0/8"}},
+			args:           server.ProofSourceArgs{IP: "10.20.30.40"},
+			expBroadcaster: "10.20.30.40",
+		},
+		"allowlisted ip accepted": {
+			cfg:            config.ProofsAuth{Allowlist: []string{"192.0.2.1", "2001:db8::1"}},
+			args:           server.ProofSourceArgs{IP: "2001:db8::1"},
+			expBroadcaster: "2001:db8::1",
+		},
+		"address off allowlist rejected": {
+			cfg:    config.ProofsAuth{Allowlist: []string{"10.0.0.0/8"}},
+			args:   server.ProofSourceArgs{IP: "192.0.2.1"},
+			expErr: errors.New("Permission Denied: address 192.0.2.1 can't send proofs"),
+		},
+		"api key from address off allowlist rejected": {
+			cfg:    config.ProofsAuth{Keys: keys, Allowlist: []string{"10.0.0.0/8"}},
+			args:   server.ProofSourceArgs{APIKey: "arc-key", IP: "192.0.2.1"},
+			expErr: errors.New("Permission Denied: address 192.0.2.1 can't send proofs"),
+		},
+		"api key from allowlisted address accepted": {
+			cfg:            config.ProofsAuth{Keys: keys, Allowlist: []string{"10.0.0.0/8"}},
+			args:           server.ProofSourceArgs{APIKey: "arc-key", IP: "10.0.0.1"},
+			expBroadcaster: "arc",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			broadcaster, err := service.NewProofSourceAuth(&test.cfg).ProofSourceAuthenticate(context.TODO(), test.args)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expBroadcaster, broadcaster)
+		})
+	}
+}
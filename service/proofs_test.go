@@ -0,0 +1,235 @@
+package service_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/data/headers"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+func TestProof_Create(t *testing.T) {
+	const (
+		txID    = "3e6f4e7f5a6b2b0c8d9e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e"
+		sibling = "b1ba8a9e3c7c4c6a1b3dd8e7e2cf09a0a5c5f5fbf3a2a1e3c8d9e1f0a3b4c5d6"
+	)
+	root, err := bc.MerkleTreeParentStr(sibling, txID)
+	assert.NoError(t, err)
+	merkleRoot, err := hex.DecodeString(root)
+	assert.NoError(t, err)
+	header := &bc.BlockHeader{
+		Version:        1,
+		Time:           1231006505,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: merkleRoot,
+		Bits:           []byte{0x1d, 0x00, 0xff, 0xff},
+	}
+	blockHash := server.BlockHash(header)
+
+	wrapper := func(fn func(*dpp.ProofWrapper)) *dpp.ProofWrapper {
+		p := &dpp.ProofWrapper{
+			CallbackPayload: &bc.MerkleProof{
+				Index:      1,
+				TxOrID:     txID,
+				Target:     blockHash,
+				TargetType: "hash",
+				Nodes:      []string{sibling},
+			},
+			BlockHash:      blockHash,
+			BlockHeight:    100,
+			CallbackTxID:   txID,
+			CallbackReason: "merkleProof",
+		}
+		if fn != nil {
+			fn(p)
+		}
+		return p
+	}
+	tests := map[string]struct {
+		proof          *dpp.ProofWrapper
+		verify         bool
+		expErr         error
+		expUnprocessed bool
+	}{
+		"valid proof stored": {
+			proof:  wrapper(nil),
+			verify: true,
+		},
+		"proof targeting header stored": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Target, p.CallbackPayload.TargetType = header.String(), "header"
+			}),
+			verify: true,
+		},
+		"proof targeting merkle root stored": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Target, p.CallbackPayload.TargetType = root, "merkleRoot"
+			}),
+			verify: true,
+		},
+		"proof with wrong index rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Index = 0
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof for tx " + txID + " does not lead to block " + blockHash),
+			expUnprocessed: true,
+		},
+		"proof with wrong nodes rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Nodes = []string{"*"}
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof for tx " + txID + " does not lead to block " + blockHash),
+			expUnprocessed: true,
+		},
+		"proof with index beyond nodes rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Index = 3
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof is malformed: index is beyond the nodes in the branch"),
+			expUnprocessed: true,
+		},
+		"proof with malformed node rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Nodes = []string{"abc"}
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof is malformed: node 0 must be 64 hex characters"),
+			expUnprocessed: true,
+		},
+		"proof for unknown block rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.BlockHash, p.CallbackPayload.Target = sibling, sibling
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: block " + sibling + " is not known"),
+			expUnprocessed: true,
+		},
+		"proof with target for another block rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Target = sibling
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof target " + sibling + " does not match blockHash " + blockHash),
+			expUnprocessed: true,
+		},
+		"proof with wrong merkle root target rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Target, p.CallbackPayload.TargetType = sibling, "merkleRoot"
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: merkle proof target is not the merkle root of block " + blockHash),
+			expUnprocessed: true,
+		},
+		"tree proof rejected": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.ProofType = "tree"
+			}),
+			verify:         true,
+			expErr:         errors.New("Unprocessable Entity: only branch merkle proofs can be verified"),
+			expUnprocessed: true,
+		},
+		"invalid proof stored when not verifying": {
+			proof: wrapper(func(p *dpp.ProofWrapper) {
+				p.CallbackPayload.Index = 0
+			}),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &mocks.PaymentStoreMock{
+				ProofCreateFunc: func(context.Context, dpp.ProofCreateArgs, envelope.JSONEnvelope) error {
+					return nil
+				},
+			}
+			svc := service.NewProof(store, nil, nil)
+			if test.verify {
+				svc = service.NewProof(store, headers.NewMemory(header), nil)
+			}
+			env, err := envelope.NewJSONEnvelope(test.proof)
+			assert.NoError(t, err)
+
+			err = svc.Create(context.TODO(), dpp.ProofCreateArgs{TxID: txID}, *env)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+				assert.Equal(t, test.expUnprocessed, errors.As(err, &client_errors.ErrUnprocessable{}))
+				assert.Empty(t, store.ProofCreateCalls())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, store.ProofCreateCalls(), 1)
+		})
+	}
+}
+
+func TestProof_ProofsCreate(t *testing.T) {
+	wrapper := func(txID string) envelope.JSONEnvelope {
+		env, err := envelope.NewJSONEnvelope(&dpp.ProofWrapper{
+			CallbackPayload: &bc.MerkleProof{
+				TxOrID:     txID,
+				Target:     "0000000000000000041c4b2a3a7b0b3c5c1a8b9a1f7c6e5d4c3b2a1908f7e6d5",
+				TargetType: "hash",
+			},
+			BlockHash:      "0000000000000000041c4b2a3a7b0b3c5c1a8b9a1f7c6e5d4c3b2a1908f7e6d5",
+			CallbackTxID:   txID,
+			CallbackReason: "merkleProof",
+		})
+		assert.NoError(t, err)
+		return *env
+	}
+	txIDs := []string{
+		"3e6f4e7f5a6b2b0c8d9e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e",
+		"b1ba8a9e3c7c4c6a1b3dd8e7e2cf09a0a5c5f5fbf3a2a1e3c8d9e1f0a3b4c5d6",
+	}
+	items := make([]server.ProofBatchItem, 0, 20)
+	for i := 0; i < 20; i++ {
+		txID := txIDs[i%2]
+		items = append(items, server.ProofBatchItem{
+			Args:  dpp.ProofCreateArgs{TxID: txID, PaymentReference: "abc123"},
+			Proof: wrapper(txID),
+		})
+	}
+	// every third proof is sent for the wrong txid.
+	for i := 0; i < len(items); i += 3 {
+		items[i].Args.TxID = txIDs[(i+1)%2]
+	}
+	store := &mocks.PaymentStoreMock{
+		ProofCreateFunc: func(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+			if args.TxID == txIDs[1] {
+				return errors.New("oh no")
+			}
+			return nil
+		},
+	}
+
+	errs := service.NewProof(store, nil, nil).ProofsCreate(context.TODO(), items)
+	assert.Len(t, errs, len(items))
+	stored := 0
+	for i, err := range errs {
+		switch {
+		case i%3 == 0:
+			assert.EqualError(t, err, "[callbackPayload.txOrId: txId provided in callbackPayload doesn't match expected txID "+
+				items[i].Args.TxID+"], [callbackTxID: proof txid does not match expected txid "+items[i].Args.TxID+"]")
+		case items[i].Args.TxID == txIDs[1]:
+			assert.EqualError(t, err, "failed to add proof with txid '"+txIDs[1]+"' and invoiceID 'abc123': oh no")
+			stored++
+		default:
+			assert.NoError(t, err)
+			stored++
+		}
+	}
+	assert.Len(t, store.ProofCreateCalls(), stored)
+}
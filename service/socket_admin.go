@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// socketAdmin lets operators inspect and close the channels and connections
+// held by a node.
+type socketAdmin struct {
+	rw server.SocketAdminReaderWriter
+}
+
+// NewSocketAdmin will setup and return a new socket admin service.
+func NewSocketAdmin(rw server.SocketAdminReaderWriter) *socketAdmin {
+	return &socketAdmin{rw: rw}
+}
+
+// Channels returns every channel open on the node.
+func (s *socketAdmin) Channels(ctx context.Context) ([]server.SocketChannel, error) {
+	channels, err := s.rw.Channels(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read channels")
+	}
+	return channels, nil
+}
+
+// Channel will validate the args and return the channel with its clients and awaits.
+func (s *socketAdmin) Channel(ctx context.Context, args server.SocketChannelArgs) (*server.SocketChannel, error) {
+	if err := validator.New().
+		Validate("channelID", validator.NotEmpty(args.ChannelID)).Err(); err != nil {
+		return nil, err
+	}
+	ch, err := s.rw.Channel(ctx, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read channel %s", args.ChannelID)
+	}
+	return ch, nil
+}
+
+// Clients returns every client connected to the node.
+func (s *socketAdmin) Clients(ctx context.Context) ([]server.SocketClient, error) {
+	clients, err := s.rw.Clients(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read clients")
+	}
+	return clients, nil
+}
+
+// Awaits returns the messages sent to channels waiting on a reply.
+func (s *socketAdmin) Awaits(ctx context.Context) ([]server.SocketAwait, error) {
+	awaits, err := s.rw.Awaits(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read awaits")
+	}
+	return awaits, nil
+}
+
+// ChannelClose will validate the args and disconnect every client on the channel.
+func (s *socketAdmin) ChannelClose(ctx context.Context, args server.SocketChannelArgs) error {
+	if err := validator.New().
+		Validate("channelID", validator.NotEmpty(args.ChannelID)).Err(); err != nil {
+		return err
+	}
+	return errors.Wrapf(s.rw.ChannelClose(ctx, args), "failed to close channel %s", args.ChannelID)
+}
+
+// ClientClose will validate the args and disconnect the client.
+func (s *socketAdmin) ClientClose(ctx context.Context, args server.SocketClientArgs) error {
+	if err := validator.New().
+		Validate("clientID", validator.NotEmpty(args.ClientID)).Err(); err != nil {
+		return err
+	}
+	return errors.Wrapf(s.rw.ClientClose(ctx, args), "failed to close client %s", args.ClientID)
+}
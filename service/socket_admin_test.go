@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/mocks"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+func TestSocketAdmin_Channel(t *testing.T) {
+	joined := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		args       server.SocketChannelArgs
+		channelFn  func(context.Context, server.SocketChannelArgs) (*server.SocketChannel, error)
+		expChannel *server.SocketChannel
+		expCalls   int
+		expErr     error
+	}{
+		"channel should be returned": {
+			args: server.SocketChannelArgs{ChannelID: "abc123"},
+			channelFn: func(ctx context.Context, args server.SocketChannelArgs) (*server.SocketChannel, error) {
+				return &server.SocketChannel{
+					ChannelID: args.ChannelID,
+					Created:   joined,
+					Sent:      2,
+					Received:  1,
+					Clients: []server.SocketClient{{
+						ClientID:  "client1",
+						ChannelID: args.ChannelID,
+						Joined:    joined,
+						Received:  1,
+					}},
+				}, nil
+			},
+			expChannel: &server.SocketChannel{
+				ChannelID: "abc123",
+				Created:   joined,
+				Sent:      2,
+				Received:  1,
+				Clients: []server.SocketClient{{
+					ClientID:  "client1",
+					ChannelID: "abc123",
+					Joined:    joined,
+					Received:  1,
+				}},
+			},
+			expCalls: 1,
+		},
+		"empty channelID should error": {
+			args:   server.SocketChannelArgs{},
+			expErr: errors.New("[channelID: value cannot be empty]"),
+		},
+		"channel not found should be returned": {
+			args: server.SocketChannelArgs{ChannelID: "abc123"},
+			channelFn: func(ctx context.Context, args server.SocketChannelArgs) (*server.SocketChannel, error) {
+				return nil, client_errors.NewErrNotFoundf("404", "channel %s not found", args.ChannelID)
+			},
+			expCalls: 1,
+			expErr:   errors.New("failed to read channel abc123: Not Found: channel abc123 not found"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := &mocks.SocketAdminReaderWriterMock{ChannelFunc: test.channelFn}
+			ch, err := service.NewSocketAdmin(rw).Channel(context.Background(), test.args)
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expChannel, ch)
+			assert.Len(t, rw.ChannelCalls(), test.expCalls)
+		})
+	}
+}
+
+func TestSocketAdmin_Close(t *testing.T) {
+	tests := map[string]struct {
+		channelID string
+		clientID  string
+		closeErr  error
+		expCalls  int
+		expErr    error
+	}{
+		"channel should be closed": {
+			channelID: "abc123",
+			expCalls:  1,
+		},
+		"client should be closed": {
+			clientID: "client1",
+			expCalls: 1,
+		},
+		"empty channelID should error": {
+			channelID: "",
+			expErr:    errors.New("[channelID: value cannot be empty]"),
+		},
+		"channel not found should be returned": {
+			channelID: "abc123",
+			closeErr:  client_errors.NewErrNotFound("404", "channel abc123 not found"),
+			expCalls:  1,
+			expErr:    errors.New("failed to close channel abc123: Not Found: channel abc123 not found"),
+		},
+		"client not found should be returned": {
+			clientID: "client1",
+			closeErr: client_errors.NewErrNotFound("404", "client client1 not found"),
+			expCalls: 1,
+			expErr:   errors.New("failed to close client client1: Not Found: client client1 not found"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := &mocks.SocketAdminReaderWriterMock{
+				ChannelCloseFunc: func(ctx context.Context, args server.SocketChannelArgs) error {
+					return test.closeErr
+				},
+				ClientCloseFunc: func(ctx context.Context, args server.SocketClientArgs) error {
+					return test.closeErr
+				},
+			}
+			svc := service.NewSocketAdmin(rw)
+			var err error
+			var calls int
+			if test.clientID != "" {
+				err = svc.ClientClose(context.Background(), server.SocketClientArgs{ClientID: test.clientID})
+				calls = len(rw.ClientCloseCalls())
+			} else {
+				err = svc.ChannelClose(context.Background(), server.SocketChannelArgs{ChannelID: test.channelID})
+				calls = len(rw.ChannelCloseCalls())
+			}
+			if test.expErr != nil {
+				assert.EqualError(t, err, test.expErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expCalls, calls)
+		})
+	}
+}
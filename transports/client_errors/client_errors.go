@@ -4,6 +4,7 @@
 package client_errors
 
 import (
+	"errors"
 	"fmt"
 
 "github.com/google/uuid"
@@ -303,3 +304,49 @@ func (e ErrUnprocessable) CannotProcess() bool {
 	return true
 }
 
+
+// ErrBadGateway can be returned when an upstream service, such as a payee
+// wallet, returns a response that cannot be used.
+type ErrBadGateway struct {
+	ErrClient
+}
+
+// NewErrBadGateway will create and return a new BadGateway error.
+// You can supply a code which can be set in your application to identify
+// a particular error in code such as G001.
+// Detail can be supplied to give more context to the error, ie
+// "payee wallet returned expired payment terms".
+func NewErrBadGateway(code, detail string) ErrBadGateway {
+	c := newErrClient(code, detail)
+	c.title = "Bad Gateway"
+	return ErrBadGateway{
+		ErrClient: c,
+	}
+}
+
+// NewErrBadGatewayf will create and return a new BadGateway error.
+// You can supply a code which can be set in your application to identify
+// a particular error in code such as G001.
+// Detail can be supplied to give more context to the error, ie
+// "payee wallet returned expired payment terms".
+func NewErrBadGatewayf(code, detail string, a ...interface{}) ErrBadGateway {
+	return NewErrBadGateway(code, fmt.Sprintf(detail, a...))
+}
+
+// BadGateway implements the BadGateway interface
+// and is used in error checking code.
+func (e ErrBadGateway) BadGateway() bool {
+	return true
+}
+
+// BadGateway can be implemented by errors raised when an upstream
+// service returns an unusable response.
+type BadGateway interface {
+	BadGateway() bool
+}
+
+// IsBadGateway will check that an error is a BadGateway type.
+func IsBadGateway(err error) bool {
+	var e BadGateway
+	return errors.As(err, &e)
+}
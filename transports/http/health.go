@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// healthHandler is an http handler for liveness, readiness and version probes.
+type healthHandler struct {
+	svc server.HealthService
+}
+
+// NewHealthHandler will create and return a new health handler.
+func NewHealthHandler(svc server.HealthService) *healthHandler {
+	return &healthHandler{svc: svc}
+}
+
+// RegisterRoutes will setup all routes with an echo group.
+func (h *healthHandler) RegisterRoutes(g *echo.Group) {
+	g.GET(RouteHealthz, h.healthz)
+	g.GET(RouteReadyz, h.readyz)
+	g.GET(RouteVersion, h.version)
+}
+
+// healthz godoc
+// @Summary Liveness
+// @Description Returns 200 while the server is running.
+// @Tags Health
+// @Produce json
+// @Success 200 {string} string "ok"
+// @Router /healthz [GET].
+func (h *healthHandler) healthz(e echo.Context) error {
+	return e.JSON(http.StatusOK, "ok")
+}
+
+// readyz godoc
+// @Summary Readiness
+// @Description Returns the result of each dependency check, with websocket counts in socket and hybrid modes.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} server.Readiness
+// @Failure 503 {object} server.Readiness "returned if a check fails or the server is shutting down"
+// @Router /readyz [GET].
+func (h *healthHandler) readyz(e echo.Context) error {
+	resp := h.svc.Readiness(e.Request().Context())
+	if !resp.Ready {
+		return e.JSON(http.StatusServiceUnavailable, resp)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
+
+// version godoc
+// @Summary Version
+// @Description Returns the version, commit, build date and region of the server.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} server.Version
+// @Router /version [GET].
+func (h *healthHandler) version(e echo.Context) error {
+	return e.JSON(http.StatusOK, h.svc.Version(e.Request().Context()))
+}
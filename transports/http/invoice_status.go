@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// invoiceStatusHandler is an http handler returning where an invoice stands.
+type invoiceStatusHandler struct {
+	svc server.InvoiceStatusReader
+}
+
+// NewInvoiceStatusHandler will create and return a new invoice status handler.
+func NewInvoiceStatusHandler(svc server.InvoiceStatusReader) *invoiceStatusHandler {
+	return &invoiceStatusHandler{svc: svc}
+}
+
+// RegisterRoutes will setup all routes with an echo group, m is applied to
+// each route.
+func (h *invoiceStatusHandler) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET(RouteV1PaymentStatus, h.status, m...)
+}
+
+// status godoc
+// @Summary Invoice status
+// @Description Returns the current state of an invoice and the history of states it moved through.
+// @Tags Payment
+// @Produce json
+// @Param paymentID path string true "Payment ID"
+// @Success 200 {object} server.InvoiceStatus
+// @Failure 404 {string} string "returned if the paymentID has not been seen"
+// @Failure 400 {object} server.ClientError "returned if the user input is invalid"
+// @Router /api/v1/payment/{paymentID}/status [GET].
+func (h *invoiceStatusHandler) status(e echo.Context) error {
+	var args server.InvoiceArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to bind request")
+	}
+	resp, err := h.svc.InvoiceStatus(e.Request().Context(), args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
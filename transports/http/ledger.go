@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// ledgerHandler is an http handler letting operators read the payment ledger.
+type ledgerHandler struct {
+	svc server.LedgerReader
+}
+
+// NewLedgerHandler will create and return a new ledger handler.
+func NewLedgerHandler(svc server.LedgerReader) *ledgerHandler {
+	return &ledgerHandler{svc: svc}
+}
+
+// RegisterRoutes will setup all routes with an echo group, m is applied to
+// each route.
+func (h *ledgerHandler) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET(RouteV1AdminLedger, h.entries, m...)
+}
+
+// entries godoc
+// @Summary Payment ledger
+// @Description Returns the PaymentTerms, Payments, PaymentACKs / errors and proofs recorded for a paymentID, oldest first.
+// @Tags Admin
+// @Produce json
+// @Param X-API-Key header string true "Admin API key"
+// @Param paymentID path string true "Payment ID"
+// @Success 200 {array} server.LedgerEntry
+// @Failure 400 {object} server.ClientError "returned if the user input is invalid"
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Router /api/v1/admin/ledger/{paymentID} [GET].
+func (h *ledgerHandler) entries(e echo.Context) error {
+	var args server.LedgerArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to bind request")
+	}
+	resp, err := h.svc.LedgerEntries(e.Request().Context(), args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
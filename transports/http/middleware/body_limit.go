@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// BodyLimit will reject requests with a body larger than n bytes with a bad
+// request. The body is limited as it is read, so this must be added before any
+// middleware reading it.
+func BodyLimit(n int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > n {
+				return client_errors.NewBadRequestf("400", "request body is larger than %d bytes", n)
+			}
+			req.Body = &limitedBody{ReadCloser: req.Body, limit: n, left: n}
+			return next(c)
+		}
+	}
+}
+
+// limitedBody is a request body returning a bad request once more than limit
+// bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	left     int64
+	exceeded bool
+}
+
+// Read will read from the body, reading one byte past the limit to know if it is exceeded.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.tooLarge()
+	}
+	if int64(len(p)) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.left {
+		n, b.left, b.exceeded = int(b.left), 0, true
+		return n, b.tooLarge()
+	}
+	b.left -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) tooLarge() error {
+	return client_errors.NewBadRequestf("400", "request body is larger than %d bytes", b.limit)
+}
@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestBodyLimit(t *testing.T) {
+	tests := map[string]struct {
+		body          string
+		chunked       bool
+		expStatusCode int
+		expBody       string
+	}{
+		"body under the limit read": {
+			body:          "abc",
+			expStatusCode: http.StatusOK,
+			expBody:       "abc",
+		},
+		"body at the limit read": {
+			body:          "abcdefgh",
+			expStatusCode: http.StatusOK,
+			expBody:       "abcdefgh",
+		},
+		"body over the limit rejected": {
+			body:          "abcdefghi",
+			expStatusCode: http.StatusBadRequest,
+		},
+		"body over the limit without a length rejected": {
+			body:          "abcdefghi",
+			chunked:       true,
+			expStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			e.POST("/proofs", func(c echo.Context) error {
+				body, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				return c.String(http.StatusOK, string(body))
+			}, middleware.BodyLimit(8))
+			req := httptest.NewRequest(http.MethodPost, "/proofs", strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, test.expStatusCode, rec.Code)
+			if test.expBody != "" {
+				assert.Equal(t, test.expBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Drain will answer requests with a 503 once draining returns true, so clients
+// retry against another node while the server stops.
+func Drain(draining func() bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if draining() {
+				return c.JSON(http.StatusServiceUnavailable, "Service Unavailable: server is shutting down")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestDrain(t *testing.T) {
+	tests := map[string]struct {
+		draining      bool
+		expStatusCode int
+	}{
+		"requests served before draining": {
+			expStatusCode: http.StatusOK,
+		},
+		"requests refused while draining": {
+			draining:      true,
+			expStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/payment/:paymentID", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.Drain(func() bool {
+				return test.draining
+			}))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment/abc123", nil))
+			assert.Equal(t, test.expStatusCode, rec.Code)
+		})
+	}
+}
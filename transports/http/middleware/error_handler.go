@@ -19,63 +19,59 @@ func ErrorHandler(l log.Logger) echo.HTTPErrorHandler {
 		if err == nil {
 			return
 		}
-		var valErr validator.ErrValidation
-		if errors.As(err, &valErr) {
-			resp := map[string]interface{}{
-				"errors": valErr,
-			}
-			_ = c.JSON(http.StatusBadRequest, resp)
-			return
-		}
+		code, resp := ErrorResponse(l, err)
+		_ = c.JSON(code, resp)
+	}
+}
 
-		if errors.Is(err, echo.ErrNotFound) {
-			err = client_errors.NewErrNotFound("404", "Not Found")
+// ErrorResponse returns the status code and body err is returned to clients
+// with, internal errors are logged and only a small detail returned.
+func ErrorResponse(l log.Logger, err error) (int, interface{}) {
+	var valErr validator.ErrValidation
+	if errors.As(err, &valErr) {
+		return http.StatusBadRequest, map[string]interface{}{
+			"errors": valErr,
 		}
+	}
 
-		var cErr server.ClientError
-		if errors.As(err, &cErr) {
-			_ = c.JSON(http.StatusBadRequest, cErr.Message)
-			return
-		}
+	if errors.Is(err, echo.ErrNotFound) {
+		err = client_errors.NewErrNotFound("404", "Not Found")
+	}
 
-		// Internal server error, log it to a system and return small detail
-		if !lathos.IsClientError(err) {
-			internalErr := errs.NewErrInternal(err, "500")
-			l.Error(internalErr, "Internal Server Error")
-			_ = c.JSON(http.StatusInternalServerError, internalErr.Error())
-			return
-		}
-		var clientErr lathos.ClientError
-		errors.As(err, &clientErr)
-		resp := server.ClientError{
-			ID:      clientErr.ID(),
-			Code:    clientErr.Code(),
-			Title:   clientErr.Title(),
-			Message: clientErr.Detail(),
-		}
-		if lathos.IsNotFound(err) {
-			_ = c.JSON(http.StatusNotFound, resp.Message)
-			return
-		}
-		if lathos.IsDuplicate(err) {
-			_ = c.JSON(http.StatusConflict, resp.Message)
-			return
-		}
-		if lathos.IsNotAuthenticated(err) {
-			_ = c.JSON(http.StatusUnauthorized, resp.Message)
-			return
-		}
-		if lathos.IsNotAuthorised(err) {
-			_ = c.JSON(http.StatusForbidden, resp.Message)
-			return
-		}
-		if lathos.IsCannotProcess(err) {
-			_ = c.JSON(http.StatusUnprocessableEntity, resp.Message)
-			return
-		}
-		if lathos.IsBadRequest(err) {
-			_ = c.JSON(http.StatusBadRequest, resp.Message)
-			return
-		}
+	var cErr server.ClientError
+	if errors.As(err, &cErr) {
+		return http.StatusBadRequest, cErr.Message
+	}
+
+	// Internal server error, log it to a system and return small detail
+	if !lathos.IsClientError(err) {
+		internalErr := errs.NewErrInternal(err, "500")
+		l.Error(internalErr, "Internal Server Error")
+		return http.StatusInternalServerError, internalErr.Error()
+	}
+	var clientErr lathos.ClientError
+	errors.As(err, &clientErr)
+	resp := server.ClientError{
+		ID:      clientErr.ID(),
+		Code:    clientErr.Code(),
+		Title:   clientErr.Title(),
+		Message: clientErr.Detail(),
+	}
+	switch {
+	case lathos.IsNotFound(err):
+		return http.StatusNotFound, resp.Message
+	case lathos.IsDuplicate(err):
+		return http.StatusConflict, resp.Message
+	case lathos.IsNotAuthenticated(err):
+		return http.StatusUnauthorized, resp.Message
+	case lathos.IsNotAuthorised(err):
+		return http.StatusForbidden, resp.Message
+	case lathos.IsCannotProcess(err):
+		return http.StatusUnprocessableEntity, resp.Message
+	case client_errors.IsBadGateway(err):
+		return http.StatusBadGateway, resp.Message
+	case lathos.IsBadRequest(err):
+		return http.StatusBadRequest, resp.Message
 	}
+	return http.StatusInternalServerError, resp.Message
 }
@@ -62,6 +62,11 @@ func TestErrorHandler(t *testing.T) {
 			expResp: "what did you even send?",
 			expStatusCode: http.StatusUnprocessableEntity,
 		},
+		"bad gateway 502": {
+			err: client_errors.NewErrBadGateway("502", "wallet sent rubbish"),
+			expResp: "wallet sent rubbish",
+			expStatusCode: http.StatusBadGateway,
+		},
 	}
 
 	for name, test := range tests {
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+// KeyAuth will reject requests that don't send one of keys, either in the
+// X-API-Key header or as a bearer token, such as Prometheus sends.
+func KeyAuth(keys []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey := c.Request().Header.Get(headerAPIKey)
+			if apiKey == "" {
+				apiKey = bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			}
+			if apiKey == "" {
+				return client_errors.NewErrNotAuthenticated("401", "an api key is required")
+			}
+			for _, key := range keys {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+					return next(c)
+				}
+			}
+			return client_errors.NewErrNotAuthenticated("401", "api key is invalid")
+		}
+	}
+}
+
+// bearerToken returns the token of a bearer Authorization header, or an empty
+// string for any other scheme.
+func bearerToken(auth string) string {
+	const prefix = "Bearer "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return ""
+	}
+	return auth[len(prefix):]
+}
@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestKeyAuth(t *testing.T) {
+	tests := map[string]struct {
+		keys          []string
+		apiKey        string
+		authorization string
+		expStatusCode int
+		expBody       string
+	}{
+		"api key accepted": {
+			keys:          []string{"ops-key"},
+			apiKey:        "ops-key",
+			expStatusCode: http.StatusOK,
+		},
+		"any configured api key accepted": {
+			keys:          []string{"ops-key", "oncall-key"},
+			apiKey:        "oncall-key",
+			expStatusCode: http.StatusOK,
+		},
+		"bearer token accepted": {
+			keys:          []string{"ops-key"},
+			authorization: "Bearer ops-key",
+			expStatusCode: http.StatusOK,
+		},
+		"invalid bearer token rejected": {
+			keys:          []string{"ops-key"},
+			authorization: "Bearer nope",
+			expStatusCode: http.StatusUnauthorized,
+			expBody:       `"api key is invalid"`,
+		},
+		"basic auth rejected": {
+			keys:          []string{"ops-key"},
+			authorization: "Basic b3BzLWtleQ==",
+			expStatusCode: http.StatusUnauthorized,
+			expBody:       `"an api key is required"`,
+		},
+		"invalid api key rejected": {
+			keys:          []string{"ops-key"},
+			apiKey:        "nope",
+			expStatusCode: http.StatusUnauthorized,
+			expBody:       `"api key is invalid"`,
+		},
+		"missing api key rejected": {
+			keys:          []string{"ops-key"},
+			expStatusCode: http.StatusUnauthorized,
+			expBody:       `"an api key is required"`,
+		},
+		"no keys configured rejects everything": {
+			apiKey:        "ops-key",
+			expStatusCode: http.StatusUnauthorized,
+			expBody:       `"api key is invalid"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			e.GET("/metrics", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.KeyAuth(test.keys))
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expStatusCode, rec.Code)
+			if test.expBody != "" {
+				assert.JSONEq(t, test.expBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+const (
+	headerAPIKey    = "X-API-Key"
+	headerSignature = "X-Signature"
+	headerTimestamp = "X-Timestamp"
+
+	// RejectUnauthenticated is the reason given for requests with missing or
+	// invalid credentials.
+	RejectUnauthenticated = "unauthenticated"
+	// RejectForbidden is the reason given for requests from an address that
+	// isn't allowed.
+	RejectForbidden = "forbidden"
+)
+
+// ProofsAuth will reject proofs that aren't sent by a known broadcaster, the
+// broadcaster of accepted proofs is added to the request context.
+//
+// onReject is called with the reason for each request rejected. The address
+// checked is read by extractIP, see TrustedProxyIP.
+func ProofsAuth(auth server.ProofSourceAuthenticator, extractIP echo.IPExtractor,
+	onReject func(reason string)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			args := server.ProofSourceArgs{
+				APIKey:    req.Header.Get(headerAPIKey),
+				Signature: req.Header.Get(headerSignature),
+				Timestamp: req.Header.Get(headerTimestamp),
+				Method:    req.Method,
+				URI:       req.URL.RequestURI(),
+				IP:        extractIP(req),
+			}
+			if args.Signature != "" {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return errors.Wrap(err, "failed to read proof")
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				args.Body = body
+			}
+			broadcaster, err := auth.ProofSourceAuthenticate(req.Context(), args)
+			if err != nil {
+				switch {
+				case lathos.IsNotAuthenticated(err):
+					onReject(RejectUnauthenticated)
+				case lathos.IsNotAuthorised(err):
+					onReject(RejectForbidden)
+				}
+				return err
+			}
+			c.SetRequest(req.WithContext(server.WithBroadcaster(req.Context(), broadcaster)))
+			return next(c)
+		}
+	}
+}
+
+// TrustedProxyIP returns an extractor reading the client address from the
+// X-Forwarded-For header set by the proxies at trustedProxies, which are IPs
+// or CIDRs. Entries that aren't are ignored.
+//
+// If trustedProxies is empty the address of the connecting peer is used and
+// forwarded for headers are ignored.
+func TrustedProxyIP(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, entry := range trustedProxies {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			opts = append(opts, echo.TrustIPRange(ipNet))
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			opts = append(opts, echo.TrustIPRange(&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
@@ -0,0 +1,163 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/service"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestProofsAuth(t *testing.T) {
+	const body = `{"payload":"{}"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	tests := map[string]struct {
+		cfg            config.ProofsAuth
+		target         string
+		headers        map[string]string
+		remoteAddr     string
+		expStatusCode  int
+		expBroadcaster string
+		expRejected    []string
+	}{
+		"api key accepted": {
+			cfg:            config.ProofsAuth{Keys: map[string]string{"arc": "arc-key"}},
+			headers:        map[string]string{"X-API-Key": "arc-key"},
+			expStatusCode:  http.StatusCreated,
+			expBroadcaster: "arc",
+		},
+		"signed request accepted": {
+			cfg:    config.ProofsAuth{Secrets: map[string]string{"mapi": "sssh"}},
+			target: "/proofs?i=1",
+			headers: map[string]string{
+				"X-Signature": service.ProofSignature("sssh", http.MethodPost, "/proofs?i=1", now, []byte(body)),
+				"X-Timestamp": now,
+			},
+			expStatusCode:  http.StatusCreated,
+			expBroadcaster: "mapi",
+		},
+		"signed request replayed to another query rejected": {
+			cfg:    config.ProofsAuth{Secrets: map[string]string{"mapi": "sssh"}},
+			target: "/proofs?i=2",
+			headers: map[string]string{
+				"X-Signature": service.ProofSignature("sssh", http.MethodPost, "/proofs?i=1", now, []byte(body)),
+				"X-Timestamp": now,
+			},
+			expStatusCode: http.StatusUnauthorized,
+			expRejected:   []string{middleware.RejectUnauthenticated},
+		},
+		"named broadcaster doesn't select the secret": {
+			cfg: config.ProofsAuth{Secrets: map[string]string{"mapi": "sssh", "arc": "other"}},
+			headers: map[string]string{
+				"X-Broadcaster": "arc",
+				"X-Signature":   service.ProofSignature("sssh", http.MethodPost, "/proofs", now, []byte(body)),
+				"X-Timestamp":   now,
+			},
+			expStatusCode:  http.StatusCreated,
+			expBroadcaster: "mapi",
+		},
+		"allowlisted address accepted": {
+			cfg:            config.ProofsAuth{Allowlist: []string{"10.0.0.0/8"}},
+			remoteAddr:     "10.1.2.3:4000",
+			expStatusCode:  http.StatusCreated,
+			expBroadcaster: "10.1.2.3",
+		},
+		"address forwarded by trusted proxy accepted": {
+			cfg: config.ProofsAuth{
+				Allowlist:      []string{"10.0.0.0/8"},
+				TrustedProxies: []string{"192.0.2.1"},
+			},
+			headers:        map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			remoteAddr:     "192.0.2.1:4000",
+			expStatusCode:  http.StatusCreated,
+			expBroadcaster: "10.1.2.3",
+		},
+		"address forwarded by untrusted proxy ignored": {
+			cfg: config.ProofsAuth{
+				Allowlist:      []string{"10.0.0.0/8"},
+				TrustedProxies: []string{"192.0.2.0/24"},
+			},
+			headers:       map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			remoteAddr:    "198.51.100.1:4000",
+			expStatusCode: http.StatusForbidden,
+			expRejected:   []string{middleware.RejectForbidden},
+		},
+		"forwarded address ignored": {
+			cfg:           config.ProofsAuth{Allowlist: []string{"10.0.0.0/8"}},
+			headers:       map[string]string{"X-Forwarded-For": "10.1.2.3", "X-Real-IP": "10.1.2.3"},
+			remoteAddr:    "192.0.2.1:4000",
+			expStatusCode: http.StatusForbidden,
+			expRejected:   []string{middleware.RejectForbidden},
+		},
+		"invalid api key rejected": {
+			cfg:           config.ProofsAuth{Keys: map[string]string{"arc": "arc-key"}},
+			headers:       map[string]string{"X-API-Key": "nope"},
+			expStatusCode: http.StatusUnauthorized,
+			expRejected:   []string{middleware.RejectUnauthenticated},
+		},
+		"signature of another body rejected": {
+			cfg: config.ProofsAuth{Secrets: map[string]string{"mapi": "sssh"}},
+			headers: map[string]string{
+				"X-Signature": service.ProofSignature("sssh", http.MethodPost, "/proofs", now, []byte(`{}`)),
+				"X-Timestamp": now,
+			},
+			expStatusCode: http.StatusUnauthorized,
+			expRejected:   []string{middleware.RejectUnauthenticated},
+		},
+		"missing credentials rejected": {
+			cfg:           config.ProofsAuth{Keys: map[string]string{"arc": "arc-key"}},
+			expStatusCode: http.StatusUnauthorized,
+			expRejected:   []string{middleware.RejectUnauthenticated},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var rejected []string
+			var broadcaster, received string
+			e := echo.New()
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			e.POST("/proofs", func(c echo.Context) error {
+				broadcaster = server.Broadcaster(c.Request().Context())
+				bb, _ := io.ReadAll(c.Request().Body)
+				received = string(bb)
+				return c.NoContent(http.StatusCreated)
+			}, middleware.ProofsAuth(service.NewProofSourceAuth(&test.cfg), middleware.TrustedProxyIP(test.cfg.TrustedProxies),
+				func(reason string) {
+					rejected = append(rejected, reason)
+				}))
+
+			target := test.target
+			if target == "" {
+				target = "/proofs"
+			}
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			if test.remoteAddr != "" {
+				req.RemoteAddr = test.remoteAddr
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expStatusCode, rec.Code)
+			assert.Equal(t, test.expRejected, rejected)
+			assert.Equal(t, test.expBroadcaster, broadcaster)
+			if test.expStatusCode == http.StatusCreated {
+				assert.Equal(t, body, received)
+			}
+		})
+	}
+}
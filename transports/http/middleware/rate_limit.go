@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/theflyingcodr/lathos"
+	"golang.org/x/time/rate"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+)
+
+// Scopes a request can be rate limited in.
+const (
+	RateLimitIP        = "ip"
+	RateLimitPaymentID = "paymentid"
+	RateLimitNotFound  = "notfound"
+)
+
+// limiterIdle is how long the limiter of a client IP or paymentID is kept
+// after its last request.
+const limiterIdle = 10 * time.Minute
+
+// RateLimit will limit requests per client IP and per paymentID. Each 404 a
+// client IP receives is also counted against the stricter not found limit to
+// stop paymentIDs being enumerated, once it's used up every request from the
+// client is limited.
+//
+// Limited requests get a 429 with a Retry-After header and onLimit is called
+// with the scope they were limited in.
+func RateLimit(cfg config.RateLimit, onLimit func(scope string)) echo.MiddlewareFunc {
+	extractIP := echo.ExtractIPDirect()
+	if cfg.TrustProxy {
+		extractIP = echo.ExtractIPFromXFFHeader()
+	}
+	ips := newLimiters(cfg.IP)
+	paymentIDs := newLimiters(cfg.PaymentID)
+	notFound := newLimiters(cfg.NotFound)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			now := time.Now()
+			ip := extractIP(c.Request())
+			if d := notFound.wait(ip, now); d > 0 {
+				return limited(c, onLimit, RateLimitNotFound, d)
+			}
+			if d := ips.take(ip, now); d > 0 {
+				return limited(c, onLimit, RateLimitIP, d)
+			}
+			if paymentID := c.Param("paymentID"); paymentID != "" {
+				if d := paymentIDs.take(paymentID, now); d > 0 {
+					return limited(c, onLimit, RateLimitPaymentID, d)
+				}
+			}
+			err := next(c)
+			if isNotFound(c, err) {
+				notFound.take(ip, now)
+			}
+			return err
+		}
+	}
+}
+
+// limited responds to a rate limited request.
+func limited(c echo.Context, onLimit func(scope string), scope string, retryAfter time.Duration) error {
+	onLimit(scope)
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+	return c.JSON(http.StatusTooManyRequests, fmt.Sprintf("Too Many Requests: retry after %d seconds", secs))
+}
+
+// isNotFound returns true if the request is answered with a 404.
+func isNotFound(c echo.Context, err error) bool {
+	if err == nil {
+		return c.Response().Status == http.StatusNotFound
+	}
+	return lathos.IsNotFound(err) || errors.Is(err, echo.ErrNotFound)
+}
+
+// limiters holds a token bucket for each key, buckets idle for limiterIdle
+// are dropped.
+type limiters struct {
+	mu      sync.Mutex
+	bucket  config.TokenBucket
+	buckets map[string]*limiter
+	swept   time.Time
+}
+
+type limiter struct {
+	lim  *rate.Limiter
+	seen time.Time
+}
+
+func newLimiters(bucket config.TokenBucket) *limiters {
+	return &limiters{
+		bucket:  bucket,
+		buckets: map[string]*limiter{},
+		swept:   time.Now(),
+	}
+}
+
+// take will use a token from the bucket of key, if it's empty no token is used
+// and the time until one is available is returned.
+func (l *limiters) take(key string, now time.Time) time.Duration {
+	r := l.get(key, now).ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return d
+	}
+	return 0
+}
+
+// wait returns the time until the bucket of key has a token, without using it.
+func (l *limiters) wait(key string, now time.Time) time.Duration {
+	r := l.get(key, now).ReserveN(now, 1)
+	defer r.CancelAt(now)
+	return r.DelayFrom(now)
+}
+
+// get returns the limiter for key, creating it if needed.
+func (l *limiters) get(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.swept) > limiterIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.seen) > limiterIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &limiter{lim: rate.NewLimiter(rate.Limit(l.bucket.Rate), l.bucket.Burst)}
+		l.buckets[key] = b
+	}
+	b.seen = now
+	return b.lim
+}
@@ -0,0 +1,103 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/config"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+func TestRateLimit(t *testing.T) {
+	type request struct {
+		paymentID  string
+		remoteAddr string
+	}
+	// a rate this low never refills a token during the test.
+	slow := config.TokenBucket{Rate: 0.001, Burst: 2}
+	fast := config.TokenBucket{Rate: 1000, Burst: 1000}
+	tests := map[string]struct {
+		cfg        config.RateLimit
+		requests   []request
+		expCodes   []int
+		expLimited []string
+	}{
+		"requests under the limits are served": {
+			cfg:      config.RateLimit{IP: slow, PaymentID: slow, NotFound: slow},
+			requests: []request{{"abc", "10.0.0.1:1"}, {"abc", "10.0.0.1:1"}},
+			expCodes: []int{http.StatusOK, http.StatusOK},
+		},
+		"client ip limited across paymentIDs": {
+			cfg:        config.RateLimit{IP: slow, PaymentID: fast, NotFound: fast},
+			requests:   []request{{"abc", "10.0.0.1:1"}, {"def", "10.0.0.1:2"}, {"ghi", "10.0.0.1:3"}, {"abc", "10.0.0.2:1"}},
+			expCodes:   []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+			expLimited: []string{middleware.RateLimitIP},
+		},
+		"paymentID limited across client ips": {
+			cfg:        config.RateLimit{IP: fast, PaymentID: slow, NotFound: fast},
+			requests:   []request{{"abc", "10.0.0.1:1"}, {"abc", "10.0.0.2:1"}, {"abc", "10.0.0.3:1"}, {"def", "10.0.0.3:1"}},
+			expCodes:   []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+			expLimited: []string{middleware.RateLimitPaymentID},
+		},
+		"client limited once not found limit used": {
+			cfg: config.RateLimit{IP: fast, PaymentID: fast, NotFound: slow},
+			requests: []request{
+				{"missing1", "10.0.0.1:1"}, {"missing2", "10.0.0.1:1"}, {"abc", "10.0.0.1:1"}, {"abc", "10.0.0.2:1"},
+			},
+			expCodes:   []int{http.StatusNotFound, http.StatusNotFound, http.StatusTooManyRequests, http.StatusOK},
+			expLimited: []string{middleware.RateLimitNotFound},
+		},
+		"forwarded for ignored without trusted proxy": {
+			cfg:        config.RateLimit{IP: config.TokenBucket{Rate: 0.001, Burst: 1}, PaymentID: fast, NotFound: fast},
+			requests:   []request{{"abc", "10.0.0.1:1"}, {"abc", "10.0.0.1:1"}},
+			expCodes:   []int{http.StatusOK, http.StatusTooManyRequests},
+			expLimited: []string{middleware.RateLimitIP},
+		},
+		"forwarded for used with trusted proxy": {
+			cfg: config.RateLimit{
+				TrustProxy: true,
+				IP:         config.TokenBucket{Rate: 0.001, Burst: 1},
+				PaymentID:  fast,
+				NotFound:   fast,
+			},
+			requests: []request{{"abc", "10.0.0.1:1"}, {"abc", "10.0.0.1:1"}},
+			expCodes: []int{http.StatusOK, http.StatusOK},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var limited []string
+			e := echo.New()
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			e.GET("/payment/:paymentID", func(c echo.Context) error {
+				if c.Param("paymentID") != "abc" && c.Param("paymentID") != "def" && c.Param("paymentID") != "ghi" {
+					return client_errors.NewErrNotFoundf("404", "payment %s not found", c.Param("paymentID"))
+				}
+				return c.NoContent(http.StatusOK)
+			}, middleware.RateLimit(test.cfg, func(scope string) {
+				limited = append(limited, scope)
+			}))
+
+			for i, r := range test.requests {
+				req := httptest.NewRequest(http.MethodGet, "/payment/"+r.paymentID, nil)
+				req.RemoteAddr = r.remoteAddr
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("192.0.2.%d", i+1))
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				assert.Equal(t, test.expCodes[i], rec.Code, "request %d", i)
+				if rec.Code == http.StatusTooManyRequests {
+					assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+				}
+			}
+			assert.Equal(t, test.expLimited, limited)
+		})
+	}
+}
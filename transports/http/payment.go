@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 
+	server "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/labstack/echo/v4"
 	"github.com/libsv/go-dpp"
 	"github.com/pkg/errors"
@@ -20,9 +21,10 @@ func NewPaymentHandler(svc dpp.PaymentService) *paymentHandler {
 	}
 }
 
-// RegisterRoutes will setup all routes with an echo group.
-func (h *paymentHandler) RegisterRoutes(g *echo.Group) {
-	g.POST(RouteV1Payment, h.createPayment)
+// RegisterRoutes will setup all routes with an echo group, m is applied to
+// each route.
+func (h *paymentHandler) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.POST(RouteV1Payment, h.createPayment, m...)
 }
 
 // @Summary A user will submit an SpvEnvelope along with other information that is validated before being broadcast to the network.
@@ -31,10 +33,12 @@ func (h *paymentHandler) RegisterRoutes(g *echo.Group) {
 // @Accept json
 // @Produce json
 // @Param paymentID path string true "Payment ID"
+// @Param Idempotency-Key header string false "Unique key for the payment, retries with the same key return the original result"
 // @Param body body dpp.PaymentCreateArgs true "payment message used in BIP270"
 // @Success 201 {object} dpp.PaymentACK "if successful"
 // @Failure 404 {string} string "returned if the paymentID has not been found"
 // @Failure 400 {string} string "returned if the user input is invalid, usually an issue with the paymentID"
+// @Failure 409 {string} string "returned if the Idempotency-Key has been used for a different payment"
 // @Failure 500 {string} string "returned if there is an unexpected internal error"
 // @Router /api/v1/payment/{paymentID} [POST].
 func (h *paymentHandler) createPayment(e echo.Context) error {
@@ -45,7 +49,8 @@ func (h *paymentHandler) createPayment(e echo.Context) error {
 	if err := e.Bind(&req); err != nil {
 		return errors.WithStack(err)
 	}
-	resp, err := h.svc.PaymentCreate(e.Request().Context(), args, req)
+	ctx := server.WithIdempotencyKey(e.Request().Context(), e.Request().Header.Get(headerIdempotencyKey))
+	resp, err := h.svc.PaymentCreate(ctx, args, req)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/libsv/go-bk/envelope"
 	"github.com/libsv/go-dpp"
 	"github.com/pkg/errors"
 )
@@ -22,9 +26,10 @@ func NewPaymentTermsHandler(svc dpp.PaymentTermsService) *PaymentTermsHandler {
 	}
 }
 
-// RegisterRoutes will setup all routes with an echo group.
-func (h *PaymentTermsHandler) RegisterRoutes(g *echo.Group) {
-	g.GET(RouteV1PaymentTerms, h.buildPaymentTerms)
+// RegisterRoutes will setup all routes with an echo group, m is applied to
+// each route.
+func (h *PaymentTermsHandler) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET(RouteV1PaymentTerms, h.buildPaymentTerms, m...)
 }
 
 // buildPaymentTerms will setup and return a new payment request.
@@ -34,7 +39,9 @@ func (h *PaymentTermsHandler) RegisterRoutes(g *echo.Group) {
 // @Accept json
 // @Produce json
 // @Param paymentID path string true "Payment ID"
+// @Param If-None-Match header string false "ETag of PaymentTerms already held by the caller"
 // @Success 201 {object} envelope.JSONEnvelope "contains the signed PaymentTerms"
+// @Success 304 "returned if the PaymentTerms match the If-None-Match ETag"
 // @Failure 404 {object} server.ClientError "returned if the paymentID has not been found"
 // @Failure 400 {object} server.ClientError "returned if the user input is invalid, usually an issue with the paymentID"
 // @Failure 500 {string} string "returned if there is an unexpected internal error"
@@ -48,5 +55,38 @@ func (h *PaymentTermsHandler) buildPaymentTerms(e echo.Context) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	// Terms can be replaced or invalidated by the payee at any time so clients
+	// must revalidate, this is cheap as unchanged terms return a 304.
+	etag := paymentTermsETag(resp)
+	e.Response().Header().Set(echo.HeaderCacheControl, "private, no-cache")
+	e.Response().Header().Set(headerETag, etag)
+	if etagMatch(e.Request().Header.Get(headerIfNoneMatch), etag) {
+		return e.NoContent(http.StatusNotModified)
+	}
 	return e.JSON(http.StatusOK, resp)
 }
+
+// paymentTermsETag returns a strong ETag computed from the signed payload.
+func paymentTermsETag(env *envelope.JSONEnvelope) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(env.Payload))
+	if env.Signature != nil {
+		_, _ = h.Write([]byte(*env.Signature))
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil))
+}
+
+// etagMatch will check the If-None-Match header value for the etag, weak
+// comparison is used as per RFC 7232.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
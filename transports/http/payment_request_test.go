@@ -88,3 +88,83 @@ func TestPaymentTermsHandler_BuildPaymentTerms(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentTermsHandler_BuildPaymentTermsConditional(t *testing.T) {
+	env, err := envelope.NewJSONEnvelope(&dpp.PaymentTerms{Memo: "payment abc123"})
+	assert.NoError(t, err)
+	etag := paymentTermsETag(env)
+
+	tests := map[string]struct {
+		ifNoneMatch   string
+		expStatusCode int
+	}{
+		"no etag returns terms": {
+			expStatusCode: http.StatusOK,
+		},
+		"matching etag returns not modified": {
+			ifNoneMatch:   etag,
+			expStatusCode: http.StatusNotModified,
+		},
+		"matching weak etag in list returns not modified": {
+			ifNoneMatch:   `"abc", W/` + etag,
+			expStatusCode: http.StatusNotModified,
+		},
+		"stale etag returns terms": {
+			ifNoneMatch:   `"abc"`,
+			expStatusCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			h := NewPaymentTermsHandler(&dppMocks.PaymentTermsServiceMock{
+				PaymentTermsFunc: func(ctx context.Context, args dpp.PaymentTermsArgs) (*envelope.JSONEnvelope, error) {
+					return env, nil
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.ifNoneMatch != "" {
+				req.Header.Set(headerIfNoneMatch, test.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/api/v1/payment/:paymentID")
+			ctx.SetParamNames("paymentID")
+			ctx.SetParamValues("abc123")
+
+			assert.NoError(t, h.buildPaymentTerms(ctx))
+
+			response := rec.Result()
+			defer response.Body.Close()
+			assert.Equal(t, test.expStatusCode, response.StatusCode)
+			assert.Equal(t, etag, response.Header.Get(headerETag))
+			assert.Equal(t, "private, no-cache", response.Header.Get(echo.HeaderCacheControl))
+		})
+	}
+}
+
+func TestPaymentTermsETag(t *testing.T) {
+	sig := "3045022100"
+	tests := map[string]struct {
+		env     *envelope.JSONEnvelope
+		expETag string
+	}{
+		"unsigned terms hash the payload": {
+			env:     &envelope.JSONEnvelope{Payload: `{"memo":"abc123"}`},
+			expETag: `"ed96b63f92d0702de7af358978e989eb5cd1711560724987c6b1db91f4f52d4e"`,
+		},
+		"signed terms hash the payload and signature": {
+			env:     &envelope.JSONEnvelope{Payload: `{"memo":"abc123"}`, Signature: &sig},
+			expETag: `"d9cc61a25e776b4f5c178af35fca9dc2b852cd322c95da8d53acecc618a092a9"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expETag, paymentTermsETag(test.env))
+		})
+	}
+}
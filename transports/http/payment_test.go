@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	server "github.com/bitcoin-sv/dpp-proxy"
 	"github.com/bitcoin-sv/dpp-proxy/log"
 	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
 	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
@@ -105,3 +106,27 @@ func TestPaymentHandler_CreatedPayment(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentHandler_CreatedPaymentIdempotencyKey(t *testing.T) {
+	var key string
+	e := echo.New()
+	h := NewPaymentHandler(&dppMocks.PaymentServiceMock{
+		PaymentCreateFunc: func(ctx context.Context, args dpp.PaymentCreateArgs, req dpp.Payment) (*dpp.PaymentACK, error) {
+			key = server.IdempotencyKey(ctx)
+			return &dpp.PaymentACK{}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("{}"))
+	req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Add("Idempotency-Key", "abc-def")
+	rec := httptest.NewRecorder()
+
+	ctx := e.NewContext(req, rec)
+	ctx.SetPath("/api/v1/payment/:paymentID")
+	ctx.SetParamNames("paymentID")
+	ctx.SetParamValues("abc123")
+
+	assert.NoError(t, h.createPayment(ctx))
+	assert.Equal(t, "abc-def", key)
+}
@@ -0,0 +1,283 @@
+package http
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+)
+
+const (
+	// arcStatusMined is the ARC txStatus sent once a transaction is in a block.
+	arcStatusMined = "MINED"
+	// callbackReasonMerkleProof is the mAPI callbackReason sent with a merkle proof.
+	callbackReasonMerkleProof = "merkleProof"
+)
+
+// mapiCallback is the payload of a mAPI callback, the proof is a JSON encoded
+// string rather than an object.
+type mapiCallback struct {
+	BlockHash       string `json:"blockHash"`
+	BlockHeight     uint32 `json:"blockHeight"`
+	CallbackTxID    string `json:"callbackTxId"`
+	CallbackReason  string `json:"callbackReason"`
+	CallbackPayload string `json:"callbackPayload"`
+}
+
+// arcCallback is the body of an ARC status callback, the proof is a BUMP
+// encoded merkle path.
+type arcCallback struct {
+	TxID        string `json:"txid"`
+	TxStatus    string `json:"txStatus"`
+	BlockHash   string `json:"blockHash"`
+	BlockHeight uint32 `json:"blockHeight"`
+	MerklePath  string `json:"merklePath"`
+}
+
+// decodeProof will detect the format of a proof callback from its shape and
+// return it as a JSONEnvelope wrapping a dpp.ProofWrapper.
+//
+// The formats accepted are:
+//   - a JSONEnvelope wrapping a dpp.ProofWrapper, returned unchanged
+//   - a mAPI callback, either in a JSONEnvelope or unwrapped
+//   - an ARC callback with a BUMP merkle path
+//
+// mAPI and ARC callbacks are returned in an unsigned envelope, the signature of
+// a mAPI envelope is checked before it's removed. If the callback doesn't
+// carry a merkle proof, such as a mAPI double spend or an ARC status update, nil
+// is returned.
+//
+// If requireSig is true, only signed envelopes and signed mAPI callbacks are
+// accepted, ARC callbacks are never signed so are rejected.
+func decodeProof(body []byte, requireSig bool) (*envelope.JSONEnvelope, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, client_errors.NewBadRequestf("400", "proof could not be read: %s", err)
+	}
+	switch {
+	case fields["payload"] != nil:
+		var env envelope.JSONEnvelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, client_errors.NewBadRequestf("400", "proof envelope could not be read: %s", err)
+		}
+		if requireSig && env.Signature == nil {
+			return nil, client_errors.NewErrNotAuthenticated("401", "proof envelope must be signed")
+		}
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(env.Payload), &payload); err != nil || !isJSONString(payload["callbackPayload"]) {
+			// left for the proofs service to validate.
+			return &env, nil
+		}
+		if err := validator.New().Validate("jsonEnvelope", func() error {
+			// mAPI signs the payload as sent, IsValid strips the escapes from the
+			// callbackPayload string of json payloads so the mime type is cleared.
+			raw := env
+			raw.MimeType = ""
+			ok, err := raw.IsValid()
+			if err != nil {
+				return errors.Wrap(err, "invalid mAPI callback envelope")
+			}
+			if !ok {
+				return errors.New("invalid mAPI callback envelope signature")
+			}
+			return nil
+		}).Err(); err != nil {
+			return nil, err
+		}
+		return decodeMAPI([]byte(env.Payload))
+	case requireSig && (fields["callbackReason"] != nil || fields["txStatus"] != nil):
+		return nil, client_errors.NewErrNotAuthenticated("401", "unsigned callbacks are not accepted")
+	case fields["callbackReason"] != nil:
+		return decodeMAPI(body)
+	case fields["txStatus"] != nil:
+		return decodeARC(body)
+	}
+	return nil, client_errors.NewErrBadRequest("400", "proof format is not recognised, "+
+		"expected a JSONEnvelope, mAPI callback or ARC callback")
+}
+
+// decodeMAPI will convert a mAPI callback payload to a proof envelope.
+func decodeMAPI(payload []byte) (*envelope.JSONEnvelope, error) {
+	var cb mapiCallback
+	if err := json.Unmarshal(payload, &cb); err != nil {
+		return nil, client_errors.NewBadRequestf("400", "mAPI callback could not be read: %s", err)
+	}
+	if !strings.EqualFold(cb.CallbackReason, callbackReasonMerkleProof) {
+		return nil, nil
+	}
+	var proof bc.MerkleProof
+	if err := json.Unmarshal([]byte(cb.CallbackPayload), &proof); err != nil {
+		return nil, client_errors.NewBadRequestf("400", "mAPI callbackPayload could not be read: %s", err)
+	}
+	return proofEnvelope(dpp.ProofWrapper{
+		CallbackPayload: &proof,
+		BlockHash:       cb.BlockHash,
+		BlockHeight:     cb.BlockHeight,
+		CallbackTxID:    cb.CallbackTxID,
+		CallbackReason:  cb.CallbackReason,
+	})
+}
+
+// decodeARC will convert an ARC callback to a proof envelope.
+func decodeARC(body []byte) (*envelope.JSONEnvelope, error) {
+	var cb arcCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return nil, client_errors.NewBadRequestf("400", "ARC callback could not be read: %s", err)
+	}
+	if cb.TxStatus != arcStatusMined {
+		return nil, nil
+	}
+	proof, blockHeight, err := merkleProofFromBUMP(cb.TxID, cb.MerklePath)
+	if err != nil {
+		return nil, client_errors.NewBadRequestf("400", "ARC merklePath could not be read: %s", err)
+	}
+	if blockHeight != uint64(cb.BlockHeight) {
+		return nil, client_errors.NewBadRequestf("400", "ARC merklePath is for block %d not blockHeight %d",
+			blockHeight, cb.BlockHeight)
+	}
+	proof.Target, proof.TargetType = cb.BlockHash, "hash"
+	return proofEnvelope(dpp.ProofWrapper{
+		CallbackPayload: proof,
+		BlockHash:       cb.BlockHash,
+		BlockHeight:     cb.BlockHeight,
+		CallbackTxID:    cb.TxID,
+		CallbackReason:  callbackReasonMerkleProof,
+	})
+}
+
+// merkleProofFromBUMP will read the branch for txID from a BUMP (BRC-74) encoded
+// merkle path and return it with the block height of the path, the proof
+// returned has no target set.
+//
+// A BUMP holding several transactions omits the nodes that can be computed
+// from the level below, these are computed as needed.
+func merkleProofFromBUMP(txID, bump string) (*bc.MerkleProof, uint64, error) {
+	bb, err := hex.DecodeString(bump)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "merklePath is not hex")
+	}
+	r := bytes.NewReader(bb)
+	var blockHeight bt.VarInt
+	if _, err := blockHeight.ReadFrom(r); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read block height")
+	}
+	treeHeight, err := r.ReadByte()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read tree height")
+	}
+	levels := make(bumpLevels, treeHeight)
+	var index uint64
+	found := false
+	for h := range levels {
+		var n bt.VarInt
+		if _, err := n.ReadFrom(r); err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to read leaf count of level %d", h)
+		}
+		// every leaf takes at least 2 bytes, this stops a bad count allocating.
+		if uint64(n) > uint64(r.Len()/2) {
+			return nil, 0, errors.Errorf("leaf count of level %d is larger than the merklePath", h)
+		}
+		levels[h] = make(map[uint64]string, n)
+		for i := uint64(0); i < uint64(n); i++ {
+			var offset bt.VarInt
+			if _, err := offset.ReadFrom(r); err != nil {
+				return nil, 0, errors.Wrapf(err, "failed to read leaf offset of level %d", h)
+			}
+			flags, err := r.ReadByte()
+			if err != nil {
+				return nil, 0, errors.Wrapf(err, "failed to read leaf flags of level %d", h)
+			}
+			if flags&1 == 1 {
+				levels[h][uint64(offset)] = "*"
+				continue
+			}
+			hash := make([]byte, 32)
+			if _, err := io.ReadFull(r, hash); err != nil {
+				return nil, 0, errors.Wrapf(err, "failed to read leaf hash of level %d", h)
+			}
+			levels[h][uint64(offset)] = hex.EncodeToString(bt.ReverseBytes(hash))
+			if h == 0 && levels[h][uint64(offset)] == txID {
+				index, found = uint64(offset), true
+			}
+		}
+	}
+	if !found {
+		return nil, 0, errors.Errorf("txid %s is not in the merklePath", txID)
+	}
+	nodes := make([]string, 0, len(levels))
+	for h := range levels {
+		sibling, err := levels.node(h, (index>>h)^1)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "level %d has no sibling for txid %s", h, txID)
+		}
+		nodes = append(nodes, sibling)
+	}
+	return &bc.MerkleProof{
+		Index:  index,
+		TxOrID: txID,
+		Nodes:  nodes,
+	}, uint64(blockHeight), nil
+}
+
+// bumpLevels holds the hashes at each offset of every level of a BUMP, "*"
+// marks a duplicate of its sibling.
+type bumpLevels []map[uint64]string
+
+// node returns the hash at offset of level h, computing it from its children
+// if the BUMP omits it. Computed hashes are kept for later calls.
+func (l bumpLevels) node(h int, offset uint64) (string, error) {
+	if hash, ok := l[h][offset]; ok {
+		return hash, nil
+	}
+	if h == 0 {
+		return "", errors.Errorf("leaf %d is missing", offset)
+	}
+	left, err := l.node(h-1, offset*2)
+	if err != nil {
+		return "", err
+	}
+	if left == "*" {
+		return "", errors.Errorf("leaf %d of level %d is a duplicate without a sibling", offset*2, h-1)
+	}
+	right, err := l.node(h-1, offset*2+1)
+	if err != nil {
+		return "", err
+	}
+	if right == "*" {
+		right = left
+	}
+	hash, err := bc.MerkleTreeParentStr(left, right)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to compute node %d of level %d", offset, h)
+	}
+	l[h][offset] = hash
+	return hash, nil
+}
+
+// proofEnvelope will wrap a proof in an unsigned JSONEnvelope.
+func proofEnvelope(proof dpp.ProofWrapper) (*envelope.JSONEnvelope, error) {
+	bb, err := json.Marshal(proof)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode proof")
+	}
+	return &envelope.JSONEnvelope{
+		Payload:  string(bb),
+		Encoding: "UTF-8",
+		MimeType: "application/json",
+	}, nil
+}
+
+// isJSONString returns true if the raw message is a JSON string.
+func isJSONString(raw json.RawMessage) bool {
+	return len(raw) > 0 && raw[0] == '"'
+}
@@ -1,49 +1,67 @@
 package http
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
-	"github.com/libsv/go-bk/envelope"
 	"github.com/libsv/go-dpp"
 	"github.com/pkg/errors"
+
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
 )
 
+// maxProofBytes is the largest proof body accepted.
+const maxProofBytes = 1 << 20
+
 // proofs is used to accept merkle proofs from transactions
 // submitted by the payment protocol server.
 type proofs struct {
-	svc dpp.ProofsService
+	svc        dpp.ProofsService
+	requireSig bool
 }
 
-// NewProofs will setup and return a new proofs http handler.
-func NewProofs(svc dpp.ProofsService) *proofs {
-	return &proofs{svc: svc}
+// NewProofs will setup and return a new proofs http handler, if requireSig is
+// true unsigned proofs and callbacks are rejected.
+func NewProofs(svc dpp.ProofsService, requireSig bool) *proofs {
+	return &proofs{svc: svc, requireSig: requireSig}
 }
 
-// RegisterRoutes will setup all proof routes with the supplied echo group.
-func (p *proofs) RegisterRoutes(g *echo.Group) {
-	g.POST(RouteV1Proofs, p.create)
+// RegisterRoutes will setup all proof routes with the supplied echo group,
+// m is applied to each route after the body is limited to maxProofBytes.
+func (p *proofs) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.POST(RouteV1Proofs, p.create, append([]echo.MiddlewareFunc{middleware.BodyLimit(maxProofBytes)}, m...)...)
 }
 
 // create godoc
 // @Summary InvoiceCreate proof
-// @Description Creates a json envelope proof
+// @Description Creates a json envelope proof, ARC callbacks and mAPI callbacks, signed or not, are also accepted
+// @Description and converted to a json envelope proof. Callbacks without a merkle proof are acknowledged and dropped.
+// @Description When signatures are required, unsigned envelopes and callbacks are rejected with a 401.
 // @Tags Proofs
 // @Accept json
 // @Produce json
 // @Param txid path string true "Transaction ID"
 // @Param body body envelope.JSONEnvelope true "JSON Envelope"
 // @Success 201
+// @Success 204
 // @Router /api/v1/proofs/{txid} [POST].
 func (p *proofs) create(c echo.Context) error {
-	var req envelope.JSONEnvelope
-	if err := c.Bind(&req); err != nil {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read proof")
+	}
+	req, err := decodeProof(body, p.requireSig)
+	if err != nil {
 		return errors.WithStack(err)
 	}
+	if req == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
 	args := dpp.ProofCreateArgs{TxID: c.Param("txid"),
 		PaymentReference: c.QueryParam("i"),
 	}
-	if err := p.svc.Create(c.Request().Context(), args, req); err != nil {
+	if err := p.svc.Create(c.Request().Context(), args, *req); err != nil {
 		return errors.WithStack(err)
 	}
 	return c.NoContent(http.StatusCreated)
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+const (
+	// maxProofBatch is the most proofs accepted in a single batch.
+	maxProofBatch = 1000
+	// maxProofBatchBytes is the largest batch body accepted.
+	maxProofBatchBytes = 16 << 20
+)
+
+// proofBatchRequest is a single proof sent to the batch endpoint, the proof can
+// be in any format accepted by the proofs endpoint.
+type proofBatchRequest struct {
+	TxID             string          `json:"txid"`
+	PaymentReference string          `json:"paymentReference"`
+	Proof            json.RawMessage `json:"proof"`
+}
+
+// proofBatchResult is the outcome of a single proof in a batch, Status is the
+// http status the proof would have received if sent on its own.
+type proofBatchResult struct {
+	TxID             string      `json:"txid"`
+	PaymentReference string      `json:"paymentReference"`
+	Status           int         `json:"status"`
+	Error            interface{} `json:"error,omitempty"`
+}
+
+// proofsBatch is used to accept the merkle proofs of many transactions at once,
+// such as when a block is mined.
+type proofsBatch struct {
+	l          log.Logger
+	svc        server.ProofsBatchService
+	requireSig bool
+}
+
+// NewProofsBatch will setup and return a new batch proofs http handler, if
+// requireSig is true unsigned proofs and callbacks are rejected.
+func NewProofsBatch(l log.Logger, svc server.ProofsBatchService, requireSig bool) *proofsBatch {
+	return &proofsBatch{l: l, svc: svc, requireSig: requireSig}
+}
+
+// RegisterRoutes will setup all batch proof routes with the supplied echo group,
+// m is applied to each route after the body is limited to maxProofBatchBytes.
+func (p *proofsBatch) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.POST(RouteV1ProofsBatch, p.create, append([]echo.MiddlewareFunc{middleware.BodyLimit(maxProofBatchBytes)}, m...)...)
+}
+
+// create godoc
+// @Summary Create proofs in a batch
+// @Description Creates many proofs at once, each proof can be in any format accepted by /api/v1/proofs/{txid}.
+// @Description The status and error of each proof is returned in the order sent.
+// @Tags Proofs
+// @Accept json
+// @Produce json
+// @Param body body []proofBatchRequest true "Proofs"
+// @Success 200 {array} proofBatchResult
+// @Failure 400 {string} string "returned if the batch can't be read or is too large"
+// @Router /api/v1/proofs [POST].
+func (p *proofsBatch) create(c echo.Context) error {
+	var reqs []proofBatchRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&reqs); err != nil {
+		return client_errors.NewBadRequestf("400", "proof batch could not be read: %s", err)
+	}
+	if len(reqs) > maxProofBatch {
+		return client_errors.NewBadRequestf("400", "proof batch has %d proofs, at most %d are accepted", len(reqs), maxProofBatch)
+	}
+	results := make([]proofBatchResult, len(reqs))
+	items := make([]server.ProofBatchItem, 0, len(reqs))
+	// idx maps each item sent to the service back to its result.
+	idx := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		results[i] = proofBatchResult{
+			TxID:             req.TxID,
+			PaymentReference: req.PaymentReference,
+			Status:           http.StatusCreated,
+		}
+		env, err := decodeProof(req.Proof, p.requireSig)
+		if err != nil {
+			results[i].Status, results[i].Error = middleware.ErrorResponse(p.l, err)
+			continue
+		}
+		if env == nil {
+			results[i].Status = http.StatusNoContent
+			continue
+		}
+		items = append(items, server.ProofBatchItem{
+			Args:  dpp.ProofCreateArgs{TxID: req.TxID, PaymentReference: req.PaymentReference},
+			Proof: *env,
+		})
+		idx = append(idx, i)
+	}
+	if len(items) > 0 {
+		errs := p.svc.ProofsCreate(c.Request().Context(), items)
+		if len(errs) != len(items) {
+			return errors.Errorf("proof batch returned %d results for %d proofs", len(errs), len(items))
+		}
+		for n, err := range errs {
+			if err != nil {
+				results[idx[n]].Status, results[idx[n]].Error = middleware.ErrorResponse(p.l, err)
+			}
+		}
+	}
+	return c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/client_errors"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+type proofsBatchSvc struct {
+	items []server.ProofBatchItem
+	errs  []error
+}
+
+func (p *proofsBatchSvc) ProofsCreate(ctx context.Context, items []server.ProofBatchItem) []error {
+	p.items = items
+	return p.errs
+}
+
+func TestProofsBatch_Create(t *testing.T) {
+	proof := func(txID string) json.RawMessage {
+		env, err := envelope.NewJSONEnvelope(dpp.ProofWrapper{
+			CallbackPayload: &bc.MerkleProof{TxOrID: txID, Target: "abc", TargetType: "hash"},
+			BlockHash:       "abc",
+			CallbackTxID:    txID,
+			CallbackReason:  "merkleProof",
+		})
+		assert.NoError(t, err)
+		bb, err := json.Marshal(env)
+		assert.NoError(t, err)
+		return bb
+	}
+	tests := map[string]struct {
+		body          interface{}
+		errs          []error
+		expStatusCode int
+		expItems      int
+		expResults    []proofBatchResult
+	}{
+		"all proofs created": {
+			body: []proofBatchRequest{
+				{TxID: "tx1", PaymentReference: "abc123", Proof: proof("tx1")},
+				{TxID: "tx2", PaymentReference: "def456", Proof: proof("tx2")},
+			},
+			errs:          []error{nil, nil},
+			expStatusCode: http.StatusOK,
+			expItems:      2,
+			expResults: []proofBatchResult{
+				{TxID: "tx1", PaymentReference: "abc123", Status: http.StatusCreated},
+				{TxID: "tx2", PaymentReference: "def456", Status: http.StatusCreated},
+			},
+		},
+		"failures reported per proof": {
+			body: []proofBatchRequest{
+				{TxID: "tx1", PaymentReference: "abc123", Proof: proof("tx1")},
+				{TxID: "tx2", PaymentReference: "def456", Proof: json.RawMessage(`{"hello":"world"}`)},
+				{TxID: "tx3", PaymentReference: "ghi789", Proof: json.RawMessage(`{"txid":"tx3","txStatus":"SEEN_ON_NETWORK"}`)},
+				{TxID: "tx4", PaymentReference: "jkl012", Proof: proof("tx4")},
+			},
+			errs:          []error{client_errors.NewErrUnprocessable("422", "block abc is not known"), nil},
+			expStatusCode: http.StatusOK,
+			expItems:      2,
+			expResults: []proofBatchResult{
+				{TxID: "tx1", PaymentReference: "abc123", Status: http.StatusUnprocessableEntity, Error: "block abc is not known"},
+				{TxID: "tx2", PaymentReference: "def456", Status: http.StatusBadRequest,
+					Error: "proof format is not recognised, expected a JSONEnvelope, mAPI callback or ARC callback"},
+				{TxID: "tx3", PaymentReference: "ghi789", Status: http.StatusNoContent},
+				{TxID: "tx4", PaymentReference: "jkl012", Status: http.StatusCreated},
+			},
+		},
+		"malformed batch rejected": {
+			body:          map[string]string{"hello": "world"},
+			expStatusCode: http.StatusBadRequest,
+		},
+		"oversized batch rejected": {
+			body:          make([]proofBatchRequest, maxProofBatch+1),
+			expStatusCode: http.StatusBadRequest,
+		},
+		"batch over the size limit rejected": {
+			body: []proofBatchRequest{
+				{TxID: "tx1", PaymentReference: "abc123", Proof: json.RawMessage(`"` + strings.Repeat("a", maxProofBatchBytes) + `"`)},
+			},
+			expStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.HideBanner = true
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			svc := &proofsBatchSvc{errs: test.errs}
+			NewProofsBatch(log.Noop{}, svc, false).RegisterRoutes(e.Group("/"))
+
+			body, err := json.Marshal(test.body)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/proofs", bytes.NewBuffer(body))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expStatusCode, rec.Code)
+			assert.Len(t, svc.items, test.expItems)
+			if test.expResults == nil {
+				return
+			}
+			var results []proofBatchResult
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+			assert.Equal(t, test.expResults, results)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-dpp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitcoin-sv/dpp-proxy/log"
+	"github.com/bitcoin-sv/dpp-proxy/transports/http/middleware"
+)
+
+type proofsSvc struct {
+	args dpp.ProofCreateArgs
+	env  *envelope.JSONEnvelope
+}
+
+func (p *proofsSvc) Create(ctx context.Context, args dpp.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	p.args, p.env = args, &req
+	return nil
+}
+
+func TestProofs_Create(t *testing.T) {
+	const (
+		txID      = "3e6f4e7f5a6b2b0c8d9e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e"
+		sibling   = "b1ba8a9e3c7c4c6a1b3dd8e7e2cf09a0a5c5f5fbf3a2a1e3c8d9e1f0a3b4c5d6"
+		blockHash = "0000000000000000041c4b2a3a7b0b3c5c1a8b9a1f7c6e5d4c3b2a1908f7e6d5"
+	)
+	leaf := func(offset, flags byte, hash string) []byte {
+		bb, err := hex.DecodeString(hash)
+		assert.NoError(t, err)
+		return append([]byte{offset, flags}, bt.ReverseBytes(bb)...)
+	}
+	// a BUMP for block 100 holding the tx and its sibling.
+	bump := append([]byte{100, 1, 2}, leaf(0, 0, sibling)...)
+	bump = append(bump, leaf(1, 2, txID)...)
+	// a BUMP for block 100 holding two txs of a four tx block, level 1 is
+	// omitted as both nodes are computed from level 0.
+	const (
+		txID2    = "5d4c3b2a1908f7e6d5c4b3a291807f6e5d4c3b2a1908f7e6d5c4b3a291807f6e"
+		sibling2 = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	)
+	compound := append([]byte{100, 2, 4}, leaf(0, 0, sibling)...)
+	compound = append(compound, leaf(1, 2, txID)...)
+	compound = append(compound, leaf(2, 2, txID2)...)
+	compound = append(compound, leaf(3, 0, sibling2)...)
+	compound = append(compound, 0)
+	parent := func(l, r string) string {
+		p, err := bc.MerkleTreeParentStr(l, r)
+		assert.NoError(t, err)
+		return p
+	}
+	proof := &bc.MerkleProof{
+		Index:      1,
+		TxOrID:     txID,
+		Target:     blockHash,
+		TargetType: "hash",
+		Nodes:      []string{sibling},
+	}
+	wrapper := dpp.ProofWrapper{
+		CallbackPayload: proof,
+		BlockHash:       blockHash,
+		BlockHeight:     100,
+		CallbackTxID:    txID,
+		CallbackReason:  "merkleProof",
+	}
+	mapi := func(reason string) map[string]interface{} {
+		bb, err := json.Marshal(proof)
+		assert.NoError(t, err)
+		return map[string]interface{}{
+			"apiVersion":      "1.4.0",
+			"timestamp":       "2022-10-11T10:00:00Z",
+			"blockHash":       blockHash,
+			"blockHeight":     100,
+			"callbackTxId":    txID,
+			"callbackReason":  reason,
+			"callbackPayload": string(bb),
+		}
+	}
+	signed := func(v interface{}) *envelope.JSONEnvelope {
+		env, err := envelope.NewJSONEnvelope(v)
+		assert.NoError(t, err)
+		return env
+	}
+	dppEnvelope := signed(wrapper)
+	compoundWrapper := wrapper
+	compoundWrapper.CallbackPayload = &bc.MerkleProof{
+		Index:      1,
+		TxOrID:     txID,
+		Target:     blockHash,
+		TargetType: "hash",
+		Nodes:      []string{sibling, parent(txID2, sibling2)},
+	}
+	arc := func(bump []byte, height int) map[string]interface{} {
+		return map[string]interface{}{
+			"timestamp":   "2023-10-11T10:00:00Z",
+			"txid":        txID,
+			"txStatus":    "MINED",
+			"blockHash":   blockHash,
+			"blockHeight": height,
+			"merklePath":  hex.EncodeToString(bump),
+		}
+	}
+
+	tests := map[string]struct {
+		body          interface{}
+		requireSig    bool
+		expStatusCode int
+		expEnvelope   *envelope.JSONEnvelope
+		expProof      *dpp.ProofWrapper
+	}{
+		"dpp envelope passed through": {
+			body:          dppEnvelope,
+			expStatusCode: http.StatusCreated,
+			expEnvelope:   dppEnvelope,
+		},
+		"signed mAPI callback converted": {
+			body:          signed(mapi("merkleProof")),
+			expStatusCode: http.StatusCreated,
+			expProof:      &wrapper,
+		},
+		"unwrapped mAPI callback converted": {
+			body:          mapi("merkleProof"),
+			expStatusCode: http.StatusCreated,
+			expProof:      &wrapper,
+		},
+		"tampered mAPI callback rejected": {
+			body: func() *envelope.JSONEnvelope {
+				env := signed(mapi("merkleProof"))
+				cb := mapi("merkleProof")
+				cb["blockHeight"] = 101
+				bb, err := json.Marshal(cb)
+				assert.NoError(t, err)
+				env.Payload = string(bb)
+				return env
+			}(),
+			expStatusCode: http.StatusBadRequest,
+		},
+		"mAPI double spend callback dropped": {
+			body:          mapi("doubleSpendAttempt"),
+			expStatusCode: http.StatusNoContent,
+		},
+		"ARC mined callback converted": {
+			body: map[string]interface{}{
+				"timestamp":   "2023-10-11T10:00:00Z",
+				"txid":        txID,
+				"txStatus":    "MINED",
+				"blockHash":   blockHash,
+				"blockHeight": 100,
+				"merklePath":  hex.EncodeToString(bump),
+			},
+			expStatusCode: http.StatusCreated,
+			expProof:      &wrapper,
+		},
+		"ARC callback with compound merkle path converted": {
+			body:          arc(compound, 100),
+			expStatusCode: http.StatusCreated,
+			expProof:      &compoundWrapper,
+		},
+		"ARC callback with merkle path for another block rejected": {
+			body:          arc(bump, 101),
+			expStatusCode: http.StatusBadRequest,
+		},
+		"ARC callback with merkle path missing a node rejected": {
+			body:          arc(append(append([]byte{100, 2, 1}, leaf(1, 2, txID)...), 0), 100),
+			expStatusCode: http.StatusBadRequest,
+		},
+		"signed dpp envelope accepted when signature required": {
+			body:          dppEnvelope,
+			requireSig:    true,
+			expStatusCode: http.StatusCreated,
+			expEnvelope:   dppEnvelope,
+		},
+		"signed mAPI callback accepted when signature required": {
+			body:          signed(mapi("merkleProof")),
+			requireSig:    true,
+			expStatusCode: http.StatusCreated,
+			expProof:      &wrapper,
+		},
+		"unsigned dpp envelope rejected when signature required": {
+			body:          &envelope.JSONEnvelope{Payload: dppEnvelope.Payload},
+			requireSig:    true,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		"unwrapped mAPI callback rejected when signature required": {
+			body:          mapi("merkleProof"),
+			requireSig:    true,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		"ARC callback rejected when signature required": {
+			body:          arc(bump, 100),
+			requireSig:    true,
+			expStatusCode: http.StatusUnauthorized,
+		},
+		"ARC status callback dropped": {
+			body: map[string]interface{}{
+				"timestamp": "2023-10-11T10:00:00Z",
+				"txid":      txID,
+				"txStatus":  "SEEN_ON_NETWORK",
+			},
+			expStatusCode: http.StatusNoContent,
+		},
+		"ARC callback for tx not in merkle path rejected": {
+			body: map[string]interface{}{
+				"txid":        sibling[:60] + "0000",
+				"txStatus":    "MINED",
+				"blockHash":   blockHash,
+				"blockHeight": 100,
+				"merklePath":  hex.EncodeToString(bump),
+			},
+			expStatusCode: http.StatusBadRequest,
+		},
+		"ARC callback with truncated merkle path rejected": {
+			body: map[string]interface{}{
+				"txid":        txID,
+				"txStatus":    "MINED",
+				"blockHash":   blockHash,
+				"blockHeight": 100,
+				"merklePath":  hex.EncodeToString(bump[:40]),
+			},
+			expStatusCode: http.StatusBadRequest,
+		},
+		"unknown format rejected": {
+			body:          map[string]interface{}{"hello": "world"},
+			expStatusCode: http.StatusBadRequest,
+		},
+		"proof over the size limit rejected": {
+			body:          map[string]interface{}{"hello": strings.Repeat("a", maxProofBytes)},
+			expStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.HideBanner = true
+			e.HTTPErrorHandler = middleware.ErrorHandler(log.Noop{})
+			svc := &proofsSvc{}
+			NewProofs(svc, test.requireSig).RegisterRoutes(e.Group("/"))
+
+			body, err := json.Marshal(test.body)
+			assert.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/proofs/"+txID+"?i=abc123", bytes.NewBuffer(body))
+			req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expStatusCode, rec.Code)
+			if test.expStatusCode != http.StatusCreated {
+				assert.Nil(t, svc.env)
+				return
+			}
+			assert.Equal(t, dpp.ProofCreateArgs{TxID: txID, PaymentReference: "abc123"}, svc.args)
+			if test.expEnvelope != nil {
+				assert.Equal(t, test.expEnvelope, svc.env)
+				return
+			}
+			assert.Nil(t, svc.env.Signature)
+			var got dpp.ProofWrapper
+			assert.NoError(t, json.Unmarshal([]byte(svc.env.Payload), &got))
+			assert.Equal(t, *test.expProof, got)
+		})
+	}
+}
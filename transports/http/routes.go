@@ -2,7 +2,27 @@ package http
 
 // Routes used in the http handlers.
 const (
-	RouteV1PaymentTerms = "api/v1/payment/:paymentID"
-	RouteV1Payment      = "api/v1/payment/:paymentID"
-	RouteV1Proofs       = "api/v1/proofs/:txid"
+	RouteV1PaymentTerms  = "api/v1/payment/:paymentID"
+	RouteV1Payment       = "api/v1/payment/:paymentID"
+	RouteV1PaymentStatus = "api/v1/payment/:paymentID/status"
+	RouteV1Proofs        = "api/v1/proofs/:txid"
+	RouteV1ProofsBatch   = "api/v1/proofs"
+	RouteHealthz         = "healthz"
+	RouteReadyz          = "readyz"
+	RouteVersion         = "version"
+
+	RouteV1AdminChannels = "api/v1/admin/channels"
+	RouteV1AdminChannel  = "api/v1/admin/channels/:channelID"
+	RouteV1AdminClients  = "api/v1/admin/clients"
+	RouteV1AdminClient   = "api/v1/admin/clients/:clientID"
+	RouteV1AdminAwaits   = "api/v1/admin/awaits"
+	RouteV1AdminLedger   = "api/v1/admin/ledger/:paymentID"
+)
+
+// Headers used in the http handlers.
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+
+	headerIdempotencyKey = "Idempotency-Key"
 )
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	server "github.com/bitcoin-sv/dpp-proxy"
+)
+
+// socketAdminHandler is an http handler letting operators inspect and close
+// the websockets held by a node.
+type socketAdminHandler struct {
+	svc server.SocketAdminService
+}
+
+// NewSocketAdminHandler will create and return a new socket admin handler.
+func NewSocketAdminHandler(svc server.SocketAdminService) *socketAdminHandler {
+	return &socketAdminHandler{svc: svc}
+}
+
+// RegisterRoutes will setup all routes with an echo group, m is applied to
+// each route.
+func (h *socketAdminHandler) RegisterRoutes(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET(RouteV1AdminChannels, h.channels, m...)
+	g.GET(RouteV1AdminChannel, h.channel, m...)
+	g.DELETE(RouteV1AdminChannel, h.channelClose, m...)
+	g.GET(RouteV1AdminClients, h.clients, m...)
+	g.DELETE(RouteV1AdminClient, h.clientClose, m...)
+	g.GET(RouteV1AdminAwaits, h.awaits, m...)
+}
+
+// channels godoc
+// @Summary List channels
+// @Description Returns every channel open on this node with its clients, oldest first.
+// @Tags Admin
+// @Produce json
+// @Param X-API-Key header string true "Admin API key"
+// @Success 200 {array} server.SocketChannel
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Router /api/v1/admin/channels [GET].
+func (h *socketAdminHandler) channels(e echo.Context) error {
+	resp, err := h.svc.Channels(e.Request().Context())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
+
+// channel godoc
+// @Summary Inspect channel
+// @Description Returns a channel with its clients and the messages sent to it waiting on a reply.
+// @Tags Admin
+// @Produce json
+// @Param X-API-Key header string true "Admin API key"
+// @Param channelID path string true "Channel ID"
+// @Success 200 {object} server.SocketChannel
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Failure 404 {object} server.ClientError "returned if the channel isn't open on this node"
+// @Router /api/v1/admin/channels/{channelID} [GET].
+func (h *socketAdminHandler) channel(e echo.Context) error {
+	var args server.SocketChannelArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to bind request")
+	}
+	resp, err := h.svc.Channel(e.Request().Context(), args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
+
+// channelClose godoc
+// @Summary Close channel
+// @Description Disconnects every client on a channel, the channel is closed once they have left.
+// @Tags Admin
+// @Param X-API-Key header string true "Admin API key"
+// @Param channelID path string true "Channel ID"
+// @Success 204
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Failure 404 {object} server.ClientError "returned if the channel isn't open on this node"
+// @Router /api/v1/admin/channels/{channelID} [DELETE].
+func (h *socketAdminHandler) channelClose(e echo.Context) error {
+	var args server.SocketChannelArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to bind request")
+	}
+	if err := h.svc.ChannelClose(e.Request().Context(), args); err != nil {
+		return errors.WithStack(err)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// clients godoc
+// @Summary List clients
+// @Description Returns every client connected to this node, longest connected first.
+// @Tags Admin
+// @Produce json
+// @Param X-API-Key header string true "Admin API key"
+// @Success 200 {array} server.SocketClient
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Router /api/v1/admin/clients [GET].
+func (h *socketAdminHandler) clients(e echo.Context) error {
+	resp, err := h.svc.Clients(e.Request().Context())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
+
+// clientClose godoc
+// @Summary Close client
+// @Description Disconnects a client.
+// @Tags Admin
+// @Param X-API-Key header string true "Admin API key"
+// @Param clientID path string true "Client ID"
+// @Success 204
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Failure 404 {object} server.ClientError "returned if the client isn't connected to this node"
+// @Router /api/v1/admin/clients/{clientID} [DELETE].
+func (h *socketAdminHandler) clientClose(e echo.Context) error {
+	var args server.SocketClientArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to bind request")
+	}
+	if err := h.svc.ClientClose(e.Request().Context(), args); err != nil {
+		return errors.WithStack(err)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+// awaits godoc
+// @Summary List awaits
+// @Description Returns the messages sent to channels on this node waiting on a reply, oldest first.
+// @Tags Admin
+// @Produce json
+// @Param X-API-Key header string true "Admin API key"
+// @Success 200 {array} server.SocketAwait
+// @Failure 401 {object} server.ClientError "returned if the api key is missing or invalid"
+// @Router /api/v1/admin/awaits [GET].
+func (h *socketAdminHandler) awaits(e echo.Context) error {
+	resp, err := h.svc.Awaits(e.Request().Context())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,48 @@
+package sockets
+
+import (
+	"context"
+
+	proxy "github.com/bitcoin-sv/dpp-proxy"
+	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-dpp"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/sockets"
+	"github.com/theflyingcodr/sockets/server"
+)
+
+type paymentTermsCache struct {
+	svc proxy.PaymentTermsCacheService
+}
+
+// NewPaymentTermsCache will setup a new instance of a paymentTermsCache handler.
+func NewPaymentTermsCache(svc proxy.PaymentTermsCacheService) *paymentTermsCache {
+	return &paymentTermsCache{svc: svc}
+}
+
+// Register will register new handler/s with the socket server.
+func (p *paymentTermsCache) Register(s *server.SocketServer) {
+	s.RegisterChannelHandler("paymentterms.push", p.paymentTermsPush)
+	s.RegisterChannelHandler("paymentterms.invalidate", p.paymentTermsInvalidate)
+}
+
+// paymentTermsPush will cache the PaymentTerms sent by a payee wallet, these are then
+// served to payers without a round trip to the wallet until they expire.
+func (p *paymentTermsCache) paymentTermsPush(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+	var req envelope.JSONEnvelope
+	if err := msg.Bind(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to bind payment terms push")
+	}
+	if err := p.svc.PaymentTermsPush(ctx, dpp.PaymentTermsArgs{PaymentID: msg.ChannelID()}, req); err != nil {
+		return nil, err
+	}
+	return msg.NoContent()
+}
+
+// paymentTermsInvalidate will remove any cached PaymentTerms for the channel.
+func (p *paymentTermsCache) paymentTermsInvalidate(ctx context.Context, msg *sockets.Message) (*sockets.Message, error) {
+	if err := p.svc.PaymentTermsInvalidate(ctx, dpp.PaymentTermsArgs{PaymentID: msg.ChannelID()}); err != nil {
+		return nil, err
+	}
+	return msg.NoContent()
+}